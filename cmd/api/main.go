@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
@@ -13,8 +14,12 @@ import (
 	"github.com/joho/godotenv"
 	"github.com/ephy-lab/ai-db-assistant/internal/config"
 	"github.com/ephy-lab/ai-db-assistant/internal/database"
+	"github.com/ephy-lab/ai-db-assistant/internal/models"
 	"github.com/ephy-lab/ai-db-assistant/internal/router"
+	"github.com/ephy-lab/ai-db-assistant/pkg/dbpool"
+	"github.com/ephy-lab/ai-db-assistant/pkg/executor"
 	"github.com/ephy-lab/ai-db-assistant/pkg/logger"
+	"github.com/ephy-lab/ai-db-assistant/pkg/proxyclient"
 )
 
 func main() {
@@ -40,8 +45,72 @@ func main() {
 		logger.Fatal("Failed to run migrations", "error", err)
 	}
 
+	// One balancer is shared so that its circuit breakers and session
+	// assignments see all traffic
+	backendSpec := cfg.ProxyServerURLs
+	if backendSpec == "" {
+		backendSpec = cfg.ProxyServerURL
+	}
+	backends, err := proxyclient.ParseBackends(backendSpec)
+	if err != nil {
+		logger.Fatal("Invalid proxy backends", "error", err)
+	}
+	proxy, err := proxyclient.NewBalancer(backends, proxyclient.Options{
+		SigningSecret: cfg.ProxySigningSecret,
+		CAFile:        cfg.ProxyCAFile,
+		CertFile:      cfg.ProxyCertFile,
+		KeyFile:       cfg.ProxyKeyFile,
+	})
+	if err != nil {
+		logger.Fatal("Failed to configure proxy client", "error", err)
+	}
+
+	// Negotiate capabilities now so features are gated from the first
+	// request, then repeat with every health check
+	handshakeCtx, cancelHandshake := context.WithTimeout(context.Background(), 10*time.Second)
+	proxy.CheckHealth(handshakeCtx)
+	cancelHandshake()
+	for _, backend := range proxy.Status() {
+		if !backend.Healthy || backend.Capabilities == nil {
+			logger.Warn("Proxy backend unavailable; running degraded", "url", backend.URL, "error", backend.LastError)
+			continue
+		}
+		logger.Info("Proxy backend ready", "url", backend.URL, "version", backend.Capabilities.Version, "features", backend.Capabilities.Features, "legacy", backend.Capabilities.Legacy)
+	}
+
+	proxyCtx, stopProxy := context.WithCancel(context.Background())
+	defer stopProxy()
+	go proxy.Run(proxyCtx, 15*time.Second)
+	if cfg.ProxySigningSecret == "" {
+		logger.Warn("PROXY_SIGNING_SECRET is not set; requests to the proxy are not signed")
+	}
+
+	exec, err := executor.New(cfg.ExecutionBackend, proxy)
+	if err != nil {
+		logger.Fatal("Failed to configure execution backend", "error", err)
+	}
+	if cfg.ExecutionBackend == executor.BackendNative {
+		// Native connections do not survive a restart
+		if err := db.Model(&models.DBSession{}).Where("connected = ?", true).Update("connected", false).Error; err != nil {
+			logger.Fatal("Failed to reset database sessions", "error", err)
+		}
+	}
+	logger.Info("Execution backend configured", "backend", cfg.ExecutionBackend)
+
+	// Connection pools to project databases, evicted after 10 minutes idle
+	// and health-checked every minute
+	pools := dbpool.NewManager(10*time.Minute, time.Minute)
+	poolCtx, stopPools := context.WithCancel(context.Background())
+	defer stopPools()
+	go pools.Run(poolCtx)
+
 	// Initialize router
-	r := router.NewRouter(db, cfg)
+	r := router.NewRouter(db, cfg, proxy, exec, pools)
+
+	// Requests derive their context from baseCtx so that shutdown can cancel
+	// them, and with them any queries still running on the proxy
+	baseCtx, cancelRequests := context.WithCancel(context.Background())
+	defer cancelRequests()
 
 	// Create server
 	srv := &http.Server{
@@ -50,6 +119,9 @@ func main() {
 		ReadTimeout:  15 * time.Second,
 		WriteTimeout: 15 * time.Second,
 		IdleTimeout:  60 * time.Second,
+		BaseContext: func(net.Listener) context.Context {
+			return baseCtx
+		},
 	}
 
 	// Start server in a goroutine
@@ -70,9 +142,18 @@ func main() {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
+	// Cancel requests still running near the end of the grace period so
+	// their handlers can cancel proxy queries and respond before exit
+	stopCancel := time.AfterFunc(8*time.Second, cancelRequests)
+	defer stopCancel.Stop()
+
 	if err := srv.Shutdown(ctx); err != nil {
 		logger.Fatal("Server forced to shutdown", "error", err)
 	}
 
+	stopPools()
+	pools.Close()
+	stopProxy()
+
 	logger.Info("Server exited gracefully")
 }
\ No newline at end of file
@@ -2,7 +2,10 @@ package config
 
 import (
 	"os"
+	"strconv"
 	"time"
+
+	"github.com/ephy-lab/ai-db-assistant/pkg/schema"
 )
 
 type Config struct {
@@ -12,6 +15,26 @@ type Config struct {
 	JWTExpiry     time.Duration
 	Environment   string
 	ProxyServerURL string
+	MaskingSecret  string
+
+	// ProxyServerURLs lists several proxy servers as "url[=weight],...";
+	// when empty, ProxyServerURL is the only one
+	ProxyServerURLs string
+
+	// ExecutionBackend runs database operations through the proxy ("proxy")
+	// or directly from this server ("native")
+	ExecutionBackend string
+
+	// Proxy authentication: requests are HMAC-signed when ProxySigningSecret
+	// is set, and use mutual TLS when a client certificate is configured
+	ProxySigningSecret string
+	ProxyCAFile        string
+	ProxyCertFile      string
+	ProxyKeyFile       string
+
+	// SchemaContextBudget caps the size in bytes of the schema sent with
+	// each chat question; 0 sends the whole schema
+	SchemaContextBudget int
 }
 
 
@@ -23,6 +46,18 @@ func Load() *Config {
 		JWTExpiry:      time.Hour * 24, // 24 hours
 		Environment:    getEnv("ENVIRONMENT", "development"),
 		ProxyServerURL: getEnv("PROXY_SERVER_URL", "http://localhost:8000"),
+		MaskingSecret:  getEnv("MASKING_SECRET", "your_masking_secret"),
+
+		ProxyServerURLs: getEnv("PROXY_SERVER_URLS", ""),
+
+		ExecutionBackend: getEnv("EXECUTION_BACKEND", "proxy"),
+
+		ProxySigningSecret: getEnv("PROXY_SIGNING_SECRET", ""),
+		ProxyCAFile:        getEnv("PROXY_CA_FILE", ""),
+		ProxyCertFile:      getEnv("PROXY_CERT_FILE", ""),
+		ProxyKeyFile:       getEnv("PROXY_KEY_FILE", ""),
+
+		SchemaContextBudget: getEnvInt("SCHEMA_CONTEXT_BUDGET", schema.DefaultBudget),
 	}
 }
 
@@ -31,4 +66,13 @@ func getEnv(key, defaultValue string) string {
 		return value
 	}
 	return defaultValue
+}
+
+func getEnvInt(key string, defaultValue int) int {
+	if value, exists := os.LookupEnv(key); exists {
+		if n, err := strconv.Atoi(value); err == nil {
+			return n
+		}
+	}
+	return defaultValue
 }
\ No newline at end of file
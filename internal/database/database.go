@@ -30,11 +30,71 @@ func NewPostgresDB(dsn string) (*gorm.DB, error) {
 }
 
 func RunMigrations(db *gorm.DB) error {
-	return db.AutoMigrate(
+	err := db.AutoMigrate(
 		&models.User{},
 		&models.Project{},
 		&models.Permission{},
+		&models.QueryPolicy{},
+		&models.MaskingRule{},
+		&models.DBSession{},
+		&models.PermissionVersion{},
+		&models.SchemaSnapshot{},
 		&models.Query{},
 		&models.Message{},
 	)
-}
\ No newline at end of file
+	if err != nil {
+		return err
+	}
+
+	if err := backfillPermissionVersions(db); err != nil {
+		return err
+	}
+	return backfillQueryPolicies(db)
+}
+
+// backfillPermissionVersions records the current permissions of projects
+// created before permission history was kept as their first version, so
+// that they can be rolled back to
+func backfillPermissionVersions(db *gorm.DB) error {
+	var permissions []models.Permission
+	err := db.Preload("Project").
+		Where("NOT EXISTS (SELECT 1 FROM permission_versions v WHERE v.project_id = permissions.project_id)").
+		Find(&permissions).Error
+	if err != nil {
+		return fmt.Errorf("failed to find projects without permission history: %w", err)
+	}
+
+	for _, permission := range permissions {
+		version := models.PermissionVersion{
+			ProjectID: permission.ProjectID,
+			Version:   1,
+			ChangedBy: permission.Project.UserID,
+			Reason:    "Initial permissions",
+			NewValues: permission.Flags(),
+		}
+		if err := db.Create(&version).Error; err != nil {
+			return fmt.Errorf("failed to record initial permissions of project %d: %w", permission.ProjectID, err)
+		}
+	}
+	return nil
+}
+
+// backfillQueryPolicies gives projects created before query policies existed
+// the default guardrails, which new projects get when they are created
+func backfillQueryPolicies(db *gorm.DB) error {
+	var projectIDs []uint
+	err := db.Model(&models.Project{}).
+		Where("NOT EXISTS (SELECT 1 FROM query_policies p WHERE p.project_id = projects.id)").
+		Pluck("id", &projectIDs).Error
+	if err != nil {
+		return fmt.Errorf("failed to find projects without a query policy: %w", err)
+	}
+
+	for _, projectID := range projectIDs {
+		policy := models.NewQueryPolicy(projectID)
+		if err := db.Create(&policy).Error; err != nil {
+			return fmt.Errorf("failed to create the query policy of project %d: %w", projectID, err)
+		}
+	}
+	return nil
+}
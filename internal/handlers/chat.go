@@ -10,20 +10,28 @@ import (
 	"github.com/ephy-lab/ai-db-assistant/internal/config"
 	"github.com/ephy-lab/ai-db-assistant/internal/middleware"
 	"github.com/ephy-lab/ai-db-assistant/internal/models"
+	"github.com/ephy-lab/ai-db-assistant/pkg/dbpool"
+	"github.com/ephy-lab/ai-db-assistant/pkg/logger"
 	"github.com/ephy-lab/ai-db-assistant/pkg/proxyclient"
 	"github.com/ephy-lab/ai-db-assistant/pkg/response"
+	"github.com/ephy-lab/ai-db-assistant/pkg/schema"
+	"github.com/ephy-lab/ai-db-assistant/pkg/sqlparser"
 	"gorm.io/gorm"
 )
 
 type ChatHandler struct {
-	db          *gorm.DB
-	proxyClient *proxyclient.Client
+	db           *gorm.DB
+	proxyClient  proxyclient.Proxy
+	pools        *dbpool.Manager
+	schemaBudget int
 }
 
-func NewChatHandler(db *gorm.DB, cfg *config.Config) *ChatHandler {
+func NewChatHandler(db *gorm.DB, cfg *config.Config, proxy proxyclient.Proxy, pools *dbpool.Manager) *ChatHandler {
 	return &ChatHandler{
-		db:          db,
-		proxyClient: proxyclient.NewClient(cfg.ProxyServerURL),
+		db:           db,
+		proxyClient:  proxy,
+		pools:        pools,
+		schemaBudget: cfg.SchemaContextBudget,
 	}
 }
 
@@ -58,7 +66,7 @@ func (h *ChatHandler) SendMessage(w http.ResponseWriter, r *http.Request) {
 
 	// Verify project ownership and load with permission
 	var project models.Project
-	if err := h.db.Preload("Permission").Where("id = ? AND user_id = ?", projectID, userID).First(&project).Error; err != nil {
+	if err := h.db.Preload("Permission").Preload("QueryPolicy").Where("id = ? AND user_id = ?", projectID, userID).First(&project).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			response.Error(w, http.StatusNotFound, "Project not found")
 			return
@@ -90,9 +98,19 @@ func (h *ChatHandler) SendMessage(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Ground the generated SQL in the tables most relevant to the question.
+	// Without a schema the AI guesses table names, but it can still answer.
+	var dbSchema string
+	if snapshot, err := projectSchema(r.Context(), h.db, h.pools, &project); err != nil {
+		logger.Warn("Generating SQL without schema", "project_id", project.ID, "error", err)
+	} else {
+		selection := schema.Select(&snapshot.Schema, req.Content, h.schemaBudget)
+		dbSchema = selection.Text
+		logger.Debug("Selected schema for question", "project_id", project.ID, "tables", selection.Tables, "omitted", selection.Omitted)
+	}
+
 	// Generate AI response using proxy client
-	// TODO: Optionally fetch and include database schema
-	proxyResp, err := h.proxyClient.GenerateSQL(req.Content, project.DatabaseType, "")
+	proxyResp, err := h.proxyClient.GenerateSQLContext(r.Context(), req.Content, project.DatabaseType, dbSchema)
 	if err != nil {
 		// Save error message
 		aiMessage := models.Message{
@@ -102,7 +120,7 @@ func (h *ChatHandler) SendMessage(w http.ResponseWriter, r *http.Request) {
 		}
 		h.db.Create(&aiMessage)
 
-		response.Error(w, http.StatusInternalServerError, "Failed to generate SQL: "+err.Error())
+		response.Error(w, proxyErrorStatus(err), "Failed to generate SQL: "+err.Error())
 		return
 	}
 
@@ -132,10 +150,11 @@ func (h *ChatHandler) SendMessage(w http.ResponseWriter, r *http.Request) {
 	// If query was generated, log it to queries table
 	if aiResp.Query != nil {
 		query := models.Query{
-			ProjectID: uint(projectID),
-			Query:     *aiResp.Query,
-			Status:    "generated",
-			Result:    "Query generated but not executed yet",
+			ProjectID:   uint(projectID),
+			Query:       queryLogText(&project, *aiResp.Query),
+			Fingerprint: sqlparser.Fingerprint(*aiResp.Query, sqlparser.ParseDialect(project.DatabaseType)),
+			Status:      "generated",
+			Result:      "Query generated but not executed yet",
 		}
 		h.db.Create(&query)
 	}
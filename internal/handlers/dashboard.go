@@ -1,23 +1,27 @@
 package handlers
 
 import (
-	"database/sql"
+	"context"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/ephy-lab/ai-db-assistant/internal/middleware"
 	"github.com/ephy-lab/ai-db-assistant/internal/models"
+	"github.com/ephy-lab/ai-db-assistant/pkg/dbpool"
 	"github.com/ephy-lab/ai-db-assistant/pkg/response"
+	"github.com/ephy-lab/ai-db-assistant/pkg/sqlparser"
 	"gorm.io/gorm"
 )
 
 type DashboardHandler struct {
-	db *gorm.DB
+	db    *gorm.DB
+	pools *dbpool.Manager
 }
 
-func NewDashboardHandler(db *gorm.DB) *DashboardHandler {
-	return &DashboardHandler{db: db}
+func NewDashboardHandler(db *gorm.DB, pools *dbpool.Manager) *DashboardHandler {
+	return &DashboardHandler{db: db, pools: pools}
 }
 
 type ProjectSummary struct {
@@ -51,7 +55,7 @@ func (h *DashboardHandler) GetProjectSummary(w http.ResponseWriter, r *http.Requ
 
 	// Verify project ownership
 	var project models.Project
-	if err := h.db.Where("id = ? AND user_id = ?", projectID, userID).First(&project).Error; err != nil {
+	if err := h.db.Preload("QueryPolicy").Where("id = ? AND user_id = ?", projectID, userID).First(&project).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			response.Error(w, http.StatusNotFound, "Project not found")
 			return
@@ -69,7 +73,7 @@ func (h *DashboardHandler) GetProjectSummary(w http.ResponseWriter, r *http.Requ
 	h.db.Where("project_id = ?", projectID).Order("created_at desc").Limit(10).Find(&recentQueries)
 
 	// Get table count from connected database
-	tableCount := h.getTableCount(project.DatabaseType, project.ConnectionString)
+	tableCount := h.getTableCount(r.Context(), &project)
 
 	summary := ProjectSummary{
 		ProjectID:     project.ID,
@@ -83,6 +87,102 @@ func (h *DashboardHandler) GetProjectSummary(w http.ResponseWriter, r *http.Requ
 	response.JSON(w, http.StatusOK, summary)
 }
 
+// QueryStats aggregates the executions of one query fingerprint
+type QueryStats struct {
+	Fingerprint     string    `json:"fingerprint"`
+	NormalizedQuery string    `json:"normalized_query"`
+	QueryType       string    `json:"query_type"`
+	Count           int64     `json:"count"`
+	ErrorCount      int64     `json:"error_count"`
+	ErrorRate       float64   `json:"error_rate"`
+	AvgTime         float64   `json:"avg_execution_time"` // in milliseconds
+	P95Time         float64   `json:"p95_execution_time"` // in milliseconds
+	LastExecutedAt  time.Time `json:"last_executed_at"`
+	SampleQuery     string    `json:"-"`
+}
+
+// queryStatsOrder maps the sort query parameter to an ORDER BY clause
+var queryStatsOrder = map[string]string{
+	"count":      "count DESC",
+	"avg_time":   "avg_time DESC",
+	"p95_time":   "p95_time DESC",
+	"error_rate": "error_rate DESC, count DESC",
+}
+
+// GetQueryStats groups a project's executed queries by fingerprint
+func (h *DashboardHandler) GetQueryStats(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r)
+	if !ok {
+		response.Error(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	vars := mux.Vars(r)
+	projectID, err := strconv.ParseUint(vars["id"], 10, 32)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "Invalid project ID")
+		return
+	}
+
+	// Verify project ownership
+	var project models.Project
+	if err := h.db.Where("id = ? AND user_id = ?", projectID, userID).First(&project).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			response.Error(w, http.StatusNotFound, "Project not found")
+			return
+		}
+		response.Error(w, http.StatusInternalServerError, "Failed to fetch project")
+		return
+	}
+
+	sort := r.URL.Query().Get("sort")
+	if sort == "" {
+		sort = "count"
+	}
+	order, ok := queryStatsOrder[sort]
+	if !ok {
+		response.Error(w, http.StatusBadRequest, "Sort must be one of count, avg_time, p95_time or error_rate")
+		return
+	}
+
+	limit := 20
+	if value := r.URL.Query().Get("limit"); value != "" {
+		limit, err = strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > 100 {
+			response.Error(w, http.StatusBadRequest, "Limit must be between 1 and 100")
+			return
+		}
+	}
+
+	// Only executed queries have an outcome and an execution time
+	stats := []QueryStats{}
+	if err := h.db.Model(&models.Query{}).
+		Select(`fingerprint,
+			MAX(query_type) AS query_type,
+			COUNT(*) AS count,
+			COUNT(*) FILTER (WHERE status = 'error') AS error_count,
+			COUNT(*) FILTER (WHERE status = 'error')::float / COUNT(*) AS error_rate,
+			AVG(execution_time) AS avg_time,
+			percentile_cont(0.95) WITHIN GROUP (ORDER BY execution_time) AS p95_time,
+			MAX(created_at) AS last_executed_at,
+			MAX(query) AS sample_query`).
+		Where("project_id = ? AND fingerprint <> '' AND status IN ?", projectID, []string{"success", "error"}).
+		Group("fingerprint").
+		Order(order).
+		Limit(limit).
+		Scan(&stats).Error; err != nil {
+		response.Error(w, http.StatusInternalServerError, "Failed to fetch query statistics")
+		return
+	}
+
+	dialect := sqlparser.ParseDialect(project.DatabaseType)
+	for i := range stats {
+		stats[i].NormalizedQuery = sqlparser.Normalize(stats[i].SampleQuery, dialect)
+	}
+
+	response.JSON(w, http.StatusOK, stats)
+}
+
 func (h *DashboardHandler) GetUserDashboard(w http.ResponseWriter, r *http.Request) {
 	userID, ok := middleware.GetUserID(r)
 	if !ok {
@@ -110,37 +210,73 @@ func (h *DashboardHandler) GetUserDashboard(w http.ResponseWriter, r *http.Reque
 	response.JSON(w, http.StatusOK, dashboard)
 }
 
-func (h *DashboardHandler) getTableCount(dbType, connString string) int {
-	var db *sql.DB
-	var err error
-
-	switch dbType {
-	case "postgresql":
-		db, err = sql.Open("postgres", connString)
-	case "mysql":
-		db, err = sql.Open("mysql", connString)
-	default:
-		return 0
-	}
+// tableCountTimeout bounds the table count shown in the project summary
+const tableCountTimeout = 5 * time.Second
 
+func (h *DashboardHandler) getTableCount(ctx context.Context, project *models.Project) int {
+	db, err := h.pools.Get(project.ID, project.DatabaseType, project.ConnectionString, projectPoolLimits(project))
 	if err != nil {
 		return 0
 	}
-	defer db.Close()
 
-	var count int
 	var query string
-
-	if dbType == "postgresql" {
+	if project.DatabaseType == "postgresql" {
 		query = "SELECT COUNT(*) FROM information_schema.tables WHERE table_schema = 'public' AND table_type = 'BASE TABLE'"
 	} else {
 		query = "SELECT COUNT(*) FROM information_schema.tables WHERE table_schema = DATABASE() AND table_type = 'BASE TABLE'"
 	}
 
-	err = db.QueryRow(query).Scan(&count)
-	if err != nil {
+	ctx, cancel := context.WithTimeout(ctx, tableCountTimeout)
+	defer cancel()
+
+	var count int
+	if err := db.QueryRowContext(ctx, query).Scan(&count); err != nil {
 		return 0
 	}
 
 	return count
-}
\ No newline at end of file
+}
+
+// GetPoolStats reports the state of the project's connection pool
+func (h *DashboardHandler) GetPoolStats(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r)
+	if !ok {
+		response.Error(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	vars := mux.Vars(r)
+	projectID, err := strconv.ParseUint(vars["id"], 10, 32)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "Invalid project ID")
+		return
+	}
+
+	var project models.Project
+	if err := h.db.Where("id = ? AND user_id = ?", projectID, userID).First(&project).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			response.Error(w, http.StatusNotFound, "Project not found")
+			return
+		}
+		response.Error(w, http.StatusInternalServerError, "Failed to fetch project")
+		return
+	}
+
+	stats, ok := h.pools.Stats(project.ID)
+	if !ok {
+		response.Error(w, http.StatusNotFound, "Project has no open connection pool")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, stats)
+}
+
+// projectPoolLimits returns the pool limits for a project with its query
+// policy preloaded
+func projectPoolLimits(project *models.Project) dbpool.Limits {
+	var limits dbpool.Limits
+	if project.QueryPolicy != nil {
+		limits.MaxOpenConns = project.QueryPolicy.MaxConnections
+	}
+	return limits
+}
@@ -2,6 +2,7 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -12,33 +13,71 @@ import (
 	"github.com/ephy-lab/ai-db-assistant/internal/config"
 	"github.com/ephy-lab/ai-db-assistant/internal/middleware"
 	"github.com/ephy-lab/ai-db-assistant/internal/models"
+	"github.com/ephy-lab/ai-db-assistant/pkg/executor"
 	"github.com/ephy-lab/ai-db-assistant/pkg/proxyclient"
 	"github.com/ephy-lab/ai-db-assistant/pkg/response"
+	"github.com/ephy-lab/ai-db-assistant/pkg/sqllint"
+	"github.com/ephy-lab/ai-db-assistant/pkg/sqlparams"
 	"github.com/ephy-lab/ai-db-assistant/pkg/sqlparser"
 	"gorm.io/gorm"
 )
 
 type DatabaseHandler struct {
-	db          *gorm.DB
-	proxyClient *proxyclient.Client
+	db            *gorm.DB
+	executor      executor.Executor
+	maskingSecret string
 }
 
-func NewDatabaseHandler(db *gorm.DB, cfg *config.Config) *DatabaseHandler {
+func NewDatabaseHandler(db *gorm.DB, cfg *config.Config, exec executor.Executor) *DatabaseHandler {
 	return &DatabaseHandler{
-		db:          db,
-		proxyClient: proxyclient.NewClient(cfg.ProxyServerURL),
+		db:            db,
+		executor:      exec,
+		maskingSecret: cfg.MaskingSecret,
 	}
 }
 
 type ExecuteSQLRequest struct {
-	Query  string `json:"query"`
-	DryRun bool   `json:"dry_run,omitempty"`
+	Query               string                     `json:"query"`
+	Params              map[string]sqlparams.Param `json:"params,omitempty"` // keyed by name for :name, by position ("1", "2", ...) for ? and $n
+	DryRun              bool                       `json:"dry_run,omitempty"`
+	ConfirmAffectedRows *int                       `json:"confirm_affected_rows,omitempty"`
+	Stream              bool                       `json:"stream,omitempty"` // stream rows as NDJSON instead of one JSON response
 }
 
 type ValidateSQLRequest struct {
 	Query string `json:"query"`
 }
 
+// ValidateSQLResult combines the proxy's EXPLAIN result with local lint warnings
+type ValidateSQLResult struct {
+	*proxyclient.ValidateSQLResponse
+	Warnings []sqllint.Warning `json:"warnings"`
+}
+
+// ExecuteSQLResult is the proxy's result for one statement. Truncated is set
+// when a read returned more than the project's max_rows and the extra rows
+// were dropped; MaskedValues counts values changed by masking rules.
+type ExecuteSQLResult struct {
+	*proxyclient.ExecuteSQLResponse
+	Truncated    bool `json:"truncated,omitempty"`
+	MaxRows      int  `json:"max_rows,omitempty"`
+	MaskedValues int  `json:"masked_values,omitempty"`
+}
+
+// StatementResult is the outcome of one statement in a multi-statement batch
+type StatementResult struct {
+	Index     int               `json:"index"`
+	Query     string            `json:"query"`
+	QueryType string            `json:"query_type"`
+	Result    *ExecuteSQLResult `json:"result,omitempty"`
+	Error     string            `json:"error,omitempty"`
+}
+
+// BatchExecuteResponse holds per-statement results in execution order
+type BatchExecuteResponse struct {
+	Results []StatementResult `json:"results"`
+}
+
 // ConnectDB establishes a connection to the project's database
 func (h *DatabaseHandler) ConnectDB(w http.ResponseWriter, r *http.Request) {
 	userID, ok := middleware.GetUserID(r)
@@ -65,14 +104,21 @@ func (h *DatabaseHandler) ConnectDB(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Connect to database via proxy
-	resp, err := h.proxyClient.ConnectDB(project.DatabaseType, project.ConnectionString)
+	// Connect to database via proxy in the project's session, replacing any
+	// previous connection of that session
+	session, err := h.loadSession(&project)
 	if err != nil {
-		response.Error(w, http.StatusInternalServerError, "Failed to connect to database: "+err.Error())
+		response.Error(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 
-	response.JSON(w, http.StatusOK, resp)
+	resp, err := h.connectSession(r.Context(), &project, session)
+	if err != nil {
+		response.Error(w, proxyErrorStatus(err), "Failed to connect to database: "+err.Error())
+		return
+	}
+
+	response.JSON(w, http.StatusOK, ConnectDBResult{ConnectDBResponse: resp, SessionID: session.SessionID})
 }
 
 // DisconnectDB closes the database connection
@@ -101,13 +147,25 @@ func (h *DatabaseHandler) DisconnectDB(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Disconnect from database via proxy
-	resp, err := h.proxyClient.DisconnectDB()
+	// Disconnect the project's session via proxy
+	session, err := h.loadSession(&project)
 	if err != nil {
-		response.Error(w, http.StatusInternalServerError, "Failed to disconnect from database: "+err.Error())
+		response.Error(w, http.StatusInternalServerError, err.Error())
 		return
 	}
 
+	resp, err := h.executor.DisconnectDBContext(proxyclient.WithSessionID(r.Context(), session.SessionID))
+	if err != nil {
+		response.Error(w, proxyErrorStatus(err), "Failed to disconnect from database: "+err.Error())
+		return
+	}
+
+	now := time.Now()
+	session.Connected = false
+	session.ProxyBackend = ""
+	session.DisconnectedAt = &now
+	h.db.Save(session)
+
 	response.JSON(w, http.StatusOK, resp)
 }
 
@@ -128,7 +186,7 @@ func (h *DatabaseHandler) ExecuteSQL(w http.ResponseWriter, r *http.Request) {
 
 	// Verify project ownership and load permissions
 	var project models.Project
-	if err := h.db.Preload("Permission").Where("id = ? AND user_id = ?", projectID, userID).First(&project).Error; err != nil {
+	if err := h.db.Preload("Permission").Preload("QueryPolicy").Preload("MaskingRules").Where("id = ? AND user_id = ?", projectID, userID).First(&project).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			response.Error(w, http.StatusNotFound, "Project not found")
 			return
@@ -148,39 +206,206 @@ func (h *DatabaseHandler) ExecuteSQL(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Check permissions based on query type
-	needsDDL, needsWrite, needsRead, needsDelete := sqlparser.RequiresPermission(req.Query)
+	// Split into statements and enforce the project's multi-statement policy
+	dialect := sqlparser.ParseDialect(project.DatabaseType)
+	statements := sqlparser.SplitStatements(req.Query, dialect)
+	if len(statements) == 0 {
+		response.Error(w, http.StatusBadRequest, "Query is required")
+		return
+	}
+
+	if len(statements) > 1 && (project.QueryPolicy == nil || !project.QueryPolicy.AllowMultiStatement) {
+		response.Error(w, http.StatusForbidden, "Multiple statements are not allowed for this project")
+		return
+	}
+
+	if req.Stream && (len(statements) > 1 || req.DryRun) {
+		response.Error(w, http.StatusBadRequest, "Streaming is only supported for a single statement without dry_run")
+		return
+	}
+
+	// Reject features the proxy does not offer before touching the database
+	if req.DryRun && !proxyclient.Supports(h.executor, proxyclient.FeatureDryRun) {
+		response.Error(w, http.StatusNotImplemented, "Dry runs are not supported by the proxy")
+		return
+	}
+	if req.Stream && !proxyclient.Supports(h.executor, proxyclient.FeatureStreaming) {
+		response.Error(w, http.StatusNotImplemented, "Streaming is not supported by the proxy")
+		return
+	}
+
+	// Bind placeholder values; they travel to the proxy separately from the SQL text
+	var params []proxyclient.QueryParam
+	if len(req.Params) > 0 {
+		if len(statements) > 1 {
+			response.Error(w, http.StatusBadRequest, "Parameters are only supported for single-statement queries")
+			return
+		}
+
+		bound, err := sqlparams.Bind(statements[0].Text, dialect, req.Params)
+		if err != nil {
+			response.Error(w, http.StatusBadRequest, "Invalid parameters: "+err.Error())
+			return
+		}
+
+		statements[0].Text = bound.Query
+		for _, arg := range bound.Args {
+			params = append(params, proxyclient.QueryParam{Type: string(arg.Type), Value: arg.Value})
+		}
+	}
+
+	// Check permissions for every statement; the query needs their union
+	var required sqlparser.RequiredPermissions
+	for _, statement := range statements {
+		required.Merge(sqlparser.RequiresPermission(statement.Text, dialect))
+	}
+
+	// Statements that cannot be classified are denied regardless of permissions
+	if required.Unknown {
+		response.Error(w, http.StatusForbidden, "Unrecognized statements are not allowed")
+		return
+	}
 
 	if project.Permission != nil {
-		if needsDDL && !project.Permission.AllowDDL {
+		if required.DDL && !project.Permission.AllowDDL {
 			response.Error(w, http.StatusForbidden, "DDL operations are not allowed for this project")
 			return
 		}
-		if needsWrite && !project.Permission.AllowWrite {
+		if required.Write && !project.Permission.AllowWrite {
 			response.Error(w, http.StatusForbidden, "Write operations are not allowed for this project")
 			return
 		}
-		if needsRead && !project.Permission.AllowRead {
+		if required.Read && !project.Permission.AllowRead {
 			response.Error(w, http.StatusForbidden, "Read operations are not allowed for this project")
 			return
 		}
-		if needsDelete && !project.Permission.AllowDelete {
+		if required.Delete && !project.Permission.AllowDelete {
 			response.Error(w, http.StatusForbidden, "Delete operations are not allowed for this project")
 			return
 		}
+		if required.DCL && !project.Permission.AllowDCL {
+			response.Error(w, http.StatusForbidden, "Access control statements are not allowed for this project")
+			return
+		}
+		if required.Transaction && !project.Permission.AllowTransaction {
+			response.Error(w, http.StatusForbidden, "Transaction control statements are not allowed for this project")
+			return
+		}
+		if required.Call && !project.Permission.AllowCall {
+			response.Error(w, http.StatusForbidden, "Procedure calls are not allowed for this project")
+			return
+		}
+		if required.BulkLoad && !project.Permission.AllowBulkLoad {
+			response.Error(w, http.StatusForbidden, "Bulk load operations are not allowed for this project")
+			return
+		}
+		if required.Admin && !project.Permission.AllowAdmin {
+			response.Error(w, http.StatusForbidden, "Administrative statements are not allowed for this project")
+			return
+		}
+	}
+
+	// Refuse queries that read masked columns in a way masking cannot follow
+	masker, err := projectMasker(&project, h.maskingSecret)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "Invalid masking rules: "+err.Error())
+		return
+	}
+	for _, statement := range statements {
+		if _, err := masker.Plan(statement.Text, dialect); err != nil {
+			response.Error(w, http.StatusForbidden, "Query not allowed: "+err.Error())
+			return
+		}
+	}
+
+	// Run every proxy call against the project's own database connection
+	ctx, err := h.projectSession(r.Context(), &project)
+	if err != nil {
+		response.Error(w, proxyErrorStatus(err), err.Error())
+		return
+	}
+
+	// Apply guardrails for UPDATE and DELETE statements
+	if !req.DryRun && !h.enforceQueryPolicy(ctx, w, &project, statements, params, req.ConfirmAffectedRows) {
+		return
+	}
+
+	if req.Stream {
+		h.streamStatement(ctx, w, &project, statements[0], params)
+		return
+	}
+
+	if len(statements) == 1 {
+		resp, err := h.executeStatement(ctx, &project, statements[0], params, req.DryRun)
+		if err != nil {
+			response.Error(w, proxyErrorStatus(err), "Failed to execute query: "+err.Error())
+			return
+		}
+
+		response.JSON(w, http.StatusOK, resp)
+		return
+	}
+
+	// Execute the batch in order, stopping at the first failure
+	var batch BatchExecuteResponse
+	for i, statement := range statements {
+		result := StatementResult{
+			Index:     i,
+			Query:     statement.Text,
+			QueryType: string(statement.Type),
+		}
+
+		resp, err := h.executeStatement(ctx, &project, statement, nil, req.DryRun)
+		if err != nil {
+			result.Error = err.Error()
+			batch.Results = append(batch.Results, result)
+
+			response.ErrorWithData(w, proxyErrorStatus(err), fmt.Sprintf("Failed to execute statement %d: %s", i+1, err.Error()), batch)
+			return
+		}
+
+		result.Result = resp
+		batch.Results = append(batch.Results, result)
+	}
+
+	response.JSON(w, http.StatusOK, batch)
+}
+
+// executeStatement runs a single statement via the proxy and logs it to the
+// query history. Only the statement text is logged, never parameter values.
+func (h *DatabaseHandler) executeStatement(ctx context.Context, project *models.Project, statement sqlparser.Statement, params []proxyclient.QueryParam, dryRun bool) (*ExecuteSQLResult, error) {
+	dialect := sqlparser.ParseDialect(project.DatabaseType)
+
+	masker, err := projectMasker(project, h.maskingSecret)
+	if err != nil {
+		return nil, fmt.Errorf("invalid masking rules: %w", err)
+	}
+	plan, err := masker.Plan(statement.Text, dialect)
+	if err != nil {
+		return nil, err
+	}
+
+	// Cap reads at max_rows, fetching one extra row to detect truncation
+	maxRows := 0
+	query := statement.Text
+	if project.QueryPolicy != nil && project.QueryPolicy.MaxRows > 0 && statement.Type == sqlparser.QueryTypeSelect {
+		maxRows = project.QueryPolicy.MaxRows
+		query = sqlparser.LimitRows(query, dialect, maxRows+1)
 	}
 
-	// Execute query via proxy
 	startTime := time.Now()
-	resp, err := h.proxyClient.ExecuteSQL(req.Query, req.DryRun)
+	resp, err := h.executor.ExecuteSQLWithParamsContext(ctx, query, params, dryRun)
+	if h.reconnectLost(ctx, project, err) {
+		resp, err = h.executor.ExecuteSQLWithParamsContext(ctx, query, params, dryRun)
+	}
 	executionTime := time.Since(startTime).Milliseconds()
 
 	// Log query execution
-	queryType := string(sqlparser.GetQueryType(req.Query))
 	queryLog := models.Query{
-		ProjectID:     uint(projectID),
-		Query:         req.Query,
-		QueryType:     queryType,
+		ProjectID:     project.ID,
+		Query:         queryLogText(project, statement.Text),
+		QueryType:     string(statement.Type),
+		Fingerprint:   sqlparser.Fingerprint(statement.Text, dialect),
 		ExecutionTime: int(executionTime),
 	}
 
@@ -188,9 +413,20 @@ func (h *DatabaseHandler) ExecuteSQL(w http.ResponseWriter, r *http.Request) {
 		queryLog.Status = "error"
 		queryLog.Error = err.Error()
 		h.db.Create(&queryLog)
+		return nil, err
+	}
 
-		response.Error(w, http.StatusInternalServerError, "Failed to execute query: "+err.Error())
-		return
+	result := &ExecuteSQLResult{ExecuteSQLResponse: resp}
+	if maxRows > 0 && len(resp.Rows) > maxRows {
+		resp.Rows = resp.Rows[:maxRows]
+		resp.RowCount = maxRows
+		result.Truncated = true
+		result.MaxRows = maxRows
+	}
+
+	// Mask sensitive values before they are returned or stored in the history
+	if len(resp.Rows) > 0 {
+		result.MaskedValues = plan.MaskRows(resp.Columns, resp.Rows)
 	}
 
 	// Update query log with results
@@ -208,7 +444,81 @@ func (h *DatabaseHandler) ExecuteSQL(w http.ResponseWriter, r *http.Request) {
 
 	h.db.Create(&queryLog)
 
-	response.JSON(w, http.StatusOK, resp)
+	return result, nil
+}
+
+// enforceQueryPolicy applies the project's guardrails to statements that
+// update or delete rows, wherever the UPDATE or DELETE appears. It writes
+// an error response and returns false when the query must not be executed.
+// params are only set for single-statement queries.
+func (h *DatabaseHandler) enforceQueryPolicy(ctx context.Context, w http.ResponseWriter, project *models.Project, statements []sqlparser.Statement, params []proxyclient.QueryParam, confirmAffectedRows *int) bool {
+	policy := project.QueryPolicy
+	if policy == nil {
+		defaults := models.NewQueryPolicy(project.ID)
+		policy = &defaults
+	}
+
+	dialect := sqlparser.ParseDialect(project.DatabaseType)
+
+	var guarded []sqlparser.Statement
+	for _, statement := range statements {
+		if sqlparser.ModifiesRows(statement.Text, dialect) {
+			guarded = append(guarded, statement)
+		}
+	}
+	if len(guarded) == 0 {
+		return true
+	}
+
+	if policy.RequireWhereClause {
+		for _, statement := range guarded {
+			if !sqlparser.HasWhereClause(statement.Text, dialect) {
+				response.Error(w, http.StatusForbidden, "UPDATE and DELETE statements must include a WHERE clause for this project")
+				return false
+			}
+		}
+	}
+
+	if policy.MaxAffectedRows <= 0 && !policy.RequireConfirmation {
+		return true
+	}
+
+	if !proxyclient.Supports(h.executor, proxyclient.FeatureDryRun) {
+		response.Error(w, http.StatusNotImplemented, "Affected row limits and confirmation need dry runs, which the proxy does not support")
+		return false
+	}
+
+	// Estimate the number of affected rows with a dry run of each statement
+	total := 0
+	for _, statement := range guarded {
+		estimate, err := h.executor.ExecuteSQLWithParamsContext(ctx, statement.Text, params, true)
+		if h.reconnectLost(ctx, project, err) {
+			estimate, err = h.executor.ExecuteSQLWithParamsContext(ctx, statement.Text, params, true)
+		}
+		if err != nil {
+			response.Error(w, proxyErrorStatus(err), "Failed to estimate affected rows: "+err.Error())
+			return false
+		}
+
+		if policy.MaxAffectedRows > 0 && estimate.AffectedRows > policy.MaxAffectedRows {
+			response.Error(w, http.StatusForbidden, fmt.Sprintf("Statement would affect %d rows, exceeding the project limit of %d", estimate.AffectedRows, policy.MaxAffectedRows))
+			return false
+		}
+		total += estimate.AffectedRows
+	}
+
+	if policy.RequireConfirmation {
+		if confirmAffectedRows == nil {
+			response.Error(w, http.StatusConflict, fmt.Sprintf("Query would affect %d rows; resend with confirm_affected_rows set to %d to proceed", total, total))
+			return false
+		}
+		if *confirmAffectedRows != total {
+			response.Error(w, http.StatusConflict, fmt.Sprintf("confirm_affected_rows (%d) does not match the estimated %d affected rows", *confirmAffectedRows, total))
+			return false
+		}
+	}
+
+	return true
 }
 
 // ValidateSQL validates a SQL query without executing it
@@ -254,14 +564,31 @@ func (h *DatabaseHandler) ValidateSQL(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if !proxyclient.Supports(h.executor, proxyclient.FeatureExplain) {
+		response.Error(w, http.StatusNotImplemented, "Query validation is not supported by the proxy")
+		return
+	}
+
+	ctx, err := h.projectSession(r.Context(), &project)
+	if err != nil {
+		response.Error(w, proxyErrorStatus(err), err.Error())
+		return
+	}
+
 	// Validate query via proxy
-	resp, err := h.proxyClient.ValidateSQL(req.Query)
+	resp, err := h.executor.ValidateSQLContext(ctx, req.Query)
+	if h.reconnectLost(ctx, &project, err) {
+		resp, err = h.executor.ValidateSQLContext(ctx, req.Query)
+	}
 	if err != nil {
-		response.Error(w, http.StatusInternalServerError, "Failed to validate query: "+err.Error())
+		response.Error(w, proxyErrorStatus(err), "Failed to validate query: "+err.Error())
 		return
 	}
 
-	response.JSON(w, http.StatusOK, resp)
+	response.JSON(w, http.StatusOK, ValidateSQLResult{
+		ValidateSQLResponse: resp,
+		Warnings:            sqllint.Lint(req.Query, sqlparser.ParseDialect(project.DatabaseType)),
+	})
 }
 
 // GetDBInfo gets database connection information
@@ -290,12 +617,24 @@ func (h *DatabaseHandler) GetDBInfo(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Report on the project's session without connecting it
+	session, err := h.loadSession(&project)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
 	// Get database info via proxy
-	resp, err := h.proxyClient.GetDBInfo()
+	resp, err := h.executor.GetDBInfoContext(proxyclient.WithSessionID(r.Context(), session.SessionID))
 	if err != nil {
-		response.Error(w, http.StatusInternalServerError, "Failed to get database info: "+err.Error())
+		response.Error(w, proxyErrorStatus(err), "Failed to get database info: "+err.Error())
 		return
 	}
 
+	// Let the next request reconnect a session the proxy no longer has
+	if !resp.Connected && session.Connected {
+		h.db.Model(session).Update("connected", false)
+	}
+
 	response.JSON(w, http.StatusOK, resp)
 }
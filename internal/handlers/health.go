@@ -0,0 +1,91 @@
+// internal/handlers/health.go
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/ephy-lab/ai-db-assistant/internal/config"
+	"github.com/ephy-lab/ai-db-assistant/pkg/executor"
+	"github.com/ephy-lab/ai-db-assistant/pkg/proxyclient"
+	"github.com/ephy-lab/ai-db-assistant/pkg/response"
+)
+
+// Health statuses
+const (
+	HealthOK       = "ok"
+	HealthDegraded = "degraded"
+)
+
+// gatedFeatures are the optional features reported by the health check
+var gatedFeatures = []string{
+	proxyclient.FeatureDryRun,
+	proxyclient.FeatureExplain,
+	proxyclient.FeatureStreaming,
+}
+
+type HealthHandler struct {
+	proxy            proxyclient.Proxy
+	executor         executor.Executor
+	executionBackend string
+}
+
+func NewHealthHandler(cfg *config.Config, proxy proxyclient.Proxy, exec executor.Executor) *HealthHandler {
+	return &HealthHandler{
+		proxy:            proxy,
+		executor:         exec,
+		executionBackend: cfg.ExecutionBackend,
+	}
+}
+
+// HealthResponse reports whether the server runs degraded and why
+type HealthResponse struct {
+	Status           string                      `json:"status"`
+	Reasons          []string                    `json:"reasons,omitempty"`
+	ExecutionBackend string                      `json:"execution_backend"`
+	Features         map[string]bool             `json:"features"`
+	ProxyBackends    []proxyclient.BackendStatus `json:"proxy_backends,omitempty"`
+}
+
+// Health reports the server's status. The server is degraded while a proxy
+// backend is unhealthy or an optional feature is unavailable; it still
+// answers 200 so that it is not restarted for the proxy's faults.
+func (h *HealthHandler) Health(w http.ResponseWriter, r *http.Request) {
+	health := HealthResponse{
+		Status:           HealthOK,
+		ExecutionBackend: h.executionBackend,
+		Features:         make(map[string]bool, len(gatedFeatures)),
+	}
+
+	if reporter, ok := h.proxy.(proxyclient.StatusReporter); ok {
+		health.ProxyBackends = reporter.Status()
+
+		available := 0
+		for _, backend := range health.ProxyBackends {
+			switch {
+			case !backend.Healthy:
+				health.Reasons = append(health.Reasons, fmt.Sprintf("proxy backend %s is unhealthy: %s", backend.URL, backend.LastError))
+			case backend.CircuitOpen:
+				health.Reasons = append(health.Reasons, fmt.Sprintf("proxy backend %s is failing requests", backend.URL))
+			default:
+				available++
+			}
+		}
+		if available == 0 {
+			health.Reasons = append(health.Reasons, "no proxy backend is available; SQL generation will fail")
+		}
+	}
+
+	for _, feature := range gatedFeatures {
+		supported := proxyclient.Supports(h.executor, feature)
+		health.Features[feature] = supported
+		if !supported {
+			health.Reasons = append(health.Reasons, fmt.Sprintf("%s is not supported by the proxy", feature))
+		}
+	}
+
+	if len(health.Reasons) > 0 {
+		health.Status = HealthDegraded
+	}
+	response.JSON(w, http.StatusOK, health)
+}
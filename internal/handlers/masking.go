@@ -0,0 +1,195 @@
+// internal/handlers/masking.go
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/ephy-lab/ai-db-assistant/internal/middleware"
+	"github.com/ephy-lab/ai-db-assistant/internal/models"
+	"github.com/ephy-lab/ai-db-assistant/pkg/masking"
+	"github.com/ephy-lab/ai-db-assistant/pkg/response"
+	"github.com/gorilla/mux"
+	"gorm.io/gorm"
+)
+
+type MaskingRuleRequest struct {
+	Kind    string `json:"kind"`
+	Pattern string `json:"pattern"`
+	Action  string `json:"action"`
+}
+
+// GetMaskingRules lists a project's masking rules
+func (h *ProjectHandler) GetMaskingRules(w http.ResponseWriter, r *http.Request) {
+	project, ok := h.loadOwnedProject(w, r)
+	if !ok {
+		return
+	}
+
+	var rules []models.MaskingRule
+	if err := h.db.Where("project_id = ?", project.ID).Order("id").Find(&rules).Error; err != nil {
+		response.Error(w, http.StatusInternalServerError, "Failed to fetch masking rules")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, rules)
+}
+
+// CreateMaskingRule adds a masking rule to a project
+func (h *ProjectHandler) CreateMaskingRule(w http.ResponseWriter, r *http.Request) {
+	project, ok := h.loadOwnedProject(w, r)
+	if !ok {
+		return
+	}
+
+	var req MaskingRuleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if err := req.rule().Validate(); err != nil {
+		response.Error(w, http.StatusBadRequest, "Invalid masking rule: "+err.Error())
+		return
+	}
+
+	rule := models.MaskingRule{
+		ProjectID: project.ID,
+		Kind:      req.Kind,
+		Pattern:   req.Pattern,
+		Action:    req.Action,
+	}
+	if err := h.db.Create(&rule).Error; err != nil {
+		response.Error(w, http.StatusInternalServerError, "Failed to create masking rule")
+		return
+	}
+
+	response.Success(w, http.StatusCreated, "Masking rule created successfully", rule)
+}
+
+// UpdateMaskingRule replaces a masking rule's kind, pattern and action
+func (h *ProjectHandler) UpdateMaskingRule(w http.ResponseWriter, r *http.Request) {
+	project, ok := h.loadOwnedProject(w, r)
+	if !ok {
+		return
+	}
+
+	rule, ok := h.loadMaskingRule(w, r, project)
+	if !ok {
+		return
+	}
+
+	var req MaskingRuleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if err := req.rule().Validate(); err != nil {
+		response.Error(w, http.StatusBadRequest, "Invalid masking rule: "+err.Error())
+		return
+	}
+
+	rule.Kind = req.Kind
+	rule.Pattern = req.Pattern
+	rule.Action = req.Action
+	if err := h.db.Save(rule).Error; err != nil {
+		response.Error(w, http.StatusInternalServerError, "Failed to update masking rule")
+		return
+	}
+
+	response.Success(w, http.StatusOK, "Masking rule updated successfully", rule)
+}
+
+// DeleteMaskingRule removes a masking rule
+func (h *ProjectHandler) DeleteMaskingRule(w http.ResponseWriter, r *http.Request) {
+	project, ok := h.loadOwnedProject(w, r)
+	if !ok {
+		return
+	}
+
+	rule, ok := h.loadMaskingRule(w, r, project)
+	if !ok {
+		return
+	}
+
+	if err := h.db.Delete(rule).Error; err != nil {
+		response.Error(w, http.StatusInternalServerError, "Failed to delete masking rule")
+		return
+	}
+
+	response.Success(w, http.StatusOK, "Masking rule deleted successfully", nil)
+}
+
+func (req MaskingRuleRequest) rule() masking.Rule {
+	return masking.Rule{
+		Kind:    masking.Kind(req.Kind),
+		Pattern: req.Pattern,
+		Action:  masking.Action(req.Action),
+	}
+}
+
+// loadOwnedProject fetches the project in the URL if it belongs to the caller
+func (h *ProjectHandler) loadOwnedProject(w http.ResponseWriter, r *http.Request) (*models.Project, bool) {
+	userID, ok := middleware.GetUserID(r)
+	if !ok {
+		response.Error(w, http.StatusUnauthorized, "Unauthorized")
+		return nil, false
+	}
+
+	vars := mux.Vars(r)
+	projectID, err := strconv.ParseUint(vars["id"], 10, 32)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "Invalid project ID")
+		return nil, false
+	}
+
+	var project models.Project
+	if err := h.db.Where("id = ? AND user_id = ?", projectID, userID).First(&project).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			response.Error(w, http.StatusNotFound, "Project not found")
+			return nil, false
+		}
+		response.Error(w, http.StatusInternalServerError, "Failed to fetch project")
+		return nil, false
+	}
+
+	return &project, true
+}
+
+func (h *ProjectHandler) loadMaskingRule(w http.ResponseWriter, r *http.Request, project *models.Project) (*models.MaskingRule, bool) {
+	ruleID, err := strconv.ParseUint(mux.Vars(r)["rule_id"], 10, 32)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "Invalid rule ID")
+		return nil, false
+	}
+
+	var rule models.MaskingRule
+	if err := h.db.Where("id = ? AND project_id = ?", ruleID, project.ID).First(&rule).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			response.Error(w, http.StatusNotFound, "Masking rule not found")
+			return nil, false
+		}
+		response.Error(w, http.StatusInternalServerError, "Failed to fetch masking rule")
+		return nil, false
+	}
+
+	return &rule, true
+}
+
+// projectMasker builds the masker for a project's preloaded masking rules.
+// Hashes are keyed per project so equal values cannot be correlated across
+// projects.
+func projectMasker(project *models.Project, secret string) (*masking.Masker, error) {
+	rules := make([]masking.Rule, 0, len(project.MaskingRules))
+	for _, rule := range project.MaskingRules {
+		rules = append(rules, masking.Rule{
+			Kind:    masking.Kind(rule.Kind),
+			Pattern: rule.Pattern,
+			Action:  masking.Action(rule.Action),
+		})
+	}
+	return masking.New(rules, []byte(fmt.Sprintf("%s:%d", secret, project.ID)))
+}
@@ -0,0 +1,182 @@
+// internal/handlers/permission.go
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/ephy-lab/ai-db-assistant/internal/middleware"
+	"github.com/ephy-lab/ai-db-assistant/internal/models"
+	"github.com/ephy-lab/ai-db-assistant/pkg/response"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type RollbackPermissionsRequest struct {
+	Version int    `json:"version"`
+	Reason  string `json:"reason"`
+}
+
+// GetPermissionHistory lists every recorded permission change for a project
+func (h *ProjectHandler) GetPermissionHistory(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r)
+	if !ok {
+		response.Error(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	vars := mux.Vars(r)
+	projectID, err := strconv.ParseUint(vars["id"], 10, 32)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "Invalid project ID")
+		return
+	}
+
+	// Verify project ownership
+	var project models.Project
+	if err := h.db.Where("id = ? AND user_id = ?", projectID, userID).First(&project).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			response.Error(w, http.StatusNotFound, "Project not found")
+			return
+		}
+		response.Error(w, http.StatusInternalServerError, "Failed to fetch project")
+		return
+	}
+
+	var versions []models.PermissionVersion
+	if err := h.db.Where("project_id = ?", projectID).Order("version desc").Find(&versions).Error; err != nil {
+		response.Error(w, http.StatusInternalServerError, "Failed to fetch permission history")
+		return
+	}
+
+	response.JSON(w, http.StatusOK, versions)
+}
+
+// RollbackPermissions restores the permission flags recorded in a prior version
+func (h *ProjectHandler) RollbackPermissions(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserID(r)
+	if !ok {
+		response.Error(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	vars := mux.Vars(r)
+	projectID, err := strconv.ParseUint(vars["id"], 10, 32)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "Invalid project ID")
+		return
+	}
+
+	var req RollbackPermissionsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if req.Version <= 0 {
+		response.Error(w, http.StatusBadRequest, "Version is required")
+		return
+	}
+
+	// Verify project ownership
+	var project models.Project
+	if err := h.db.Where("id = ? AND user_id = ?", projectID, userID).First(&project).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			response.Error(w, http.StatusNotFound, "Project not found")
+			return
+		}
+		response.Error(w, http.StatusInternalServerError, "Failed to fetch project")
+		return
+	}
+
+	var target models.PermissionVersion
+	if err := h.db.Where("project_id = ? AND version = ?", projectID, req.Version).First(&target).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			response.Error(w, http.StatusNotFound, "Permission version not found")
+			return
+		}
+		response.Error(w, http.StatusInternalServerError, "Failed to fetch permission version")
+		return
+	}
+
+	// Flags added after the target version was recorded keep their values
+	kept := target.NewValues.Missing()
+	reason := req.Reason
+	if reason == "" {
+		reason = fmt.Sprintf("Rolled back to version %d", target.Version)
+		if len(kept) > 0 {
+			reason += fmt.Sprintf(" (kept current %s, not recorded in that version)", strings.Join(kept, ", "))
+		}
+	}
+
+	var permission models.Permission
+	var version models.PermissionVersion
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		if err := lockPermission(tx, uint(projectID), &permission); err != nil {
+			return err
+		}
+
+		old := permission.Flags()
+		permission.SetFlags(target.NewValues)
+		if err := tx.Save(&permission).Error; err != nil {
+			return err
+		}
+
+		rollbackOf := target.Version
+		version, err = recordPermissionVersion(tx, uint(projectID), userID, &old, permission.Flags(), reason, &rollbackOf)
+		return err
+	})
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			response.Error(w, http.StatusNotFound, "Permissions not found")
+			return
+		}
+		response.Error(w, http.StatusInternalServerError, "Failed to roll back permissions")
+		return
+	}
+
+	message := "Permissions rolled back successfully"
+	if len(kept) > 0 {
+		message += "; " + strings.Join(kept, ", ") + " kept their current values"
+	}
+	response.Success(w, http.StatusOK, message, version)
+}
+
+// lockPermission loads the project's permissions for update, so that
+// concurrent changes to them and their history run one after the other
+func lockPermission(tx *gorm.DB, projectID uint, permission *models.Permission) error {
+	return tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("project_id = ?", projectID).First(permission).Error
+}
+
+// recordPermissionVersion appends a new entry to the project's permission
+// history. Callers changing existing permissions must hold the lock taken
+// by lockPermission, or concurrent changes would pick the same version.
+func recordPermissionVersion(tx *gorm.DB, projectID, actorID uint, old *models.PermissionFlags, new models.PermissionFlags, reason string, rollbackOf *int) (models.PermissionVersion, error) {
+	var latest int
+	if err := tx.Model(&models.PermissionVersion{}).
+		Where("project_id = ?", projectID).
+		Select("COALESCE(MAX(version), 0)").
+		Scan(&latest).Error; err != nil {
+		return models.PermissionVersion{}, err
+	}
+
+	version := models.PermissionVersion{
+		ProjectID:  projectID,
+		Version:    latest + 1,
+		ChangedBy:  actorID,
+		Reason:     reason,
+		OldValues:  old,
+		NewValues:  new,
+		RollbackOf: rollbackOf,
+	}
+
+	if err := tx.Create(&version).Error; err != nil {
+		return models.PermissionVersion{}, err
+	}
+
+	return version, nil
+}
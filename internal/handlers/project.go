@@ -9,16 +9,18 @@ import (
 	"github.com/gorilla/mux"
 	"github.com/ephy-lab/ai-db-assistant/internal/middleware"
 	"github.com/ephy-lab/ai-db-assistant/internal/models"
+	"github.com/ephy-lab/ai-db-assistant/pkg/dbpool"
 	"github.com/ephy-lab/ai-db-assistant/pkg/response"
 	"gorm.io/gorm"
 )
 
 type ProjectHandler struct {
-	db *gorm.DB
+	db    *gorm.DB
+	pools *dbpool.Manager
 }
 
-func NewProjectHandler(db *gorm.DB) *ProjectHandler {
-	return &ProjectHandler{db: db}
+func NewProjectHandler(db *gorm.DB, pools *dbpool.Manager) *ProjectHandler {
+	return &ProjectHandler{db: db, pools: pools}
 }
 
 type CreateProjectRequest struct {
@@ -30,6 +32,12 @@ type CreateProjectRequest struct {
 	AllowWrite       *bool  `json:"allow_write,omitempty"`
 	AllowRead        *bool  `json:"allow_read,omitempty"`
 	AllowDelete      *bool  `json:"allow_delete,omitempty"`
+	AllowDCL         *bool  `json:"allow_dcl,omitempty"`
+	AllowTransaction *bool  `json:"allow_transaction,omitempty"`
+	AllowCall        *bool  `json:"allow_call,omitempty"`
+	AllowBulkLoad    *bool  `json:"allow_bulk_load,omitempty"`
+	AllowAdmin       *bool  `json:"allow_admin,omitempty"`
+	QueryPolicyRequest
 }
 
 type UpdateProjectRequest struct {
@@ -40,6 +48,24 @@ type UpdateProjectRequest struct {
 	AllowWrite       *bool  `json:"allow_write,omitempty"`
 	AllowRead        *bool  `json:"allow_read,omitempty"`
 	AllowDelete      *bool  `json:"allow_delete,omitempty"`
+	AllowDCL         *bool  `json:"allow_dcl,omitempty"`
+	AllowTransaction *bool  `json:"allow_transaction,omitempty"`
+	AllowCall        *bool  `json:"allow_call,omitempty"`
+	AllowBulkLoad    *bool  `json:"allow_bulk_load,omitempty"`
+	AllowAdmin       *bool  `json:"allow_admin,omitempty"`
+	Reason           string `json:"reason,omitempty"` // recorded in the permission history
+	QueryPolicyRequest
+}
+
+// QueryPolicyRequest carries the optional guardrail settings for a project
+type QueryPolicyRequest struct {
+	RequireWhereClause  *bool `json:"require_where_clause,omitempty"`
+	MaxAffectedRows     *int  `json:"max_affected_rows,omitempty"`
+	RequireConfirmation *bool `json:"require_confirmation,omitempty"`
+	AllowMultiStatement *bool `json:"allow_multi_statement,omitempty"`
+	FormatQueryLog      *bool `json:"format_query_log,omitempty"`
+	MaxRows             *int  `json:"max_rows,omitempty"`
+	MaxConnections      *int  `json:"max_connections,omitempty"`
 }
 
 func (h *ProjectHandler) CreateProject(w http.ResponseWriter, r *http.Request) {
@@ -65,6 +91,21 @@ func (h *ProjectHandler) CreateProject(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if req.MaxAffectedRows != nil && *req.MaxAffectedRows < 0 {
+		response.Error(w, http.StatusBadRequest, "max_affected_rows must not be negative")
+		return
+	}
+
+	if req.MaxRows != nil && *req.MaxRows < 0 {
+		response.Error(w, http.StatusBadRequest, "max_rows must not be negative")
+		return
+	}
+
+	if req.MaxConnections != nil && *req.MaxConnections < 0 {
+		response.Error(w, http.StatusBadRequest, "max_connections must not be negative")
+		return
+	}
+
 	project := models.Project{
 		UserID:           userID,
 		Name:             req.Name,
@@ -103,6 +144,24 @@ func (h *ProjectHandler) CreateProject(w http.ResponseWriter, r *http.Request) {
 		AllowWrite:  allowWrite,
 		AllowRead:   allowRead,
 		AllowDelete: allowDelete,
+		// Transaction control is harmless on its own; the remaining
+		// categories must be enabled explicitly
+		AllowTransaction: true,
+	}
+	if req.AllowDCL != nil {
+		permission.AllowDCL = *req.AllowDCL
+	}
+	if req.AllowTransaction != nil {
+		permission.AllowTransaction = *req.AllowTransaction
+	}
+	if req.AllowCall != nil {
+		permission.AllowCall = *req.AllowCall
+	}
+	if req.AllowBulkLoad != nil {
+		permission.AllowBulkLoad = *req.AllowBulkLoad
+	}
+	if req.AllowAdmin != nil {
+		permission.AllowAdmin = *req.AllowAdmin
 	}
 
 	if err := h.db.Create(&permission).Error; err != nil {
@@ -110,8 +169,43 @@ func (h *ProjectHandler) CreateProject(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Load the permission into the project response
+	if _, err := recordPermissionVersion(h.db, project.ID, userID, nil, permission.Flags(), "Project created", nil); err != nil {
+		response.Error(w, http.StatusInternalServerError, "Failed to record project permissions")
+		return
+	}
+
+	// Create query policy with defaults
+	policy := models.NewQueryPolicy(project.ID)
+	if req.RequireWhereClause != nil {
+		policy.RequireWhereClause = *req.RequireWhereClause
+	}
+	if req.MaxAffectedRows != nil {
+		policy.MaxAffectedRows = *req.MaxAffectedRows
+	}
+	if req.RequireConfirmation != nil {
+		policy.RequireConfirmation = *req.RequireConfirmation
+	}
+	if req.AllowMultiStatement != nil {
+		policy.AllowMultiStatement = *req.AllowMultiStatement
+	}
+	if req.FormatQueryLog != nil {
+		policy.FormatQueryLog = *req.FormatQueryLog
+	}
+	if req.MaxRows != nil {
+		policy.MaxRows = *req.MaxRows
+	}
+	if req.MaxConnections != nil {
+		policy.MaxConnections = *req.MaxConnections
+	}
+
+	if err := h.db.Create(&policy).Error; err != nil {
+		response.Error(w, http.StatusInternalServerError, "Failed to create project query policy")
+		return
+	}
+
+	// Load the permission and policy into the project response
 	project.Permission = &permission
+	project.QueryPolicy = &policy
 
 	response.Success(w, http.StatusCreated, "Project created successfully", project)
 }
@@ -125,7 +219,7 @@ func (h *ProjectHandler) GetProjects(w http.ResponseWriter, r *http.Request) {
 
 	var projects []models.Project
 	// Preload User and Permission data for the list view
-	if err := h.db.Preload("User").Preload("Permission").Where("user_id = ?", userID).Order("created_at desc").Find(&projects).Error; err != nil {
+	if err := h.db.Preload("User").Preload("Permission").Preload("QueryPolicy").Where("user_id = ?", userID).Order("created_at desc").Find(&projects).Error; err != nil {
 		response.Error(w, http.StatusInternalServerError, "Failed to fetch projects")
 		return
 	}
@@ -149,7 +243,7 @@ func (h *ProjectHandler) GetProject(w http.ResponseWriter, r *http.Request) {
 
 	var project models.Project
 	// Preload User and Permission data for single project view
-	if err := h.db.Preload("User").Preload("Permission").Where("id = ? AND user_id = ?", projectID, userID).First(&project).Error; err != nil {
+	if err := h.db.Preload("User").Preload("Permission").Preload("QueryPolicy").Where("id = ? AND user_id = ?", projectID, userID).First(&project).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			response.Error(w, http.StatusNotFound, "Project not found")
 			return
@@ -181,6 +275,21 @@ func (h *ProjectHandler) UpdateProject(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if req.MaxAffectedRows != nil && *req.MaxAffectedRows < 0 {
+		response.Error(w, http.StatusBadRequest, "max_affected_rows must not be negative")
+		return
+	}
+
+	if req.MaxRows != nil && *req.MaxRows < 0 {
+		response.Error(w, http.StatusBadRequest, "max_rows must not be negative")
+		return
+	}
+
+	if req.MaxConnections != nil && *req.MaxConnections < 0 {
+		response.Error(w, http.StatusBadRequest, "max_connections must not be negative")
+		return
+	}
+
 	var project models.Project
 	if err := h.db.Where("id = ? AND user_id = ?", projectID, userID).First(&project).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
@@ -207,6 +316,14 @@ func (h *ProjectHandler) UpdateProject(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Sessions and the pool still hold connections to the old database; mark
+	// the sessions disconnected so the next query reconnects with the new
+	// connection string, and drop the pool
+	if req.ConnectionString != "" {
+		h.db.Model(&models.DBSession{}).Where("project_id = ?", project.ID).Update("connected", false)
+		h.pools.Invalidate(project.ID)
+	}
+
 	// Update permissions if provided
 	permissionUpdates := map[string]interface{}{}
 	if req.AllowDDL != nil {
@@ -221,16 +338,95 @@ func (h *ProjectHandler) UpdateProject(w http.ResponseWriter, r *http.Request) {
 	if req.AllowDelete != nil {
 		permissionUpdates["allow_delete"] = *req.AllowDelete
 	}
+	if req.AllowDCL != nil {
+		permissionUpdates["allow_dcl"] = *req.AllowDCL
+	}
+	if req.AllowTransaction != nil {
+		permissionUpdates["allow_transaction"] = *req.AllowTransaction
+	}
+	if req.AllowCall != nil {
+		permissionUpdates["allow_call"] = *req.AllowCall
+	}
+	if req.AllowBulkLoad != nil {
+		permissionUpdates["allow_bulk_load"] = *req.AllowBulkLoad
+	}
+	if req.AllowAdmin != nil {
+		permissionUpdates["allow_admin"] = *req.AllowAdmin
+	}
 
 	if len(permissionUpdates) > 0 {
-		if err := h.db.Model(&models.Permission{}).Where("project_id = ?", projectID).Updates(permissionUpdates).Error; err != nil {
+		// Apply the change and record it in the permission history atomically
+		err := h.db.Transaction(func(tx *gorm.DB) error {
+			var permission models.Permission
+			if err := lockPermission(tx, uint(projectID), &permission); err != nil {
+				return err
+			}
+
+			old := permission.Flags()
+			if err := tx.Model(&permission).Updates(permissionUpdates).Error; err != nil {
+				return err
+			}
+
+			if permission.Flags() == old {
+				return nil
+			}
+
+			_, err := recordPermissionVersion(tx, uint(projectID), userID, &old, permission.Flags(), req.Reason, nil)
+			return err
+		})
+		if err != nil {
+			if err == gorm.ErrRecordNotFound {
+				response.Error(w, http.StatusNotFound, "Permissions not found")
+				return
+			}
 			response.Error(w, http.StatusInternalServerError, "Failed to update project permissions")
 			return
 		}
 	}
 
-	// Reload project with updated permission
-	if err := h.db.Preload("Permission").Where("id = ?", projectID).First(&project).Error; err != nil {
+	// Update query policy if provided
+	policyUpdates := map[string]interface{}{}
+	if req.RequireWhereClause != nil {
+		policyUpdates["require_where_clause"] = *req.RequireWhereClause
+	}
+	if req.MaxAffectedRows != nil {
+		policyUpdates["max_affected_rows"] = *req.MaxAffectedRows
+	}
+	if req.RequireConfirmation != nil {
+		policyUpdates["require_confirmation"] = *req.RequireConfirmation
+	}
+	if req.AllowMultiStatement != nil {
+		policyUpdates["allow_multi_statement"] = *req.AllowMultiStatement
+	}
+	if req.FormatQueryLog != nil {
+		policyUpdates["format_query_log"] = *req.FormatQueryLog
+	}
+	if req.MaxRows != nil {
+		policyUpdates["max_rows"] = *req.MaxRows
+	}
+	if req.MaxConnections != nil {
+		policyUpdates["max_connections"] = *req.MaxConnections
+		// The pool is sized when it is opened
+		h.pools.Invalidate(uint(projectID))
+	}
+
+	if len(policyUpdates) > 0 {
+		// A project whose policy was removed has no row
+		var policy models.QueryPolicy
+		if err := h.db.Where(models.QueryPolicy{ProjectID: uint(projectID)}).
+			Attrs(models.NewQueryPolicy(uint(projectID))).
+			FirstOrCreate(&policy).Error; err != nil {
+			response.Error(w, http.StatusInternalServerError, "Failed to update project query policy")
+			return
+		}
+		if err := h.db.Model(&policy).Updates(policyUpdates).Error; err != nil {
+			response.Error(w, http.StatusInternalServerError, "Failed to update project query policy")
+			return
+		}
+	}
+
+	// Reload project with updated permission and policy
+	if err := h.db.Preload("Permission").Preload("QueryPolicy").Where("id = ?", projectID).First(&project).Error; err != nil {
 		response.Error(w, http.StatusInternalServerError, "Failed to fetch updated project")
 		return
 	}
@@ -263,6 +459,8 @@ func (h *ProjectHandler) DeleteProject(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	h.pools.Invalidate(uint(projectID))
+
 	response.Success(w, http.StatusOK, "Project deleted successfully", nil)
 }
 
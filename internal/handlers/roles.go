@@ -0,0 +1,196 @@
+// internal/handlers/roles.go
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/ephy-lab/ai-db-assistant/internal/middleware"
+	"github.com/ephy-lab/ai-db-assistant/internal/models"
+	"github.com/ephy-lab/ai-db-assistant/pkg/proxyclient"
+	"github.com/ephy-lab/ai-db-assistant/pkg/response"
+	"github.com/ephy-lab/ai-db-assistant/pkg/rolegen"
+	"github.com/ephy-lab/ai-db-assistant/pkg/sqlparser"
+	"gorm.io/gorm"
+)
+
+type ApplyRoleRequest struct {
+	Role string `json:"role"`
+}
+
+type ApplyRoleResult struct {
+	Plan    *rolegen.Plan                     `json:"plan"`
+	Results []*proxyclient.ExecuteSQLResponse `json:"results"`
+}
+
+// GetRoleStatements generates the CREATE ROLE/GRANT statements mirroring the project's permissions
+func (h *DatabaseHandler) GetRoleStatements(w http.ResponseWriter, r *http.Request) {
+	project, ok := h.loadProjectWithPermission(w, r)
+	if !ok {
+		return
+	}
+
+	role := r.URL.Query().Get("role")
+	if role == "" {
+		role = defaultRoleName(project)
+	}
+
+	plan, err := rolegen.Generate(project.DatabaseType, role, rolegen.DatabaseName(project.DatabaseType, project.ConnectionString), roleFlags(project.Permission))
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "Failed to generate role statements: "+err.Error())
+		return
+	}
+
+	response.JSON(w, http.StatusOK, plan)
+}
+
+// ApplyRole creates the least-privilege role on the connected database
+func (h *DatabaseHandler) ApplyRole(w http.ResponseWriter, r *http.Request) {
+	project, ok := h.loadProjectWithPermission(w, r)
+	if !ok {
+		return
+	}
+
+	// Creating roles and granting privileges is access control
+	if project.Permission != nil && !project.Permission.AllowDCL {
+		response.Error(w, http.StatusForbidden, "Access control permission required to apply role statements")
+		return
+	}
+
+	var req ApplyRoleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if req.Role == "" {
+		req.Role = defaultRoleName(project)
+	}
+
+	plan, err := rolegen.Generate(project.DatabaseType, req.Role, rolegen.DatabaseName(project.DatabaseType, project.ConnectionString), roleFlags(project.Permission))
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "Failed to generate role statements: "+err.Error())
+		return
+	}
+
+	ctx, err := h.projectSession(r.Context(), project)
+	if err != nil {
+		response.Error(w, proxyErrorStatus(err), err.Error())
+		return
+	}
+
+	result := ApplyRoleResult{Plan: plan}
+	for _, statement := range plan.Statements {
+		startTime := time.Now()
+		resp, err := h.executor.ExecuteSQLWithParamsContext(ctx, statement, nil, false)
+		if h.reconnectLost(ctx, project, err) {
+			resp, err = h.executor.ExecuteSQLWithParamsContext(ctx, statement, nil, false)
+		}
+		executionTime := time.Since(startTime).Milliseconds()
+
+		queryLog := models.Query{
+			ProjectID:     project.ID,
+			Query:         queryLogText(project, statement),
+			QueryType:     string(sqlparser.GetQueryType(statement, sqlparser.ParseDialect(project.DatabaseType))),
+			Fingerprint:   sqlparser.Fingerprint(statement, sqlparser.ParseDialect(project.DatabaseType)),
+			ExecutionTime: int(executionTime),
+		}
+
+		if err != nil {
+			queryLog.Status = "error"
+			queryLog.Error = err.Error()
+			h.db.Create(&queryLog)
+
+			response.Error(w, proxyErrorStatus(err), "Failed to apply role statement '"+statement+"': "+err.Error())
+			return
+		}
+
+		queryLog.Status = "success"
+		queryLog.Result = resp.Message
+		h.db.Create(&queryLog)
+
+		result.Results = append(result.Results, resp)
+	}
+
+	response.Success(w, http.StatusOK, "Role applied successfully", result)
+}
+
+// CheckGrants reports privileges of the connected user that exceed the project's permissions
+func (h *DatabaseHandler) CheckGrants(w http.ResponseWriter, r *http.Request) {
+	project, ok := h.loadProjectWithPermission(w, r)
+	if !ok {
+		return
+	}
+
+	query, err := rolegen.GrantsQuery(project.DatabaseType)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	ctx, err := h.projectSession(r.Context(), project)
+	if err != nil {
+		response.Error(w, proxyErrorStatus(err), err.Error())
+		return
+	}
+
+	resp, err := h.executor.ExecuteSQLWithParamsContext(ctx, query, nil, false)
+	if h.reconnectLost(ctx, project, err) {
+		resp, err = h.executor.ExecuteSQLWithParamsContext(ctx, query, nil, false)
+	}
+	if err != nil {
+		response.Error(w, proxyErrorStatus(err), "Failed to read database grants: "+err.Error())
+		return
+	}
+
+	privileges := rolegen.ParseGrants(project.DatabaseType, resp.Rows)
+	response.JSON(w, http.StatusOK, rolegen.Check(privileges, roleFlags(project.Permission)))
+}
+
+// loadProjectWithPermission resolves the {id} route variable to a project owned by the caller
+func (h *DatabaseHandler) loadProjectWithPermission(w http.ResponseWriter, r *http.Request) (*models.Project, bool) {
+	userID, ok := middleware.GetUserID(r)
+	if !ok {
+		response.Error(w, http.StatusUnauthorized, "Unauthorized")
+		return nil, false
+	}
+
+	vars := mux.Vars(r)
+	projectID, err := strconv.ParseUint(vars["id"], 10, 32)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "Invalid project ID")
+		return nil, false
+	}
+
+	var project models.Project
+	if err := h.db.Preload("Permission").Preload("QueryPolicy").Where("id = ? AND user_id = ?", projectID, userID).First(&project).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			response.Error(w, http.StatusNotFound, "Project not found")
+			return nil, false
+		}
+		response.Error(w, http.StatusInternalServerError, "Failed to fetch project")
+		return nil, false
+	}
+
+	return &project, true
+}
+
+func roleFlags(permission *models.Permission) rolegen.Flags {
+	// Projects without a permission row are unrestricted
+	if permission == nil {
+		return rolegen.Flags{DDL: true, Write: true, Read: true, Delete: true}
+	}
+	return rolegen.Flags{
+		DDL:    permission.AllowDDL,
+		Write:  permission.AllowWrite,
+		Read:   permission.AllowRead,
+		Delete: permission.AllowDelete,
+	}
+}
+
+func defaultRoleName(project *models.Project) string {
+	return "ai_db_assistant_project_" + strconv.FormatUint(uint64(project.ID), 10)
+}
@@ -0,0 +1,209 @@
+// internal/handlers/schema.go
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/ephy-lab/ai-db-assistant/internal/middleware"
+	"github.com/ephy-lab/ai-db-assistant/internal/models"
+	"github.com/ephy-lab/ai-db-assistant/pkg/dbpool"
+	"github.com/ephy-lab/ai-db-assistant/pkg/logger"
+	"github.com/ephy-lab/ai-db-assistant/pkg/response"
+	"github.com/ephy-lab/ai-db-assistant/pkg/schema"
+	"github.com/ephy-lab/ai-db-assistant/pkg/sqlparser"
+	"gorm.io/gorm"
+)
+
+const (
+	// schemaMaxAge is how long a schema snapshot is used before the
+	// database is introspected again
+	schemaMaxAge = time.Hour
+	// introspectTimeout bounds a single schema introspection
+	introspectTimeout = 30 * time.Second
+)
+
+type SchemaHandler struct {
+	db    *gorm.DB
+	pools *dbpool.Manager
+}
+
+func NewSchemaHandler(db *gorm.DB, pools *dbpool.Manager) *SchemaHandler {
+	return &SchemaHandler{db: db, pools: pools}
+}
+
+// GetSchema returns the project's latest schema snapshot, introspecting the
+// database when it is missing or stale, or the version given with ?version=
+func (h *SchemaHandler) GetSchema(w http.ResponseWriter, r *http.Request) {
+	project, ok := h.loadProject(w, r)
+	if !ok {
+		return
+	}
+
+	if v := r.URL.Query().Get("version"); v != "" {
+		version, err := strconv.Atoi(v)
+		if err != nil || version <= 0 {
+			response.Error(w, http.StatusBadRequest, "Invalid version")
+			return
+		}
+
+		var snapshot models.SchemaSnapshot
+		if err := h.db.Where("project_id = ? AND version = ?", project.ID, version).First(&snapshot).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				response.Error(w, http.StatusNotFound, "Schema version not found")
+				return
+			}
+			response.Error(w, http.StatusInternalServerError, "Failed to fetch schema")
+			return
+		}
+
+		response.JSON(w, http.StatusOK, snapshot)
+		return
+	}
+
+	snapshot, err := projectSchema(r.Context(), h.db, h.pools, project)
+	if err != nil {
+		response.Error(w, http.StatusBadGateway, "Failed to introspect schema: "+err.Error())
+		return
+	}
+
+	response.JSON(w, http.StatusOK, snapshot)
+}
+
+// RefreshSchema introspects the project's database now, recording a new
+// version if the schema changed
+func (h *SchemaHandler) RefreshSchema(w http.ResponseWriter, r *http.Request) {
+	project, ok := h.loadProject(w, r)
+	if !ok {
+		return
+	}
+
+	snapshot, changed, err := refreshSchema(r.Context(), h.db, h.pools, project)
+	if err != nil {
+		response.Error(w, http.StatusBadGateway, "Failed to introspect schema: "+err.Error())
+		return
+	}
+
+	message := "Schema unchanged"
+	if changed {
+		message = fmt.Sprintf("Schema version %d recorded", snapshot.Version)
+	}
+	response.Success(w, http.StatusOK, message, snapshot)
+}
+
+// loadProject loads the project in the URL if it belongs to the user and
+// its permissions allow reads, writing the error response otherwise. The
+// schema lists every table and column, so it is read data like any query.
+func (h *SchemaHandler) loadProject(w http.ResponseWriter, r *http.Request) (*models.Project, bool) {
+	userID, ok := middleware.GetUserID(r)
+	if !ok {
+		response.Error(w, http.StatusUnauthorized, "Unauthorized")
+		return nil, false
+	}
+
+	vars := mux.Vars(r)
+	projectID, err := strconv.ParseUint(vars["id"], 10, 32)
+	if err != nil {
+		response.Error(w, http.StatusBadRequest, "Invalid project ID")
+		return nil, false
+	}
+
+	var project models.Project
+	if err := h.db.Preload("Permission").Preload("QueryPolicy").Where("id = ? AND user_id = ?", projectID, userID).First(&project).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			response.Error(w, http.StatusNotFound, "Project not found")
+			return nil, false
+		}
+		response.Error(w, http.StatusInternalServerError, "Failed to fetch project")
+		return nil, false
+	}
+
+	if project.Permission != nil && !project.Permission.AllowRead {
+		response.Error(w, http.StatusForbidden, "Read operations are not allowed for this project")
+		return nil, false
+	}
+
+	return &project, true
+}
+
+// projectSchema returns the project's latest schema snapshot. The database
+// is introspected again when there is no snapshot, it is older than
+// schemaMaxAge or the project changed since; if that fails, the cached
+// snapshot is returned when there is one.
+func projectSchema(ctx context.Context, db *gorm.DB, pools *dbpool.Manager, project *models.Project) (*models.SchemaSnapshot, error) {
+	var latest models.SchemaSnapshot
+	err := db.Where("project_id = ?", project.ID).Order("version desc").First(&latest).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("failed to fetch schema: %w", err)
+	}
+	found := err == nil
+
+	if found && time.Since(latest.CheckedAt) < schemaMaxAge && latest.CheckedAt.After(project.UpdatedAt) {
+		return &latest, nil
+	}
+
+	snapshot, _, err := refreshSchema(ctx, db, pools, project)
+	if err != nil {
+		if found {
+			logger.Warn("Using cached schema after introspection failed", "project_id", project.ID, "version", latest.Version, "error", err)
+			return &latest, nil
+		}
+		return nil, err
+	}
+	return snapshot, nil
+}
+
+// refreshSchema introspects the project's database and records a new
+// snapshot version if the schema differs from the latest one. It reports
+// whether a version was added.
+func refreshSchema(ctx context.Context, db *gorm.DB, pools *dbpool.Manager, project *models.Project) (*models.SchemaSnapshot, bool, error) {
+	conn, err := pools.Get(project.ID, project.DatabaseType, project.ConnectionString, projectPoolLimits(project))
+	if err != nil {
+		return nil, false, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, introspectTimeout)
+	defer cancel()
+
+	introspected, err := schema.Introspect(ctx, conn, sqlparser.ParseDialect(project.DatabaseType))
+	if err != nil {
+		return nil, false, err
+	}
+	checksum := introspected.Checksum()
+	now := time.Now()
+
+	var snapshot models.SchemaSnapshot
+	changed := false
+	err = db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("project_id = ?", project.ID).Order("version desc").First(&snapshot).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		if err == nil && snapshot.Checksum == checksum {
+			snapshot.CheckedAt = now
+			return tx.Model(&snapshot).Update("checked_at", now).Error
+		}
+
+		changed = true
+		snapshot = models.SchemaSnapshot{
+			ProjectID:  project.ID,
+			Version:    snapshot.Version + 1,
+			Checksum:   checksum,
+			TableCount: len(introspected.Tables),
+			Schema:     *introspected,
+			CheckedAt:  now,
+		}
+		return tx.Create(&snapshot).Error
+	})
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to save schema: %w", err)
+	}
+
+	return &snapshot, changed, nil
+}
@@ -0,0 +1,110 @@
+// internal/handlers/session.go
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/ephy-lab/ai-db-assistant/internal/models"
+	"github.com/ephy-lab/ai-db-assistant/pkg/proxyclient"
+)
+
+// ConnectDBResult is the proxy's connection result with the session it belongs to
+type ConnectDBResult struct {
+	*proxyclient.ConnectDBResponse
+	SessionID string `json:"session_id"`
+}
+
+// loadSession finds the proxy session of the project's owner, creating it
+// with a new session ID on first use. A connected session is routed to the
+// proxy server it connected through, which the proxy client may not know
+// after a restart.
+func (h *DatabaseHandler) loadSession(project *models.Project) (*models.DBSession, error) {
+	var session models.DBSession
+	if err := h.db.Where(models.DBSession{ProjectID: project.ID, UserID: project.UserID}).
+		Attrs(models.DBSession{SessionID: proxyclient.NewSessionID()}).
+		FirstOrCreate(&session).Error; err != nil {
+		return nil, fmt.Errorf("failed to load database session: %w", err)
+	}
+	if session.Connected {
+		proxyclient.RestoreSession(h.executor, session.SessionID, session.ProxyBackend)
+	}
+	return &session, nil
+}
+
+// connectSession opens the project's database connection in the session
+func (h *DatabaseHandler) connectSession(ctx context.Context, project *models.Project, session *models.DBSession) (*proxyclient.ConnectDBResponse, error) {
+	resp, err := h.executor.ConnectDBContext(proxyclient.WithSessionID(ctx, session.SessionID), project.DatabaseType, project.ConnectionString)
+	if err != nil {
+		return nil, err
+	}
+	if !resp.Success {
+		return nil, fmt.Errorf("%w: %s", proxyclient.ErrQueryFailed, resp.Message)
+	}
+
+	now := time.Now()
+	session.Connected = true
+	session.ProxyBackend = proxyclient.SessionBackend(h.executor, session.SessionID)
+	session.ConnectedAt = &now
+	session.LastUsedAt = &now
+	h.db.Save(session)
+
+	return resp, nil
+}
+
+// projectSession returns ctx bound to the proxy session for the project so
+// that proxy calls made with it run against the project's database. The
+// session is connected first if it is not already.
+func (h *DatabaseHandler) projectSession(ctx context.Context, project *models.Project) (context.Context, error) {
+	session, err := h.loadSession(project)
+	if err != nil {
+		return nil, err
+	}
+
+	if !session.Connected {
+		if _, err := h.connectSession(ctx, project, session); err != nil {
+			return nil, fmt.Errorf("failed to connect to database: %w", err)
+		}
+	} else {
+		now := time.Now()
+		h.db.Model(session).Update("last_used_at", &now)
+	}
+
+	return proxyclient.WithSessionID(ctx, session.SessionID), nil
+}
+
+// reconnectLost reconnects the project's session when err reports that the
+// proxy no longer has its database connection, e.g. after the proxy
+// restarted. It reports whether the failed call should be made once more;
+// the call never reached the database, so repeating it is safe.
+func (h *DatabaseHandler) reconnectLost(ctx context.Context, project *models.Project, err error) bool {
+	if !errors.Is(err, proxyclient.ErrSessionNotConnected) {
+		return false
+	}
+
+	session, err := h.loadSession(project)
+	if err != nil {
+		return false
+	}
+	h.db.Model(session).Update("connected", false)
+	_, err = h.connectSession(ctx, project, session)
+	return err == nil
+}
+
+// proxyErrorStatus maps a proxy client error to the HTTP status returned to
+// our caller: 503 while the proxy is unreachable, 504 when it timed out and
+// 502 when it reported a failure. Other errors are internal.
+func proxyErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, proxyclient.ErrProxyUnavailable):
+		return http.StatusServiceUnavailable
+	case errors.Is(err, proxyclient.ErrProxyTimeout), errors.Is(err, context.DeadlineExceeded):
+		return http.StatusGatewayTimeout
+	case errors.Is(err, proxyclient.ErrQueryFailed):
+		return http.StatusBadGateway
+	}
+	return http.StatusInternalServerError
+}
@@ -0,0 +1,75 @@
+// internal/handlers/sql.go
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/ephy-lab/ai-db-assistant/internal/models"
+	"github.com/ephy-lab/ai-db-assistant/pkg/response"
+	"github.com/ephy-lab/ai-db-assistant/pkg/sqlformat"
+	"github.com/ephy-lab/ai-db-assistant/pkg/sqlparser"
+)
+
+// SQLHandler serves SQL utilities that do not need a database connection
+type SQLHandler struct{}
+
+func NewSQLHandler() *SQLHandler {
+	return &SQLHandler{}
+}
+
+type FormatSQLRequest struct {
+	Query        string `json:"query"`
+	DatabaseType string `json:"database_type,omitempty"` // mysql or postgresql; generic rules when empty
+	KeywordCase  string `json:"keyword_case,omitempty"`  // upper (default), lower or preserve
+	IndentWidth  *int   `json:"indent_width,omitempty"`  // spaces per level, default 2
+	UseTabs      bool   `json:"use_tabs,omitempty"`
+}
+
+type FormatSQLResponse struct {
+	Query string `json:"query"`
+}
+
+// FormatSQL pretty-prints a SQL query
+func (h *SQLHandler) FormatSQL(w http.ResponseWriter, r *http.Request) {
+	var req FormatSQLRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response.Error(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if req.Query == "" {
+		response.Error(w, http.StatusBadRequest, "Query is required")
+		return
+	}
+
+	if req.DatabaseType != "" && req.DatabaseType != "mysql" && req.DatabaseType != "postgresql" {
+		response.Error(w, http.StatusBadRequest, "Database type must be 'mysql' or 'postgresql'")
+		return
+	}
+
+	opts := sqlformat.DefaultOptions(sqlparser.ParseDialect(req.DatabaseType))
+	if req.KeywordCase != "" {
+		opts.KeywordCase = sqlformat.KeywordCase(req.KeywordCase)
+	}
+	if req.IndentWidth != nil {
+		opts.IndentWidth = *req.IndentWidth
+	}
+	opts.UseTabs = req.UseTabs
+
+	if err := opts.Validate(); err != nil {
+		response.Error(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	response.JSON(w, http.StatusOK, FormatSQLResponse{Query: sqlformat.Format(req.Query, opts)})
+}
+
+// queryLogText returns the SQL to store in the query log, pretty-printed
+// when the project's query policy asks for it
+func queryLogText(project *models.Project, query string) string {
+	if project.QueryPolicy == nil || !project.QueryPolicy.FormatQueryLog {
+		return query
+	}
+	return sqlformat.Format(query, sqlformat.DefaultOptions(sqlparser.ParseDialect(project.DatabaseType)))
+}
@@ -0,0 +1,187 @@
+// internal/handlers/stream.go
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/ephy-lab/ai-db-assistant/internal/models"
+	"github.com/ephy-lab/ai-db-assistant/pkg/proxyclient"
+	"github.com/ephy-lab/ai-db-assistant/pkg/response"
+	"github.com/ephy-lab/ai-db-assistant/pkg/sqlparser"
+)
+
+const (
+	// streamChunkRows is the number of rows masked and flushed together
+	streamChunkRows = 100
+	// streamPreviewRows bounds the rows of a streamed result kept in the query history
+	streamPreviewRows = 20
+)
+
+// StreamLine is one NDJSON line of a streamed query result. The end line
+// also reports truncation and masking like ExecuteSQLResult.
+type StreamLine struct {
+	proxyclient.StreamMessage
+	Truncated    bool `json:"truncated,omitempty"`
+	MaxRows      int  `json:"max_rows,omitempty"`
+	MaskedValues int  `json:"masked_values,omitempty"`
+}
+
+// streamStatement runs a single statement and streams its rows to the
+// client as NDJSON, flushing every streamChunkRows rows. Only a bounded
+// preview of the result is stored in the query history.
+func (h *DatabaseHandler) streamStatement(ctx context.Context, w http.ResponseWriter, project *models.Project, statement sqlparser.Statement, params []proxyclient.QueryParam) {
+	dialect := sqlparser.ParseDialect(project.DatabaseType)
+
+	masker, err := projectMasker(project, h.maskingSecret)
+	if err != nil {
+		response.Error(w, http.StatusInternalServerError, "Failed to execute query: invalid masking rules: "+err.Error())
+		return
+	}
+	plan, err := masker.Plan(statement.Text, dialect)
+	if err != nil {
+		response.Error(w, http.StatusForbidden, "Query not allowed: "+err.Error())
+		return
+	}
+
+	// Cap reads at max_rows, fetching one extra row to detect truncation
+	maxRows := 0
+	query := statement.Text
+	if project.QueryPolicy != nil && project.QueryPolicy.MaxRows > 0 && statement.Type == sqlparser.QueryTypeSelect {
+		maxRows = project.QueryPolicy.MaxRows
+		query = sqlparser.LimitRows(query, dialect, maxRows+1)
+	}
+
+	queryLog := models.Query{
+		ProjectID:   project.ID,
+		Query:       queryLogText(project, statement.Text),
+		QueryType:   string(statement.Type),
+		Fingerprint: sqlparser.Fingerprint(statement.Text, dialect),
+	}
+
+	startTime := time.Now()
+	rows, err := h.executor.ExecuteSQLStreamContext(ctx, query, params)
+	if h.reconnectLost(ctx, project, err) {
+		rows, err = h.executor.ExecuteSQLStreamContext(ctx, query, params)
+	}
+	if err != nil {
+		queryLog.Status = "error"
+		queryLog.Error = err.Error()
+		queryLog.ExecutionTime = int(time.Since(startTime).Milliseconds())
+		h.db.Create(&queryLog)
+
+		response.Error(w, proxyErrorStatus(err), "Failed to execute query: "+err.Error())
+		return
+	}
+	defer rows.Close()
+
+	// The stream outlives the server's write timeout
+	controller := http.NewResponseController(w)
+	controller.SetWriteDeadline(time.Time{})
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.WriteHeader(http.StatusOK)
+
+	encoder := json.NewEncoder(w)
+	encoder.Encode(StreamLine{StreamMessage: proxyclient.StreamMessage{
+		Type:      proxyclient.StreamHeader,
+		QueryType: rows.QueryType(),
+		Columns:   rows.Columns(),
+	}})
+	controller.Flush()
+
+	end := StreamLine{StreamMessage: proxyclient.StreamMessage{Type: proxyclient.StreamEnd}}
+	preview := make([][]any, 0, streamPreviewRows)
+	chunk := make([][]any, 0, streamChunkRows)
+	total := 0
+	var writeErr error
+
+	// writeChunk masks and sends the buffered rows
+	writeChunk := func() {
+		end.MaskedValues += plan.MaskRows(rows.Columns(), chunk)
+		for _, row := range chunk {
+			if len(preview) < streamPreviewRows {
+				preview = append(preview, row)
+			}
+			if writeErr == nil {
+				writeErr = encoder.Encode(StreamLine{StreamMessage: proxyclient.StreamMessage{Type: proxyclient.StreamRow, Row: row}})
+			}
+		}
+		if writeErr == nil {
+			writeErr = controller.Flush()
+		}
+		chunk = chunk[:0]
+	}
+
+	for rows.Next() {
+		if maxRows > 0 && total == maxRows {
+			end.Truncated = true
+			end.MaxRows = maxRows
+			rows.Close()
+			break
+		}
+
+		chunk = append(chunk, rows.Row())
+		total++
+		if len(chunk) == streamChunkRows {
+			writeChunk()
+			if writeErr != nil {
+				// The client has gone; stop the query
+				rows.Close()
+				break
+			}
+		}
+	}
+	writeChunk()
+	queryLog.ExecutionTime = int(time.Since(startTime).Milliseconds())
+
+	streamErr := rows.Err()
+	if streamErr == nil && writeErr != nil {
+		streamErr = fmt.Errorf("failed to write stream: %w", writeErr)
+	}
+	if streamErr != nil {
+		queryLog.Status = "error"
+		queryLog.Error = streamErr.Error()
+		queryLog.RowsAffected = total
+		h.db.Create(&queryLog)
+
+		encoder.Encode(StreamLine{StreamMessage: proxyclient.StreamMessage{
+			Type:    proxyclient.StreamError,
+			Message: "Failed to execute query: " + streamErr.Error(),
+		}})
+		controller.Flush()
+		return
+	}
+
+	end.RowCount = total
+	if summary := rows.Summary(); summary != nil {
+		end.AffectedRows = summary.AffectedRows
+		end.Message = summary.Message
+	}
+
+	// Store a bounded preview of the result in the history
+	queryLog.Status = "success"
+	if total > 0 {
+		queryLog.RowsAffected = total
+		resultJSON, _ := json.Marshal(proxyclient.ExecuteSQLResponse{
+			Success:   true,
+			QueryType: rows.QueryType(),
+			Columns:   rows.Columns(),
+			Rows:      preview,
+			RowCount:  total,
+		})
+		queryLog.Result = string(resultJSON)
+	} else if end.AffectedRows > 0 {
+		queryLog.RowsAffected = end.AffectedRows
+		queryLog.Result = fmt.Sprintf("Affected rows: %d", end.AffectedRows)
+	} else if end.Message != "" {
+		queryLog.Result = end.Message
+	}
+	h.db.Create(&queryLog)
+
+	encoder.Encode(end)
+	controller.Flush()
+}
@@ -1,8 +1,11 @@
 package models
 
 import (
+	"encoding/json"
 	"time"
 	"gorm.io/gorm"
+
+	"github.com/ephy-lab/ai-db-assistant/pkg/schema"
 )
 
 type User struct {
@@ -28,21 +31,199 @@ type Project struct {
 	DeletedAt        gorm.DeletedAt `gorm:"index" json:"-"`
 	User             *User          `json:"user,omitempty"`
 	Permission       *Permission    `json:"permission,omitempty"`
+	QueryPolicy      *QueryPolicy   `json:"query_policy,omitempty"`
+	MaskingRules     []MaskingRule  `json:"masking_rules,omitempty"`
 	Queries          []Query        `json:"queries,omitempty"`
 	Messages         []Message      `json:"messages,omitempty"`
 }
 
 type Permission struct {
-	ID          uint           `gorm:"primarykey" json:"id"`
-	ProjectID   uint           `gorm:"uniqueIndex;not null" json:"project_id"`
-	AllowDDL    bool           `gorm:"not null" json:"allow_ddl"`
-	AllowWrite  bool           `gorm:"not null" json:"allow_write"`
-	AllowRead   bool           `gorm:"not null" json:"allow_read"`
-	AllowDelete bool           `gorm:"not null" json:"allow_delete"`
-	CreatedAt   time.Time      `json:"created_at"`
-	UpdatedAt   time.Time      `json:"updated_at"`
-	DeletedAt   gorm.DeletedAt `gorm:"index" json:"-"`
-	Project     Project        `json:"project,omitempty"`
+	ID               uint           `gorm:"primarykey" json:"id"`
+	ProjectID        uint           `gorm:"uniqueIndex;not null" json:"project_id"`
+	AllowDDL         bool           `gorm:"not null" json:"allow_ddl"`
+	AllowWrite       bool           `gorm:"not null" json:"allow_write"`
+	AllowRead        bool           `gorm:"not null" json:"allow_read"`
+	AllowDelete      bool           `gorm:"not null" json:"allow_delete"`
+	AllowDCL         bool           `gorm:"not null;default:false" json:"allow_dcl"`
+	AllowTransaction bool           `gorm:"not null;default:false" json:"allow_transaction"`
+	AllowCall        bool           `gorm:"not null;default:false" json:"allow_call"`
+	AllowBulkLoad    bool           `gorm:"not null;default:false" json:"allow_bulk_load"`
+	AllowAdmin       bool           `gorm:"not null;default:false" json:"allow_admin"`
+	CreatedAt        time.Time      `json:"created_at"`
+	UpdatedAt        time.Time      `json:"updated_at"`
+	DeletedAt        gorm.DeletedAt `gorm:"index" json:"-"`
+	Project          Project        `json:"project,omitempty"`
+}
+
+// PermissionFlags is a snapshot of a project's permission flags
+type PermissionFlags struct {
+	AllowDDL         bool `json:"allow_ddl"`
+	AllowWrite       bool `json:"allow_write"`
+	AllowRead        bool `json:"allow_read"`
+	AllowDelete      bool `json:"allow_delete"`
+	AllowDCL         bool `json:"allow_dcl"`
+	AllowTransaction bool `json:"allow_transaction"`
+	AllowCall        bool `json:"allow_call"`
+	AllowBulkLoad    bool `json:"allow_bulk_load"`
+	AllowAdmin       bool `json:"allow_admin"`
+
+	// missing has a bit set, in permissionFlagKeys order, for each flag a
+	// stored version was recorded without because it did not exist yet
+	missing uint16
+}
+
+// permissionFlagKeys are the JSON keys of the flags, in field order
+var permissionFlagKeys = []string{
+	"allow_ddl", "allow_write", "allow_read", "allow_delete", "allow_dcl",
+	"allow_transaction", "allow_call", "allow_bulk_load", "allow_admin",
+}
+
+// UnmarshalJSON decodes stored flags, noting the ones absent from versions
+// recorded before those flags were added
+func (f *PermissionFlags) UnmarshalJSON(data []byte) error {
+	type plain PermissionFlags
+	if err := json.Unmarshal(data, (*plain)(f)); err != nil {
+		return err
+	}
+
+	var present map[string]json.RawMessage
+	if err := json.Unmarshal(data, &present); err != nil {
+		return err
+	}
+	f.missing = 0
+	for i, key := range permissionFlagKeys {
+		if _, ok := present[key]; !ok {
+			f.missing |= 1 << i
+		}
+	}
+	return nil
+}
+
+// Missing lists the flags the stored version was recorded without
+func (f PermissionFlags) Missing() []string {
+	var keys []string
+	for i, key := range permissionFlagKeys {
+		if f.missing&(1<<i) != 0 {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// Flags returns the current permission flags as a snapshot
+func (p *Permission) Flags() PermissionFlags {
+	return PermissionFlags{
+		AllowDDL:         p.AllowDDL,
+		AllowWrite:       p.AllowWrite,
+		AllowRead:        p.AllowRead,
+		AllowDelete:      p.AllowDelete,
+		AllowDCL:         p.AllowDCL,
+		AllowTransaction: p.AllowTransaction,
+		AllowCall:        p.AllowCall,
+		AllowBulkLoad:    p.AllowBulkLoad,
+		AllowAdmin:       p.AllowAdmin,
+	}
+}
+
+// SetFlags overwrites the permission flags from a snapshot. Flags missing
+// from the snapshot keep their current values.
+func (p *Permission) SetFlags(flags PermissionFlags) {
+	fields := []*bool{
+		&p.AllowDDL, &p.AllowWrite, &p.AllowRead, &p.AllowDelete, &p.AllowDCL,
+		&p.AllowTransaction, &p.AllowCall, &p.AllowBulkLoad, &p.AllowAdmin,
+	}
+	values := []bool{
+		flags.AllowDDL, flags.AllowWrite, flags.AllowRead, flags.AllowDelete, flags.AllowDCL,
+		flags.AllowTransaction, flags.AllowCall, flags.AllowBulkLoad, flags.AllowAdmin,
+	}
+	for i, field := range fields {
+		if flags.missing&(1<<i) == 0 {
+			*field = values[i]
+		}
+	}
+}
+
+// PermissionVersion records a single change to a project's permissions
+type PermissionVersion struct {
+	ID         uint             `gorm:"primarykey" json:"id"`
+	ProjectID  uint             `gorm:"not null;uniqueIndex:idx_permission_versions_project_version" json:"project_id"`
+	Version    int              `gorm:"not null;uniqueIndex:idx_permission_versions_project_version" json:"version"`
+	ChangedBy  uint             `gorm:"not null" json:"changed_by"`
+	Reason     string           `gorm:"type:text" json:"reason,omitempty"`
+	OldValues  *PermissionFlags `gorm:"type:text;serializer:json" json:"old_values,omitempty"` // nil for the initial version
+	NewValues  PermissionFlags  `gorm:"type:text;serializer:json;not null" json:"new_values"`
+	RollbackOf *int             `json:"rollback_of,omitempty"` // version restored by this change, if any
+	CreatedAt  time.Time        `json:"created_at"`
+}
+
+// SchemaSnapshot is a version of a project's database schema. A version is
+// added only when introspection finds that the schema changed.
+type SchemaSnapshot struct {
+	ID         uint          `gorm:"primarykey" json:"id"`
+	ProjectID  uint          `gorm:"not null;uniqueIndex:idx_schema_snapshots_project_version" json:"project_id"`
+	Version    int           `gorm:"not null;uniqueIndex:idx_schema_snapshots_project_version" json:"version"`
+	Checksum   string        `gorm:"size:64;not null" json:"checksum"`
+	TableCount int           `gorm:"not null" json:"table_count"`
+	Schema     schema.Schema `gorm:"type:text;serializer:json;not null" json:"schema"`
+	CheckedAt  time.Time     `gorm:"not null" json:"checked_at"` // last introspection that found this version
+	CreatedAt  time.Time     `json:"created_at"`
+}
+
+// DefaultMaxRows is the row limit applied to reads for new projects
+const DefaultMaxRows = 1000
+
+// QueryPolicy holds per-project guardrails applied to executed SQL
+type QueryPolicy struct {
+	ID                  uint           `gorm:"primarykey" json:"id"`
+	ProjectID           uint           `gorm:"uniqueIndex;not null" json:"project_id"`
+	RequireWhereClause  bool           `gorm:"not null" json:"require_where_clause"`
+	MaxAffectedRows     int            `gorm:"not null" json:"max_affected_rows"` // 0 disables the dry-run estimate check
+	RequireConfirmation bool           `gorm:"not null" json:"require_confirmation"`
+	AllowMultiStatement bool           `gorm:"not null;default:false" json:"allow_multi_statement"`
+	FormatQueryLog      bool           `gorm:"not null;default:false" json:"format_query_log"` // store pretty-printed SQL in the query log
+	MaxRows             int            `gorm:"not null;default:0" json:"max_rows"`             // 0 disables the row limit on reads
+	MaxConnections      int            `gorm:"not null;default:0" json:"max_connections"`      // 0 uses the server's default pool size
+	CreatedAt           time.Time      `json:"created_at"`
+	UpdatedAt           time.Time      `json:"updated_at"`
+	DeletedAt           gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+// NewQueryPolicy returns the default guardrails of a project
+func NewQueryPolicy(projectID uint) QueryPolicy {
+	return QueryPolicy{
+		ProjectID:          projectID,
+		RequireWhereClause: true,
+		MaxRows:            DefaultMaxRows,
+	}
+}
+
+// DBSession is a user's proxy session for a project. The proxy keeps one
+// database connection per session, so requests for different projects never
+// share a connection.
+type DBSession struct {
+	ID             uint       `gorm:"primarykey" json:"id"`
+	ProjectID      uint       `gorm:"not null;uniqueIndex:idx_db_session_project_user" json:"project_id"`
+	UserID         uint       `gorm:"not null;uniqueIndex:idx_db_session_project_user" json:"user_id"`
+	SessionID      string     `gorm:"not null;uniqueIndex" json:"session_id"`
+	Connected      bool       `gorm:"not null;default:false" json:"connected"`
+	ProxyBackend   string     `json:"proxy_backend,omitempty"` // proxy server the session connected through
+	ConnectedAt    *time.Time `json:"connected_at,omitempty"`
+	DisconnectedAt *time.Time `json:"disconnected_at,omitempty"`
+	LastUsedAt     *time.Time `json:"last_used_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+// MaskingRule redacts or hashes sensitive values in a project's query results
+type MaskingRule struct {
+	ID        uint           `gorm:"primarykey" json:"id"`
+	ProjectID uint           `gorm:"not null;index" json:"project_id"`
+	Kind      string         `gorm:"not null" json:"kind"`    // "column", "table_column" or "detect"
+	Pattern   string         `gorm:"not null" json:"pattern"` // column glob, table.column, or email/phone/card
+	Action    string         `gorm:"not null" json:"action"`  // "redact" or "hash"
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
 }
 
 type Query struct {
@@ -50,6 +231,7 @@ type Query struct {
 	ProjectID     uint           `gorm:"not null;index" json:"project_id"`
 	Query         string         `gorm:"type:text;not null" json:"query"`
 	QueryType     string         `json:"query_type,omitempty"`
+	Fingerprint   string         `gorm:"size:16;index" json:"fingerprint,omitempty"`
 	Status        string         `gorm:"not null" json:"status"` // "success", "error", "generated", "pending"
 	Result        string         `gorm:"type:text" json:"result"`
 	Error         string         `gorm:"type:text" json:"error,omitempty"`
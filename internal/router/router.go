@@ -2,16 +2,17 @@
 package router
 
 import (
-	"net/http"
-
 	"github.com/gorilla/mux"
 	"github.com/ephy-lab/ai-db-assistant/internal/config"
 	"github.com/ephy-lab/ai-db-assistant/internal/handlers"
 	"github.com/ephy-lab/ai-db-assistant/internal/middleware"
+	"github.com/ephy-lab/ai-db-assistant/pkg/dbpool"
+	"github.com/ephy-lab/ai-db-assistant/pkg/executor"
+	"github.com/ephy-lab/ai-db-assistant/pkg/proxyclient"
 	"gorm.io/gorm"
 )
 
-func NewRouter(db *gorm.DB, cfg *config.Config) *mux.Router {
+func NewRouter(db *gorm.DB, cfg *config.Config, proxy proxyclient.Proxy, exec executor.Executor, pools *dbpool.Manager) *mux.Router {
 	r := mux.NewRouter()
 
 	// Apply global middleware
@@ -20,16 +21,16 @@ func NewRouter(db *gorm.DB, cfg *config.Config) *mux.Router {
 
 	// Initialize handlers
 	authHandler := handlers.NewAuthHandler(db, cfg)
-	projectHandler := handlers.NewProjectHandler(db)
-	chatHandler := handlers.NewChatHandler(db, cfg)
-	dashboardHandler := handlers.NewDashboardHandler(db)
-	databaseHandler := handlers.NewDatabaseHandler(db, cfg)
+	projectHandler := handlers.NewProjectHandler(db, pools)
+	chatHandler := handlers.NewChatHandler(db, cfg, proxy, pools)
+	dashboardHandler := handlers.NewDashboardHandler(db, pools)
+	databaseHandler := handlers.NewDatabaseHandler(db, cfg, exec)
+	sqlHandler := handlers.NewSQLHandler()
+	healthHandler := handlers.NewHealthHandler(cfg, proxy, exec)
+	schemaHandler := handlers.NewSchemaHandler(db, pools)
 
 	// Health check (before API routes)
-	r.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
-		w.WriteHeader(http.StatusOK)
-		w.Write([]byte("OK"))
-	}).Methods("GET", "OPTIONS")
+	r.HandleFunc("/health", healthHandler.Health).Methods("GET", "OPTIONS")
 
 	// API routes
 	api := r.PathPrefix("/api").Subrouter()
@@ -50,11 +51,22 @@ func NewRouter(db *gorm.DB, cfg *config.Config) *mux.Router {
 	protected.HandleFunc("/projects/{id}", projectHandler.UpdateProject).Methods("PUT", "OPTIONS")
 	protected.HandleFunc("/projects/{id}", projectHandler.DeleteProject).Methods("DELETE", "OPTIONS")
 	protected.HandleFunc("/projects/{id}/permissions", projectHandler.GetProjectPermissions).Methods("GET", "OPTIONS")
-
+	protected.HandleFunc("/projects/{id}/permissions/history", projectHandler.GetPermissionHistory).Methods("GET", "OPTIONS")
+	protected.HandleFunc("/projects/{id}/permissions/rollback", projectHandler.RollbackPermissions).Methods("POST", "OPTIONS")
+	protected.HandleFunc("/projects/{id}/masking-rules", projectHandler.GetMaskingRules).Methods("GET", "OPTIONS")
+	protected.HandleFunc("/projects/{id}/masking-rules", projectHandler.CreateMaskingRule).Methods("POST", "OPTIONS")
+	protected.HandleFunc("/projects/{id}/masking-rules/{rule_id}", projectHandler.UpdateMaskingRule).Methods("PUT", "OPTIONS")
+	protected.HandleFunc("/projects/{id}/masking-rules/{rule_id}", projectHandler.DeleteMaskingRule).Methods("DELETE", "OPTIONS")
 
 	// Dashboard routes (must come before /projects/{id}/summary to avoid conflict)
 	protected.HandleFunc("/dashboard", dashboardHandler.GetUserDashboard).Methods("GET", "OPTIONS")
 	protected.HandleFunc("/projects/{id}/summary", dashboardHandler.GetProjectSummary).Methods("GET", "OPTIONS")
+	protected.HandleFunc("/projects/{id}/queries/stats", dashboardHandler.GetQueryStats).Methods("GET", "OPTIONS")
+	protected.HandleFunc("/projects/{id}/pool", dashboardHandler.GetPoolStats).Methods("GET", "OPTIONS")
+
+	// Schema routes
+	protected.HandleFunc("/projects/{id}/schema", schemaHandler.GetSchema).Methods("GET", "OPTIONS")
+	protected.HandleFunc("/projects/{id}/schema/refresh", schemaHandler.RefreshSchema).Methods("POST", "OPTIONS")
 
 	// Chat routes
 	protected.HandleFunc("/chat/{project_id}", chatHandler.SendMessage).Methods("POST", "OPTIONS")
@@ -67,5 +79,13 @@ func NewRouter(db *gorm.DB, cfg *config.Config) *mux.Router {
 	protected.HandleFunc("/projects/{id}/validate-sql", databaseHandler.ValidateSQL).Methods("POST", "OPTIONS")
 	protected.HandleFunc("/projects/{id}/db-info", databaseHandler.GetDBInfo).Methods("GET", "OPTIONS")
 
+	// SQL utility routes
+	protected.HandleFunc("/sql/format", sqlHandler.FormatSQL).Methods("POST", "OPTIONS")
+
+	// Least-privilege role routes
+	protected.HandleFunc("/projects/{id}/roles/statements", databaseHandler.GetRoleStatements).Methods("GET", "OPTIONS")
+	protected.HandleFunc("/projects/{id}/roles/apply", databaseHandler.ApplyRole).Methods("POST", "OPTIONS")
+	protected.HandleFunc("/projects/{id}/roles/check", databaseHandler.CheckGrants).Methods("GET", "OPTIONS")
+
 	return r
 }
\ No newline at end of file
@@ -0,0 +1,273 @@
+// pkg/dbpool/dbpool.go
+package dbpool
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ephy-lab/ai-db-assistant/pkg/sqlparser"
+)
+
+// Limits bound a project's connection pool
+type Limits struct {
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+}
+
+// DefaultLimits apply to projects that do not set their own
+var DefaultLimits = Limits{
+	MaxOpenConns:    5,
+	MaxIdleConns:    2,
+	ConnMaxLifetime: 30 * time.Minute,
+}
+
+// healthCheckTimeout bounds a single pool's health check
+const healthCheckTimeout = 5 * time.Second
+
+// Manager keeps one connection pool per project. Pools are created on first
+// use, closed after IdleTimeout without use and health-checked every
+// HealthInterval while Run is active.
+type Manager struct {
+	IdleTimeout    time.Duration
+	HealthInterval time.Duration
+
+	mu    sync.Mutex
+	pools map[uint]*pool
+	now   func() time.Time
+}
+
+type pool struct {
+	db               *sql.DB
+	target           *Target
+	connectionString string
+	limits           Limits
+	createdAt        time.Time
+	lastUsedAt       time.Time
+	lastCheckedAt    time.Time
+	healthy          bool
+	lastError        string
+}
+
+// Stats describes a project's pool
+type Stats struct {
+	ProjectID     uint      `json:"project_id"`
+	DatabaseType  string    `json:"database_type"`
+	Host          string    `json:"host"`
+	Database      string    `json:"database"`
+	MaxOpenConns  int       `json:"max_open_connections"`
+	OpenConns     int       `json:"open_connections"`
+	InUse         int       `json:"in_use"`
+	Idle          int       `json:"idle"`
+	WaitCount     int64     `json:"wait_count"`
+	WaitDuration  float64   `json:"wait_duration_ms"`
+	Healthy       bool      `json:"healthy"`
+	LastError     string    `json:"last_error,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+	LastUsedAt    time.Time `json:"last_used_at"`
+	LastCheckedAt time.Time `json:"last_checked_at"`
+}
+
+// NewManager returns a manager with no pools
+func NewManager(idleTimeout, healthInterval time.Duration) *Manager {
+	return &Manager{
+		IdleTimeout:    idleTimeout,
+		HealthInterval: healthInterval,
+		pools:          make(map[uint]*pool),
+		now:            time.Now,
+	}
+}
+
+// Get returns the pool for a project, creating it if needed. A pool opened
+// with a different connection string or limits is replaced. Zero limit
+// fields fall back to DefaultLimits.
+func (m *Manager) Get(projectID uint, dbType, connectionString string, limits Limits) (*sql.DB, error) {
+	limits = limits.withDefaults()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	if p, ok := m.pools[projectID]; ok {
+		if p.connectionString == connectionString && p.target.Type == string(sqlparser.ParseDialect(dbType)) && p.limits == limits {
+			p.lastUsedAt = now
+			return p.db, nil
+		}
+		delete(m.pools, projectID)
+		p.db.Close()
+	}
+
+	target, err := ParseConnectionString(dbType, connectionString)
+	if err != nil {
+		return nil, err
+	}
+	db, err := sql.Open(target.Driver, target.DSN)
+	if err != nil {
+		return nil, err
+	}
+	db.SetMaxOpenConns(limits.MaxOpenConns)
+	db.SetMaxIdleConns(limits.MaxIdleConns)
+	db.SetConnMaxLifetime(limits.ConnMaxLifetime)
+
+	m.pools[projectID] = &pool{
+		db:               db,
+		target:           target,
+		connectionString: connectionString,
+		limits:           limits,
+		createdAt:        now,
+		lastUsedAt:       now,
+		healthy:          true,
+	}
+	return db, nil
+}
+
+// Invalidate closes a project's pool, e.g. after its connection string
+// changed. The next Get opens a new one.
+func (m *Manager) Invalidate(projectID uint) {
+	m.mu.Lock()
+	p, ok := m.pools[projectID]
+	delete(m.pools, projectID)
+	m.mu.Unlock()
+
+	if ok {
+		p.db.Close()
+	}
+}
+
+// Stats returns the state of a project's pool, or false if it has none
+func (m *Manager) Stats(projectID uint) (Stats, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	p, ok := m.pools[projectID]
+	if !ok {
+		return Stats{}, false
+	}
+	return p.stats(projectID), true
+}
+
+// AllStats returns the state of every pool, ordered by project
+func (m *Manager) AllStats() []Stats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stats := make([]Stats, 0, len(m.pools))
+	for projectID, p := range m.pools {
+		stats = append(stats, p.stats(projectID))
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].ProjectID < stats[j].ProjectID })
+	return stats
+}
+
+// Run evicts idle pools and health-checks the others until ctx is done,
+// then closes every pool
+func (m *Manager) Run(ctx context.Context) {
+	ticker := time.NewTicker(m.HealthInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			m.Close()
+			return
+		case <-ticker.C:
+			m.evictIdle()
+			m.checkHealth(ctx)
+		}
+	}
+}
+
+// Close closes every pool
+func (m *Manager) Close() {
+	m.mu.Lock()
+	pools := m.pools
+	m.pools = make(map[uint]*pool)
+	m.mu.Unlock()
+
+	for _, p := range pools {
+		p.db.Close()
+	}
+}
+
+func (m *Manager) evictIdle() {
+	m.mu.Lock()
+	var idle []*pool
+	cutoff := m.now().Add(-m.IdleTimeout)
+	for projectID, p := range m.pools {
+		if p.lastUsedAt.Before(cutoff) && p.db.Stats().InUse == 0 {
+			idle = append(idle, p)
+			delete(m.pools, projectID)
+		}
+	}
+	m.mu.Unlock()
+
+	for _, p := range idle {
+		p.db.Close()
+	}
+}
+
+// checkHealth pings every pool without holding the lock, so a slow
+// database does not block Get for other projects
+func (m *Manager) checkHealth(ctx context.Context) {
+	m.mu.Lock()
+	pools := make([]*pool, 0, len(m.pools))
+	for _, p := range m.pools {
+		pools = append(pools, p)
+	}
+	m.mu.Unlock()
+
+	for _, p := range pools {
+		pingCtx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+		err := p.db.PingContext(pingCtx)
+		cancel()
+
+		m.mu.Lock()
+		p.lastCheckedAt = m.now()
+		p.healthy = err == nil
+		p.lastError = ""
+		if err != nil {
+			p.lastError = err.Error()
+		}
+		m.mu.Unlock()
+	}
+}
+
+func (p *pool) stats(projectID uint) Stats {
+	dbStats := p.db.Stats()
+	return Stats{
+		ProjectID:     projectID,
+		DatabaseType:  p.target.Type,
+		Host:          p.target.Host,
+		Database:      p.target.Database,
+		MaxOpenConns:  dbStats.MaxOpenConnections,
+		OpenConns:     dbStats.OpenConnections,
+		InUse:         dbStats.InUse,
+		Idle:          dbStats.Idle,
+		WaitCount:     dbStats.WaitCount,
+		WaitDuration:  float64(dbStats.WaitDuration.Microseconds()) / 1000,
+		Healthy:       p.healthy,
+		LastError:     p.lastError,
+		CreatedAt:     p.createdAt,
+		LastUsedAt:    p.lastUsedAt,
+		LastCheckedAt: p.lastCheckedAt,
+	}
+}
+
+func (l Limits) withDefaults() Limits {
+	if l.MaxOpenConns <= 0 {
+		l.MaxOpenConns = DefaultLimits.MaxOpenConns
+	}
+	if l.MaxIdleConns <= 0 {
+		l.MaxIdleConns = DefaultLimits.MaxIdleConns
+	}
+	if l.MaxIdleConns > l.MaxOpenConns {
+		l.MaxIdleConns = l.MaxOpenConns
+	}
+	if l.ConnMaxLifetime <= 0 {
+		l.ConnMaxLifetime = DefaultLimits.ConnMaxLifetime
+	}
+	return l
+}
@@ -0,0 +1,89 @@
+// pkg/dbpool/dsn.go
+package dbpool
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/ephy-lab/ai-db-assistant/pkg/sqlparser"
+	"github.com/go-sql-driver/mysql"
+	_ "github.com/jackc/pgx/v5/stdlib"
+)
+
+// Target is a database a connection string points at
+type Target struct {
+	Driver   string // database/sql driver name
+	DSN      string // data source name in the driver's format
+	Type     string // postgresql or mysql
+	Host     string
+	Port     int
+	Database string
+}
+
+// ParseConnectionString maps a project connection string to a database/sql
+// driver and DSN. URLs may name a driver in the scheme, as in
+// postgresql+psycopg2:// or mysql+pymysql://; it is ignored.
+func ParseConnectionString(dbType, connectionString string) (*Target, error) {
+	dialect := sqlparser.ParseDialect(dbType)
+	info := &Target{Type: string(dialect)}
+
+	u, err := url.Parse(connectionString)
+	isURL := err == nil && u.Scheme != "" && u.Host != ""
+	if isURL {
+		info.Host = u.Hostname()
+		info.Port, _ = strconv.Atoi(u.Port())
+		info.Database = strings.TrimPrefix(u.Path, "/")
+	}
+
+	switch dialect {
+	case sqlparser.DialectPostgres:
+		if info.Port == 0 {
+			info.Port = 5432
+		}
+		if !isURL {
+			// keyword/value form, e.g. "host=localhost dbname=app"
+			info.Driver, info.DSN = "pgx", connectionString
+			return info, nil
+		}
+		u.Scheme = "postgres"
+		info.Driver, info.DSN = "pgx", u.String()
+		return info, nil
+
+	case sqlparser.DialectMySQL:
+		if !isURL {
+			cfg, err := mysql.ParseDSN(connectionString)
+			if err != nil {
+				return nil, fmt.Errorf("invalid MySQL connection string: %w", err)
+			}
+			host, port, _ := net.SplitHostPort(cfg.Addr)
+			info.Host = host
+			info.Port, _ = strconv.Atoi(port)
+			info.Database = cfg.DBName
+			info.Driver, info.DSN = "mysql", connectionString
+			return info, nil
+		}
+
+		if info.Port == 0 {
+			info.Port = 3306
+		}
+		cfg := mysql.NewConfig()
+		cfg.User = u.User.Username()
+		cfg.Passwd, _ = u.User.Password()
+		cfg.Net = "tcp"
+		cfg.Addr = net.JoinHostPort(info.Host, strconv.Itoa(info.Port))
+		cfg.DBName = info.Database
+		for key, values := range u.Query() {
+			if cfg.Params == nil {
+				cfg.Params = make(map[string]string)
+			}
+			cfg.Params[key] = values[0]
+		}
+		info.Driver, info.DSN = "mysql", cfg.FormatDSN()
+		return info, nil
+	}
+
+	return nil, fmt.Errorf("unsupported database type %q", dbType)
+}
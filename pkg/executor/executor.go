@@ -0,0 +1,45 @@
+// pkg/executor/executor.go
+package executor
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ephy-lab/ai-db-assistant/pkg/proxyclient"
+)
+
+// Backend names accepted by New
+const (
+	BackendProxy  = "proxy"
+	BackendNative = "native"
+)
+
+// Executor runs database operations for a session, identified by the
+// session ID set on ctx with proxyclient.WithSessionID. Failures are
+// classified with the proxyclient error values so callers handle every
+// backend alike.
+type Executor interface {
+	ConnectDBContext(ctx context.Context, dbType, connectionString string) (*proxyclient.ConnectDBResponse, error)
+	DisconnectDBContext(ctx context.Context) (*proxyclient.DisconnectDBResponse, error)
+	ExecuteSQLWithParamsContext(ctx context.Context, query string, params []proxyclient.QueryParam, dryRun bool) (*proxyclient.ExecuteSQLResponse, error)
+	ExecuteSQLStreamContext(ctx context.Context, query string, params []proxyclient.QueryParam) (*proxyclient.RowIterator, error)
+	ValidateSQLContext(ctx context.Context, query string) (*proxyclient.ValidateSQLResponse, error)
+	GetDBInfoContext(ctx context.Context) (*proxyclient.DBInfoResponse, error)
+}
+
+var (
+	_ Executor = (*proxyclient.Client)(nil)
+	_ Executor = (*Native)(nil)
+)
+
+// New returns the executor for a backend name: the proxy, or the native
+// executor that connects to databases directly
+func New(backend string, proxy proxyclient.Proxy) (Executor, error) {
+	switch backend {
+	case BackendProxy, "":
+		return proxy, nil
+	case BackendNative:
+		return NewNative(), nil
+	}
+	return nil, fmt.Errorf("unknown execution backend %q: must be %q or %q", backend, BackendProxy, BackendNative)
+}
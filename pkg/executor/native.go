@@ -0,0 +1,416 @@
+// pkg/executor/native.go
+package executor
+
+import (
+	"context"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/ephy-lab/ai-db-assistant/pkg/dbpool"
+	"github.com/ephy-lab/ai-db-assistant/pkg/proxyclient"
+	"github.com/ephy-lab/ai-db-assistant/pkg/sqlparser"
+)
+
+// Native executes queries with database/sql, connecting to project
+// databases directly instead of through the proxy. Each session has its own
+// connection pool, kept in memory until it is disconnected.
+type Native struct {
+	mu       sync.Mutex
+	sessions map[string]*nativeSession
+}
+
+type nativeSession struct {
+	db      *sql.DB
+	dialect sqlparser.Dialect
+	info    proxyclient.ConnectionInfo
+}
+
+// queryer is implemented by *sql.DB and *sql.Tx
+type queryer interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+}
+
+// NewNative returns a native executor with no connections
+func NewNative() *Native {
+	return &Native{sessions: make(map[string]*nativeSession)}
+}
+
+// ConnectDBContext opens a connection pool for the session, replacing any
+// previous one. A database that cannot be reached is reported in the
+// response, as the proxy does, rather than as an error.
+func (n *Native) ConnectDBContext(ctx context.Context, dbType, connectionString string) (*proxyclient.ConnectDBResponse, error) {
+	target, err := dbpool.ParseConnectionString(dbType, connectionString)
+	if err != nil {
+		return &proxyclient.ConnectDBResponse{Success: false, Message: err.Error()}, nil
+	}
+	info := proxyclient.ConnectionInfo{
+		Type:     target.Type,
+		Host:     target.Host,
+		Port:     target.Port,
+		Database: target.Database,
+	}
+
+	db, err := sql.Open(target.Driver, target.DSN)
+	if err != nil {
+		return &proxyclient.ConnectDBResponse{Success: false, Message: "Failed to connect: " + err.Error()}, nil
+	}
+	db.SetMaxOpenConns(5)
+	db.SetConnMaxIdleTime(5 * time.Minute)
+
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("request cancelled: %w", ctx.Err())
+		}
+		return &proxyclient.ConnectDBResponse{Success: false, Message: "Failed to connect: " + err.Error()}, nil
+	}
+
+	info.Connected = true
+	session := &nativeSession{db: db, dialect: sqlparser.ParseDialect(dbType), info: info}
+
+	sessionID, _ := proxyclient.SessionID(ctx)
+	n.mu.Lock()
+	previous := n.sessions[sessionID]
+	n.sessions[sessionID] = session
+	n.mu.Unlock()
+	if previous != nil {
+		previous.db.Close()
+	}
+
+	return &proxyclient.ConnectDBResponse{
+		Success:        true,
+		Message:        fmt.Sprintf("Connected to %s database", info.Type),
+		ConnectionInfo: info,
+	}, nil
+}
+
+// DisconnectDBContext closes the session's connection pool
+func (n *Native) DisconnectDBContext(ctx context.Context) (*proxyclient.DisconnectDBResponse, error) {
+	sessionID, _ := proxyclient.SessionID(ctx)
+	n.mu.Lock()
+	session := n.sessions[sessionID]
+	delete(n.sessions, sessionID)
+	n.mu.Unlock()
+
+	if session == nil {
+		return &proxyclient.DisconnectDBResponse{Success: false, Message: "No active database connection"}, nil
+	}
+
+	session.db.Close()
+	previous := session.info
+	previous.Connected = false
+	return &proxyclient.DisconnectDBResponse{
+		Success:            true,
+		Message:            "Disconnected from database",
+		PreviousConnection: previous,
+	}, nil
+}
+
+// ExecuteSQLWithParamsContext runs a query with positional parameters. A
+// dry run executes it in a transaction that is rolled back.
+func (n *Native) ExecuteSQLWithParamsContext(ctx context.Context, query string, params []proxyclient.QueryParam, dryRun bool) (*proxyclient.ExecuteSQLResponse, error) {
+	session, err := n.session(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if dryRun {
+		return session.dryRun(ctx, query, args(params))
+	}
+	return session.execute(ctx, session.db, query, args(params))
+}
+
+// ExecuteSQLStreamContext runs a query and returns an iterator reading its
+// rows from the database as the caller consumes them
+func (n *Native) ExecuteSQLStreamContext(ctx context.Context, query string, params []proxyclient.QueryParam) (*proxyclient.RowIterator, error) {
+	session, err := n.session(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if !sqlparser.ReturnsRows(query, session.dialect) {
+		resp, err := session.execute(ctx, session.db, query, args(params))
+		if err != nil {
+			return nil, err
+		}
+		return proxyclient.NewRowIterator(resp), nil
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	rows, err := session.db.QueryContext(ctx, query, args(params)...)
+	if err != nil {
+		cancel()
+		return nil, queryError(ctx, err)
+	}
+
+	columns, err := rows.Columns()
+	if err != nil {
+		rows.Close()
+		cancel()
+		return nil, queryError(ctx, err)
+	}
+
+	count := 0
+	next := func() (proxyclient.StreamMessage, error) {
+		if rows.Next() {
+			row, err := scanRow(rows, len(columns))
+			if err != nil {
+				return proxyclient.StreamMessage{}, queryError(ctx, err)
+			}
+			count++
+			return proxyclient.StreamMessage{Type: proxyclient.StreamRow, Row: row}, nil
+		}
+		if err := rows.Err(); err != nil {
+			return proxyclient.StreamMessage{}, queryError(ctx, err)
+		}
+		return proxyclient.StreamMessage{Type: proxyclient.StreamEnd, RowCount: count, Message: "Query executed successfully"}, nil
+	}
+
+	// Cancelling first stops a query that is still producing rows, so
+	// closing does not wait for the rest of the result
+	release := func(bool) {
+		cancel()
+		rows.Close()
+	}
+
+	queryType := string(sqlparser.GetQueryType(query, session.dialect))
+	return proxyclient.NewRowIteratorFunc(queryType, columns, next, release), nil
+}
+
+// ValidateSQLContext checks a query by asking the database to EXPLAIN it
+func (n *Native) ValidateSQLContext(ctx context.Context, query string) (*proxyclient.ValidateSQLResponse, error) {
+	session, err := n.session(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if !explainable(query, session.dialect) {
+		return &proxyclient.ValidateSQLResponse{
+			Success: false,
+			DryRun:  true,
+			Message: "Only SELECT, INSERT, UPDATE and DELETE statements can be validated with EXPLAIN",
+		}, nil
+	}
+
+	rows, err := session.db.QueryContext(ctx, "EXPLAIN "+query)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("request cancelled: %w", ctx.Err())
+		}
+		return &proxyclient.ValidateSQLResponse{Success: false, DryRun: true, Message: err.Error()}, nil
+	}
+	defer rows.Close()
+
+	result, err := readRows(rows)
+	if err != nil {
+		return nil, queryError(ctx, err)
+	}
+
+	explain := make([]string, 0, len(result.Rows))
+	for _, row := range result.Rows {
+		parts := make([]string, 0, len(row))
+		for _, value := range row {
+			if value != nil {
+				parts = append(parts, fmt.Sprint(value))
+			}
+		}
+		explain = append(explain, strings.Join(parts, " | "))
+	}
+
+	return &proxyclient.ValidateSQLResponse{
+		Success: true,
+		DryRun:  true,
+		Explain: explain,
+		Message: "Query is valid",
+	}, nil
+}
+
+// GetDBInfoContext describes the session's connection
+func (n *Native) GetDBInfoContext(ctx context.Context) (*proxyclient.DBInfoResponse, error) {
+	sessionID, _ := proxyclient.SessionID(ctx)
+	n.mu.Lock()
+	session := n.sessions[sessionID]
+	n.mu.Unlock()
+
+	if session == nil {
+		return &proxyclient.DBInfoResponse{Connected: false}, nil
+	}
+
+	info := session.info
+	return &proxyclient.DBInfoResponse{
+		Type:      info.Type,
+		Host:      info.Host,
+		Port:      info.Port,
+		Database:  info.Database,
+		Connected: session.db.PingContext(ctx) == nil,
+	}, nil
+}
+
+func (n *Native) session(ctx context.Context) (*nativeSession, error) {
+	sessionID, _ := proxyclient.SessionID(ctx)
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	session, ok := n.sessions[sessionID]
+	if !ok {
+		return nil, &queryFailedError{"not connected to a database"}
+	}
+	return session, nil
+}
+
+func (s *nativeSession) execute(ctx context.Context, q queryer, query string, args []any) (*proxyclient.ExecuteSQLResponse, error) {
+	queryType := string(sqlparser.GetQueryType(query, s.dialect))
+
+	if sqlparser.ReturnsRows(query, s.dialect) {
+		rows, err := q.QueryContext(ctx, query, args...)
+		if err != nil {
+			return nil, queryError(ctx, err)
+		}
+		defer rows.Close()
+
+		resp, err := readRows(rows)
+		if err != nil {
+			return nil, queryError(ctx, err)
+		}
+		resp.QueryType = queryType
+		return resp, nil
+	}
+
+	result, err := q.ExecContext(ctx, query, args...)
+	if err != nil {
+		return nil, queryError(ctx, err)
+	}
+	affected, _ := result.RowsAffected()
+
+	return &proxyclient.ExecuteSQLResponse{
+		Success:      true,
+		QueryType:    queryType,
+		AffectedRows: int(affected),
+		Message:      "Query executed successfully",
+	}, nil
+}
+
+// dryRun executes the query in a transaction and rolls it back, reporting
+// what it would have done
+func (s *nativeSession) dryRun(ctx context.Context, query string, args []any) (*proxyclient.ExecuteSQLResponse, error) {
+	switch sqlparser.GetQueryType(query, s.dialect) {
+	case sqlparser.QueryTypeTCL:
+		return nil, &queryFailedError{"transaction control statements cannot be dry run"}
+	case sqlparser.QueryTypeDDL, sqlparser.QueryTypeDCL, sqlparser.QueryTypeAdmin:
+		// MySQL commits these implicitly, so they cannot be rolled back
+		if s.dialect == sqlparser.DialectMySQL {
+			return nil, &queryFailedError{"this statement cannot be dry run on MySQL because it commits implicitly"}
+		}
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, queryError(ctx, err)
+	}
+	defer tx.Rollback()
+
+	resp, err := s.execute(ctx, tx, query, args)
+	if err != nil {
+		return nil, err
+	}
+	resp.DryRun = true
+	resp.Message = "Dry run completed; changes were rolled back"
+	return resp, nil
+}
+
+func readRows(rows *sql.Rows) (*proxyclient.ExecuteSQLResponse, error) {
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+
+	result := [][]any{}
+	for rows.Next() {
+		row, err := scanRow(rows, len(columns))
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, row)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return &proxyclient.ExecuteSQLResponse{
+		Success:  true,
+		Columns:  columns,
+		Rows:     result,
+		RowCount: len(result),
+		Message:  "Query executed successfully",
+	}, nil
+}
+
+// scanRow reads the current row, converting driver values to ones that
+// encode to JSON the way the proxy returns them
+func scanRow(rows *sql.Rows, width int) ([]any, error) {
+	values := make([]any, width)
+	dest := make([]any, width)
+	for i := range values {
+		dest[i] = &values[i]
+	}
+	if err := rows.Scan(dest...); err != nil {
+		return nil, err
+	}
+
+	for i, value := range values {
+		if b, ok := value.([]byte); ok {
+			if utf8.Valid(b) {
+				values[i] = string(b)
+			} else {
+				values[i] = `\x` + hex.EncodeToString(b)
+			}
+		}
+	}
+	return values, nil
+}
+
+func args(params []proxyclient.QueryParam) []any {
+	out := make([]any, len(params))
+	for i, param := range params {
+		out[i] = param.Value
+	}
+	return out
+}
+
+func queryError(ctx context.Context, err error) error {
+	if ctx.Err() != nil {
+		return fmt.Errorf("request cancelled: %w", ctx.Err())
+	}
+	return &queryFailedError{err.Error()}
+}
+
+// queryFailedError is a database error. It matches proxyclient.ErrQueryFailed
+// so that callers classify it like a failure reported by the proxy.
+type queryFailedError struct {
+	message string
+}
+
+func (e *queryFailedError) Error() string {
+	return e.message
+}
+
+func (e *queryFailedError) Unwrap() error {
+	return proxyclient.ErrQueryFailed
+}
+
+// explainable reports whether the query is a statement EXPLAIN accepts
+func explainable(query string, dialect sqlparser.Dialect) bool {
+	for _, tok := range sqlparser.Tokenize(query, dialect) {
+		if tok.Kind == sqlparser.TokenComment {
+			continue
+		}
+		return tok.IsPunct("(") || tok.IsKeyword("SELECT", "WITH", "INSERT", "UPDATE", "DELETE", "REPLACE", "VALUES", "TABLE")
+	}
+	return false
+}
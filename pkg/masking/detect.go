@@ -0,0 +1,84 @@
+// pkg/masking/detect.go
+package masking
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Detector names usable in detect rules
+const (
+	DetectEmail = "email"
+	DetectPhone = "phone"
+	DetectCard  = "card"
+)
+
+var (
+	emailPattern = regexp.MustCompile(`^[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}$`)
+	phonePattern = regexp.MustCompile(`^\+?[0-9 ()\-]+$`)
+	datePattern  = regexp.MustCompile(`^[0-9]{4}-[0-9]{2}-[0-9]{2}$`)
+	cardPattern  = regexp.MustCompile(`^[0-9][0-9 \-]*[0-9]$`)
+)
+
+// detectors report whether a value looks like a given kind of sensitive data
+var detectors = map[string]func(string) bool{
+	DetectEmail: isEmail,
+	DetectPhone: isPhone,
+	DetectCard:  isCardNumber,
+}
+
+func isEmail(s string) bool {
+	return len(s) <= 254 && emailPattern.MatchString(s)
+}
+
+// isPhone accepts 7 to 15 digits with the usual separators. ISO dates have
+// the same shape and are excluded.
+func isPhone(s string) bool {
+	if !phonePattern.MatchString(s) || datePattern.MatchString(s) {
+		return false
+	}
+	n := countDigits(s)
+	return n >= 7 && n <= 15
+}
+
+// isCardNumber accepts 13 to 19 digits, optionally grouped with spaces or
+// dashes, that pass the Luhn checksum
+func isCardNumber(s string) bool {
+	if !cardPattern.MatchString(s) {
+		return false
+	}
+	digits := strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, s)
+	if len(digits) < 13 || len(digits) > 19 {
+		return false
+	}
+
+	sum := 0
+	double := false
+	for i := len(digits) - 1; i >= 0; i-- {
+		d := int(digits[i] - '0')
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return sum%10 == 0
+}
+
+func countDigits(s string) int {
+	n := 0
+	for i := 0; i < len(s); i++ {
+		if s[i] >= '0' && s[i] <= '9' {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,207 @@
+// pkg/masking/masking.go
+package masking
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+)
+
+// Kind selects what a rule matches
+type Kind string
+
+const (
+	KindColumn      Kind = "column"       // result column name, glob pattern such as *password*
+	KindTableColumn Kind = "table_column" // table.column, column part may be a glob
+	KindDetect      Kind = "detect"       // values that look like an email, phone or card number
+)
+
+// Action selects how a matched value is masked
+type Action string
+
+const (
+	ActionRedact Action = "redact"
+	ActionHash   Action = "hash"
+)
+
+// Redacted replaces values masked with ActionRedact
+const Redacted = "[REDACTED]"
+
+// Rule is a single masking rule
+type Rule struct {
+	Kind    Kind
+	Pattern string
+	Action  Action
+}
+
+// Validate checks that the rule can be applied
+func (r Rule) Validate() error {
+	if r.Action != ActionRedact && r.Action != ActionHash {
+		return fmt.Errorf("action must be 'redact' or 'hash'")
+	}
+	if r.Pattern == "" {
+		return fmt.Errorf("pattern is required")
+	}
+
+	switch r.Kind {
+	case KindColumn:
+		if _, err := path.Match(r.Pattern, ""); err != nil {
+			return fmt.Errorf("invalid column pattern %q", r.Pattern)
+		}
+	case KindTableColumn:
+		table, column, ok := strings.Cut(r.Pattern, ".")
+		if !ok || table == "" || column == "" {
+			return fmt.Errorf("pattern must be in table.column form")
+		}
+		if _, err := path.Match(column, ""); err != nil {
+			return fmt.Errorf("invalid column pattern %q", column)
+		}
+	case KindDetect:
+		if _, ok := detectors[r.Pattern]; !ok {
+			return fmt.Errorf("detector must be one of %s, %s or %s", DetectEmail, DetectPhone, DetectCard)
+		}
+	default:
+		return fmt.Errorf("kind must be 'column', 'table_column' or 'detect'")
+	}
+	return nil
+}
+
+// Masker applies a project's rules to query results
+type Masker struct {
+	rules []Rule
+	key   []byte
+}
+
+// New returns a masker for the given rules. key is used to hash values so
+// that equal values hash equally within a project without being reversible
+// by hashing guesses.
+func New(rules []Rule, key []byte) (*Masker, error) {
+	for _, rule := range rules {
+		if err := rule.Validate(); err != nil {
+			return nil, err
+		}
+	}
+	return &Masker{rules: rules, key: key}, nil
+}
+
+// Empty reports whether the masker has no rules
+func (m *Masker) Empty() bool {
+	return m == nil || len(m.rules) == 0
+}
+
+// maskRows masks the matching values of a result set in place and returns
+// the number of values masked. tables lists the tables the query reads,
+// used to resolve table_column rules against unqualified result columns.
+// traced holds actions for result columns derived from masked columns.
+func (m *Masker) maskRows(tables, columns []string, traced []Action, rows [][]any) int {
+	if m.Empty() {
+		return 0
+	}
+
+	// Column rules decide per column; detect rules decide per value
+	columnActions := make([]Action, len(columns))
+	copy(columnActions, traced)
+	var detect []Rule
+	for _, rule := range m.rules {
+		if rule.Kind == KindDetect {
+			detect = append(detect, rule)
+			continue
+		}
+		for i, column := range columns {
+			if columnActions[i] == "" && matchesColumn(rule, tables, column) {
+				columnActions[i] = rule.Action
+			}
+		}
+	}
+
+	masked := 0
+	for _, row := range rows {
+		for i, value := range row {
+			if value == nil {
+				continue
+			}
+
+			action := Action("")
+			if i < len(columnActions) {
+				action = columnActions[i]
+			}
+			if action == "" {
+				action = detectAction(detect, value)
+			}
+			if action == "" {
+				continue
+			}
+
+			row[i] = m.MaskValue(value, action)
+			masked++
+		}
+	}
+	return masked
+}
+
+// MaskValue masks a single value with the given action
+func (m *Masker) MaskValue(value any, action Action) any {
+	if action == ActionHash {
+		mac := hmac.New(sha256.New, m.key)
+		fmt.Fprint(mac, value)
+		return "hash:" + hex.EncodeToString(mac.Sum(nil)[:8])
+	}
+	return Redacted
+}
+
+func matchesColumn(rule Rule, tables []string, column string) bool {
+	column = strings.ToLower(column)
+	pattern := strings.ToLower(rule.Pattern)
+
+	if rule.Kind == KindColumn {
+		ok, _ := path.Match(pattern, column)
+		return ok
+	}
+
+	table, columnPattern, _ := strings.Cut(pattern, ".")
+	if ok, _ := path.Match(columnPattern, column); !ok {
+		return false
+	}
+	for _, t := range tables {
+		if strings.EqualFold(t, table) {
+			return true
+		}
+	}
+	return false
+}
+
+func detectAction(rules []Rule, value any) Action {
+	s, ok := valueText(value)
+	if !ok {
+		return ""
+	}
+	s = strings.TrimSpace(s)
+	for _, rule := range rules {
+		if detectors[rule.Pattern](s) {
+			return rule.Action
+		}
+	}
+	return ""
+}
+
+// valueText returns the text of scalar values so that numbers stored in
+// numeric columns are checked like strings. Floats are written without an
+// exponent, as JSON decoding turns every number into a float64.
+func valueText(value any) (string, bool) {
+	switch v := value.(type) {
+	case string:
+		return v, true
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), true
+	case float32:
+		return strconv.FormatFloat(float64(v), 'f', -1, 32), true
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, json.Number:
+		return fmt.Sprint(v), true
+	}
+	return "", false
+}
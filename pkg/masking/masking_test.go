@@ -0,0 +1,118 @@
+package masking
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/ephy-lab/ai-db-assistant/pkg/sqlparser"
+)
+
+func TestPlanMaskRows(t *testing.T) {
+	rules := []Rule{
+		{Kind: KindTableColumn, Pattern: "users.ssn", Action: ActionRedact},
+		{Kind: KindColumn, Pattern: "*password*", Action: ActionRedact},
+	}
+
+	tests := []struct {
+		name    string
+		query   string
+		columns []string
+		masked  []bool
+	}{
+		{"plain column", "SELECT id, ssn FROM users", []string{"id", "ssn"}, []bool{false, true}},
+		{"alias", "SELECT ssn AS x FROM users", []string{"x"}, []bool{true}},
+		{"expression", "SELECT upper(ssn) FROM users", []string{"upper"}, []bool{true}},
+		{"qualified expression", "SELECT u.id, concat(u.ssn, '') AS s FROM users u", []string{"id", "s"}, []bool{false, true}},
+		{"derived table", "SELECT * FROM (SELECT ssn FROM users) s", []string{"ssn"}, []bool{true}},
+		{"cte alias in outer list", "WITH c AS (SELECT ssn FROM users) SELECT id, ssn AS x FROM c", []string{"id", "x"}, []bool{false, true}},
+		{"scalar subquery", "SELECT id, (SELECT ssn FROM users LIMIT 1) AS x FROM orders", []string{"id", "x"}, []bool{false, true}},
+		{"after star", "SELECT *, lower(ssn) AS x FROM users", []string{"id", "name", "x"}, []bool{false, false, true}},
+		{"union", "SELECT name FROM users UNION SELECT ssn FROM users", []string{"name"}, []bool{true}},
+		{"other table", "SELECT ssn AS x FROM orders", []string{"x"}, []bool{false}},
+		{"where only", "SELECT id FROM users WHERE ssn = '1'", []string{"id"}, []bool{false}},
+		{"column rule", "SELECT password_hash AS h FROM accounts", []string{"h"}, []bool{true}},
+		{"returning", "DELETE FROM users WHERE id = 1 RETURNING id, upper(ssn)", []string{"id", "upper"}, []bool{false, true}},
+	}
+
+	masker, err := New(rules, []byte("key"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plan, err := masker.Plan(tt.query, sqlparser.DialectPostgres)
+			if err != nil {
+				t.Fatalf("Plan(%q) error: %v", tt.query, err)
+			}
+			row := make([]any, len(tt.columns))
+			for i := range row {
+				row[i] = "value"
+			}
+			plan.MaskRows(tt.columns, [][]any{row})
+			for i, want := range tt.masked {
+				if got := row[i] == Redacted; got != want {
+					t.Errorf("%s: column %s masked = %v, want %v", tt.query, tt.columns[i], got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestPlanUntraceable(t *testing.T) {
+	masker, err := New([]Rule{{Kind: KindColumn, Pattern: "ssn", Action: ActionHash}}, []byte("key"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		query string
+		fails bool
+	}{
+		{"derived alias", "SELECT * FROM (SELECT ssn AS x FROM users) s", true},
+		{"derived expression", "SELECT s.x FROM (SELECT upper(ssn) x FROM users) s", true},
+		{"cte alias", "WITH c AS (SELECT ssn AS x FROM users) SELECT * FROM c", true},
+		{"derived plain column", "SELECT * FROM (SELECT ssn FROM users) s", false},
+		{"derived where", "SELECT * FROM (SELECT id FROM users WHERE ssn = '1') s", false},
+		{"top level alias", "SELECT ssn AS x FROM users", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := masker.Plan(tt.query, sqlparser.DialectPostgres)
+			if got := errors.Is(err, ErrUntraceable); got != tt.fails {
+				t.Errorf("Plan(%q) error = %v, want untraceable %v", tt.query, err, tt.fails)
+			}
+		})
+	}
+}
+
+func TestDetectNonStringValues(t *testing.T) {
+	masker, err := New([]Rule{{Kind: KindDetect, Pattern: DetectCard, Action: ActionRedact}}, []byte("key"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		value  any
+		masked bool
+	}{
+		{"card string", "4111 1111 1111 1111", true},
+		{"card float", float64(4111111111111111), true},
+		{"card int", int64(4111111111111111), true},
+		{"small number", float64(42), false},
+		{"bool", true, false},
+	}
+
+	plan, _ := masker.Plan("SELECT n FROM t", sqlparser.DialectPostgres)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			row := []any{tt.value}
+			plan.MaskRows([]string{"n"}, [][]any{row})
+			if got := row[0] == Redacted; got != tt.masked {
+				t.Errorf("value %v masked = %v, want %v", tt.value, got, tt.masked)
+			}
+		})
+	}
+}
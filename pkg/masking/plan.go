@@ -0,0 +1,168 @@
+// pkg/masking/plan.go
+package masking
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/ephy-lab/ai-db-assistant/pkg/sqlparser"
+)
+
+// ErrUntraceable is returned for queries that read a masked column in a way
+// that cannot be followed to the result columns it produces
+var ErrUntraceable = errors.New("masked column cannot be traced to the result")
+
+// Plan applies a masker to the result of one statement. Besides matching
+// result column names, it masks result columns computed from masked columns,
+// e.g. under an alias or inside an expression.
+type Plan struct {
+	masker *Masker
+	tables []string
+	lists  []plannedList
+}
+
+// plannedList holds the actions of a select list's items that derive from
+// masked columns. Items after a * are aligned with the last result columns.
+type plannedList struct {
+	front []Action
+	back  []Action
+	star  bool
+}
+
+// Plan traces the statement's result columns back to the columns they read.
+// It returns an error wrapping ErrUntraceable when a masked column reaches
+// the result through a derived table or CTE under another name, as the
+// result column it ends up in cannot be identified.
+func (m *Masker) Plan(statement string, dialect sqlparser.Dialect) (*Plan, error) {
+	plan := &Plan{masker: m}
+	if m.Empty() {
+		return plan, nil
+	}
+
+	refs := sqlparser.ExtractReferences(statement, dialect)
+	for _, table := range refs.Tables {
+		plan.tables = append(plan.tables, table.Name)
+	}
+
+	// Masked columns the statement reads, by name
+	masked := map[string]Action{}
+	for _, column := range refs.Columns {
+		tables := plan.tables
+		if column.Table != "" {
+			tables = []string{column.Table}
+		}
+		name := strings.ToLower(column.Name)
+		if _, ok := masked[name]; ok {
+			continue
+		}
+		for _, rule := range m.rules {
+			if rule.Kind != KindDetect && matchesColumn(rule, tables, name) {
+				masked[name] = rule.Action
+				break
+			}
+		}
+	}
+	if len(masked) == 0 {
+		return plan, nil
+	}
+
+	projection := sqlparser.ExtractProjection(statement, dialect)
+	for _, item := range projection.Nested {
+		if name, ok := mentions(item, masked); ok && item.Column == "" && !item.Star {
+			return nil, fmt.Errorf("%w: %s is read under an alias or in an expression of a subquery", ErrUntraceable, name)
+		}
+	}
+
+	for _, items := range projection.Lists {
+		var list plannedList
+		stars := 0
+		for _, item := range items {
+			if item.Star {
+				stars++
+			}
+		}
+
+		seen := 0
+		for _, item := range items {
+			if item.Star {
+				seen++
+				list.star = true
+				continue
+			}
+			name, ok := mentions(item, masked)
+			action := Action("")
+			if ok {
+				action = masked[name]
+			}
+			switch {
+			case seen == 0:
+				list.front = append(list.front, action)
+			case seen == stars:
+				list.back = append(list.back, action)
+			case ok:
+				return nil, fmt.Errorf("%w: %s is read between two * columns", ErrUntraceable, name)
+			}
+		}
+		plan.lists = append(plan.lists, list)
+	}
+	return plan, nil
+}
+
+// MaskRows masks the matching values of a result set in place and returns
+// the number of values masked
+func (p *Plan) MaskRows(columns []string, rows [][]any) int {
+	if p.masker.Empty() {
+		return 0
+	}
+
+	traced := make([]Action, len(columns))
+	for _, list := range p.lists {
+		fits := len(list.front)+len(list.back) == len(columns) ||
+			(list.star && len(list.front)+len(list.back) <= len(columns))
+		for i, action := range list.front {
+			if action == "" {
+				continue
+			}
+			if !fits {
+				// The items do not line up with the result: mask it all
+				fillActions(traced, action)
+				break
+			}
+			setAction(traced, i, action)
+		}
+		for i, action := range list.back {
+			if action == "" {
+				continue
+			}
+			if !fits {
+				fillActions(traced, action)
+				break
+			}
+			setAction(traced, len(columns)-len(list.back)+i, action)
+		}
+	}
+	return p.masker.maskRows(p.tables, columns, traced, rows)
+}
+
+// mentions returns the first masked column an item reads
+func mentions(item sqlparser.SelectItem, masked map[string]Action) (string, bool) {
+	for _, name := range item.Names {
+		if _, ok := masked[name]; ok {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+func setAction(actions []Action, i int, action Action) {
+	if actions[i] == "" {
+		actions[i] = action
+	}
+}
+
+func fillActions(actions []Action, action Action) {
+	for i := range actions {
+		setAction(actions, i, action)
+	}
+}
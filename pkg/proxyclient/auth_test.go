@@ -0,0 +1,62 @@
+package proxyclient
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestVerifySignature(t *testing.T) {
+	secret := []byte("secret")
+	body := []byte(`{"query":"DELETE FROM orders"}`)
+	now := time.Unix(1700000000, 0)
+
+	signed := func() *http.Request {
+		req := httptest.NewRequest(http.MethodPost, "/execute-sql", strings.NewReader(string(body)))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set(RequestIDHeader, "request-1")
+		req.Header.Set(SessionIDHeader, "session-a")
+		sign(req, secret, body, now)
+		return req
+	}
+
+	tests := []struct {
+		name   string
+		tamper func(req *http.Request) []byte
+		at     time.Time
+		valid  bool
+	}{
+		{"untouched", func(req *http.Request) []byte { return body }, now, true},
+		{"within window", func(req *http.Request) []byte { return body }, now.Add(4 * time.Minute), true},
+		{"outside window", func(req *http.Request) []byte { return body }, now.Add(6 * time.Minute), false},
+		{"other session", func(req *http.Request) []byte {
+			req.Header.Set(SessionIDHeader, "session-b")
+			return body
+		}, now, false},
+		{"session removed", func(req *http.Request) []byte {
+			req.Header.Del(SessionIDHeader)
+			return body
+		}, now, false},
+		{"other content type", func(req *http.Request) []byte {
+			req.Header.Set("Content-Type", "text/plain")
+			return body
+		}, now, false},
+		{"other request id", func(req *http.Request) []byte {
+			req.Header.Set(RequestIDHeader, "request-2")
+			return body
+		}, now, false},
+		{"other body", func(req *http.Request) []byte { return []byte(`{"query":"SELECT 1"}`) }, now, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := signed()
+			err := VerifySignature(req, tt.tamper(req), secret, DefaultReplayWindow, tt.at)
+			if (err == nil) != tt.valid {
+				t.Errorf("VerifySignature() error = %v, want valid %v", err, tt.valid)
+			}
+		})
+	}
+}
@@ -0,0 +1,525 @@
+// pkg/proxyclient/balancer.go
+package proxyclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"math/rand"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultHealthPath is requested by the balancer's health checks
+const DefaultHealthPath = "/health"
+
+// DefaultSessionIdleTimeout is how long the balancer remembers the backend
+// of a session that is not used
+const DefaultSessionIdleTimeout = 24 * time.Hour
+
+// BackendConfig describes one proxy server
+type BackendConfig struct {
+	URL    string
+	Weight int
+}
+
+// ParseBackends parses a comma-separated list of proxy URLs, each optionally
+// followed by "=weight", e.g. "http://a:8000=3,http://b:8000". Weights
+// default to 1.
+func ParseBackends(spec string) ([]BackendConfig, error) {
+	var backends []BackendConfig
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		backend := BackendConfig{URL: entry, Weight: 1}
+		if i := strings.LastIndex(entry, "="); i >= 0 {
+			weight, err := strconv.Atoi(entry[i+1:])
+			if err != nil || weight <= 0 {
+				return nil, fmt.Errorf("invalid weight in proxy backend %q: must be a positive integer", entry)
+			}
+			backend.URL = strings.TrimSpace(entry[:i])
+			backend.Weight = weight
+		}
+		backend.URL = strings.TrimRight(backend.URL, "/")
+		backends = append(backends, backend)
+	}
+
+	if len(backends) == 0 {
+		return nil, errors.New("no proxy backends configured")
+	}
+	return backends, nil
+}
+
+// Balancer spreads requests over several proxy servers. SQL generation is
+// load-balanced by weight and fails over to another backend when one is
+// unavailable. Database sessions live on the proxy that opened them, so
+// every request of a session goes to the same backend: the one chosen when
+// the session connected or restored with RestoreSession, or, for sessions
+// it has not seen, the one ConnectDBContext would choose.
+type Balancer struct {
+	HealthPath string
+	// SessionIdleTimeout is how long an unused session stays pinned to its
+	// backend; health checks forget older pins
+	SessionIdleTimeout time.Duration
+
+	backends []*backend
+
+	mu       sync.Mutex
+	sessions map[string]*sessionPin
+	now      func() time.Time
+}
+
+// sessionPin is the backend a session connected through
+type sessionPin struct {
+	backend  *backend
+	lastUsed time.Time
+}
+
+type backend struct {
+	client *Client
+	weight int
+
+	// Guarded by Balancer.mu
+	healthy       bool
+	lastError     string
+	lastCheckedAt time.Time
+	capabilities  *Capabilities // nil until the first successful handshake
+}
+
+// BackendStatus describes a proxy backend
+type BackendStatus struct {
+	URL           string    `json:"url"`
+	Weight        int       `json:"weight"`
+	Healthy       bool      `json:"healthy"`
+	CircuitOpen   bool      `json:"circuit_open"`
+	Sessions      int       `json:"sessions"`
+	LastError     string    `json:"last_error,omitempty"`
+	LastCheckedAt time.Time `json:"last_checked_at"`
+
+	// Capabilities reported by the backend; nil before the first handshake
+	Capabilities *Capabilities `json:"capabilities"`
+}
+
+// StatusReporter is implemented by proxies that track their backends
+type StatusReporter interface {
+	Status() []BackendStatus
+}
+
+// SessionRouter is implemented by proxies that route each session to one
+// of several servers, so that callers can persist a session's server and
+// restore its routing after a restart
+type SessionRouter interface {
+	SessionBackend(sessionID string) string
+	RestoreSession(sessionID, backendURL string)
+}
+
+// SessionBackend returns the URL of the server p routes a connected session
+// to, or "" when p does not route sessions or the session is not connected
+func SessionBackend(p any, sessionID string) string {
+	if router, ok := p.(SessionRouter); ok {
+		return router.SessionBackend(sessionID)
+	}
+	return ""
+}
+
+// RestoreSession routes a session to the server recorded with
+// SessionBackend unless p already routes it somewhere
+func RestoreSession(p any, sessionID, backendURL string) {
+	if router, ok := p.(SessionRouter); ok && backendURL != "" {
+		router.RestoreSession(sessionID, backendURL)
+	}
+}
+
+var (
+	_ Proxy          = (*Balancer)(nil)
+	_ FeatureSet     = (*Balancer)(nil)
+	_ StatusReporter = (*Balancer)(nil)
+	_ SessionRouter  = (*Balancer)(nil)
+)
+
+// NewBalancer creates a balancer over the given backends, one client per
+// backend, each configured with opts. Backends start out healthy.
+func NewBalancer(configs []BackendConfig, opts Options) (*Balancer, error) {
+	if len(configs) == 0 {
+		return nil, errors.New("no proxy backends configured")
+	}
+
+	b := &Balancer{
+		HealthPath:         DefaultHealthPath,
+		SessionIdleTimeout: DefaultSessionIdleTimeout,
+		sessions:           make(map[string]*sessionPin),
+		now:                time.Now,
+	}
+	for _, config := range configs {
+		client, err := NewClientWithOptions(config.URL, opts)
+		if err != nil {
+			return nil, err
+		}
+		weight := config.Weight
+		if weight <= 0 {
+			weight = 1
+		}
+		b.backends = append(b.backends, &backend{client: client, weight: weight, healthy: true})
+	}
+	return b, nil
+}
+
+// GenerateSQLContext generates SQL on a backend chosen by weight, failing
+// over to the others while backends are unavailable
+func (b *Balancer) GenerateSQLContext(ctx context.Context, question, dbType, dbSchema string) (*GenerateSQLResponse, error) {
+	var err error
+	for _, backend := range b.pick() {
+		var resp *GenerateSQLResponse
+		resp, err = backend.client.GenerateSQLContext(ctx, question, dbType, dbSchema)
+		if err == nil || !failover(err) || ctx.Err() != nil {
+			return resp, err
+		}
+	}
+	return nil, err
+}
+
+// ConnectDBContext opens the session's connection on the backend it is
+// pinned to or hashes to, or on the next healthy one, and pins the session
+// there
+func (b *Balancer) ConnectDBContext(ctx context.Context, dbType, connectionString string) (*ConnectDBResponse, error) {
+	sessionID, _ := SessionID(ctx)
+
+	var err error
+	for _, backend := range b.rank(sessionID) {
+		var resp *ConnectDBResponse
+		resp, err = backend.client.ConnectDBContext(ctx, dbType, connectionString)
+		if err == nil {
+			b.pin(sessionID, backend)
+			return resp, nil
+		}
+		if !failover(err) || ctx.Err() != nil {
+			return nil, err
+		}
+	}
+	return nil, err
+}
+
+// DisconnectDBContext closes the session's connection on its backend
+func (b *Balancer) DisconnectDBContext(ctx context.Context) (*DisconnectDBResponse, error) {
+	sessionID, _ := SessionID(ctx)
+	resp, err := b.session(sessionID).client.DisconnectDBContext(ctx)
+	if err == nil {
+		b.unpin(sessionID)
+	}
+	return resp, err
+}
+
+// ExecuteSQLWithParamsContext runs a query on the session's backend
+func (b *Balancer) ExecuteSQLWithParamsContext(ctx context.Context, query string, params []QueryParam, dryRun bool) (*ExecuteSQLResponse, error) {
+	sessionID, _ := SessionID(ctx)
+	return b.session(sessionID).client.ExecuteSQLWithParamsContext(ctx, query, params, dryRun)
+}
+
+// ExecuteSQLStreamContext streams a query's rows from the session's backend
+func (b *Balancer) ExecuteSQLStreamContext(ctx context.Context, query string, params []QueryParam) (*RowIterator, error) {
+	sessionID, _ := SessionID(ctx)
+	return b.session(sessionID).client.ExecuteSQLStreamContext(ctx, query, params)
+}
+
+// ValidateSQLContext validates a query on the session's backend
+func (b *Balancer) ValidateSQLContext(ctx context.Context, query string) (*ValidateSQLResponse, error) {
+	sessionID, _ := SessionID(ctx)
+	return b.session(sessionID).client.ValidateSQLContext(ctx, query)
+}
+
+// GetDBInfoContext describes the session's connection on its backend
+func (b *Balancer) GetDBInfoContext(ctx context.Context) (*DBInfoResponse, error) {
+	sessionID, _ := SessionID(ctx)
+	return b.session(sessionID).client.GetDBInfoContext(ctx)
+}
+
+// Status returns the state of every backend, in configuration order
+func (b *Balancer) Status() []BackendStatus {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	sessions := make(map[*backend]int)
+	for _, pin := range b.sessions {
+		sessions[pin.backend]++
+	}
+
+	status := make([]BackendStatus, 0, len(b.backends))
+	for _, backend := range b.backends {
+		status = append(status, BackendStatus{
+			URL:           backend.client.BaseURL,
+			Weight:        backend.weight,
+			Healthy:       backend.healthy,
+			CircuitOpen:   backend.client.Breaker != nil && backend.client.Breaker.Open(),
+			Sessions:      sessions[backend],
+			LastError:     backend.lastError,
+			LastCheckedAt: backend.lastCheckedAt,
+			Capabilities:  backend.capabilities,
+		})
+	}
+	return status
+}
+
+// Supports reports whether every available backend that completed the
+// handshake offers a feature, so that the feature works whichever backend a
+// request goes to. Before any handshake succeeds every feature is assumed.
+func (b *Balancer) Supports(feature string) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for _, backend := range b.backends {
+		if backend.capabilities != nil && b.available(backend) && !backend.capabilities.Supports(feature) {
+			return false
+		}
+	}
+	return true
+}
+
+// Run health-checks every backend each interval until ctx is done. Call
+// CheckHealth first to negotiate capabilities at startup.
+func (b *Balancer) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			b.CheckHealth(ctx)
+		}
+	}
+}
+
+// CheckHealth requests HealthPath from every backend and repeats the
+// capabilities handshake with those that answer. A backend is healthy when
+// it answers without a server error and speaks this client's API version.
+// Sessions idle for longer than SessionIdleTimeout are forgotten.
+func (b *Balancer) CheckHealth(ctx context.Context) {
+	b.forgetIdleSessions()
+
+	var wg sync.WaitGroup
+	for _, backend := range b.backends {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			b.check(ctx, backend)
+		}()
+	}
+	wg.Wait()
+}
+
+func (b *Balancer) check(ctx context.Context, backend *backend) {
+	checkCtx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+	defer cancel()
+
+	err := backend.client.ping(checkCtx, b.HealthPath)
+	var caps *Capabilities
+	if err == nil {
+		// Like ping, the handshake bypasses the breaker: going through it,
+		// an open breaker would fail the check and keep itself open
+		caps, err = backend.client.capabilities(checkCtx, backend.client.send)
+	}
+	if ctx.Err() != nil {
+		return
+	}
+
+	// Health checks are the breaker's other source of failures besides
+	// requests that cannot reach the backend, and close it on recovery
+	if breaker := backend.client.Breaker; breaker != nil {
+		if err == nil {
+			breaker.Success()
+		} else {
+			breaker.Failure()
+		}
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	backend.lastCheckedAt = b.now()
+	backend.healthy = err == nil
+	backend.lastError = ""
+	if err != nil {
+		backend.lastError = err.Error()
+	}
+	if caps != nil {
+		backend.capabilities = caps
+	}
+}
+
+// healthCheckTimeout bounds a single backend's health check
+const healthCheckTimeout = 5 * time.Second
+
+// ping requests path without retries or the circuit breaker. Error
+// responses below 500 still show that the proxy is up.
+func (c *Client) ping(ctx context.Context, path string) error {
+	resp, _, err := c.open(ctx, c.HTTPClient, http.MethodGet, path, nil)
+	if err != nil {
+		var proxyErr *ProxyError
+		if errors.As(err, &proxyErr) && proxyErr.StatusCode < http.StatusInternalServerError {
+			return nil
+		}
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// available reports whether a backend should receive new work
+func (b *Balancer) available(backend *backend) bool {
+	return backend.healthy && (backend.client.Breaker == nil || !backend.client.Breaker.Open())
+}
+
+// pick orders the backends for a stateless request: healthy backends in a
+// random order weighted by their weights, then the unhealthy ones as a last
+// resort in case their health is out of date
+func (b *Balancer) pick() []*backend {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	// Weighted random permutation: sort by u^(1/weight) descending
+	keys := make(map[*backend]float64, len(b.backends))
+	for _, backend := range b.backends {
+		keys[backend] = math.Pow(rand.Float64(), 1/float64(backend.weight))
+	}
+	return b.order(keys)
+}
+
+// rank orders the backends for a session by weighted rendezvous hashing of
+// the session ID, unhealthy backends last, so a session maps to the same
+// backend while the set of healthy backends is unchanged. A backend the
+// session is already pinned to comes first while it is available.
+func (b *Balancer) rank(sessionID string) []*backend {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var pinned *backend
+	if pin, ok := b.sessions[sessionID]; ok {
+		pinned = pin.backend
+	}
+	keys := make(map[*backend]float64, len(b.backends))
+	for _, backend := range b.backends {
+		keys[backend] = rendezvousScore(sessionID, backend.client.BaseURL, backend.weight)
+		if backend == pinned {
+			keys[backend] = math.Inf(1)
+		}
+	}
+	return b.order(keys)
+}
+
+// order sorts the backends by key descending, available backends first.
+// b.mu must be held.
+func (b *Balancer) order(keys map[*backend]float64) []*backend {
+	ordered := make([]*backend, len(b.backends))
+	copy(ordered, b.backends)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		ai, aj := b.available(ordered[i]), b.available(ordered[j])
+		if ai != aj {
+			return ai
+		}
+		return keys[ordered[i]] > keys[ordered[j]]
+	})
+	return ordered
+}
+
+// session returns the backend a session is pinned to, or the one
+// ConnectDBContext would connect it on when the balancer has not seen it
+// connect, e.g. after a restart
+func (b *Balancer) session(sessionID string) *backend {
+	b.mu.Lock()
+	pin, ok := b.sessions[sessionID]
+	if ok {
+		pin.lastUsed = b.now()
+	}
+	b.mu.Unlock()
+	if ok {
+		return pin.backend
+	}
+	return b.rank(sessionID)[0]
+}
+
+func (b *Balancer) pin(sessionID string, backend *backend) {
+	if sessionID == "" {
+		return
+	}
+	b.mu.Lock()
+	b.sessions[sessionID] = &sessionPin{backend: backend, lastUsed: b.now()}
+	b.mu.Unlock()
+}
+
+func (b *Balancer) unpin(sessionID string) {
+	b.mu.Lock()
+	delete(b.sessions, sessionID)
+	b.mu.Unlock()
+}
+
+// SessionBackend returns the URL of the backend a session is pinned to
+func (b *Balancer) SessionBackend(sessionID string) string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if pin, ok := b.sessions[sessionID]; ok {
+		return pin.backend.client.BaseURL
+	}
+	return ""
+}
+
+// RestoreSession pins a session the balancer has not seen to the backend
+// with the given URL, e.g. the one recorded when it connected before a
+// restart. Unknown URLs are ignored.
+func (b *Balancer) RestoreSession(sessionID, backendURL string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if _, ok := b.sessions[sessionID]; ok || sessionID == "" {
+		return
+	}
+	for _, backend := range b.backends {
+		if backend.client.BaseURL == backendURL {
+			b.sessions[sessionID] = &sessionPin{backend: backend, lastUsed: b.now()}
+			return
+		}
+	}
+}
+
+// forgetIdleSessions drops the pins of sessions idle for longer than
+// SessionIdleTimeout. A forgotten session that is used again is restored
+// by its caller or reconnected when its backend no longer knows it.
+func (b *Balancer) forgetIdleSessions() {
+	if b.SessionIdleTimeout <= 0 {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for sessionID, pin := range b.sessions {
+		if b.now().Sub(pin.lastUsed) > b.SessionIdleTimeout {
+			delete(b.sessions, sessionID)
+		}
+	}
+}
+
+// rendezvousScore is the weighted rendezvous hashing score of a session on
+// a backend: -weight / ln(h) for h the hash mapped into (0, 1)
+func rendezvousScore(sessionID, url string, weight int) float64 {
+	hash := fnv.New64a()
+	hash.Write([]byte(sessionID))
+	hash.Write([]byte{0})
+	hash.Write([]byte(url))
+	h := (float64(hash.Sum64()>>11) + 0.5) / (1 << 53)
+	return -float64(weight) / math.Log(h)
+}
+
+// failover reports whether a request may succeed on another backend
+func failover(err error) bool {
+	return errors.Is(err, ErrProxyUnavailable) || errors.Is(err, ErrProxyTimeout)
+}
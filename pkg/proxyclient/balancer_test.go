@@ -0,0 +1,162 @@
+package proxyclient
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// newTestBalancer starts backends that answer every request with their
+// own index and returns a balancer over them
+func newTestBalancer(t *testing.T, count int) (*Balancer, []string) {
+	var configs []BackendConfig
+	var urls []string
+	for i := 0; i < count; i++ {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			json.NewEncoder(w).Encode(ExecuteSQLResponse{Success: true, Message: r.Host})
+		}))
+		t.Cleanup(server.Close)
+		configs = append(configs, BackendConfig{URL: server.URL, Weight: 1})
+		urls = append(urls, server.URL)
+	}
+
+	b, err := NewBalancer(configs, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b, urls
+}
+
+// servedBy returns the URL of the backend that ran a query of the session
+func servedBy(t *testing.T, b *Balancer, sessionID string) string {
+	resp, err := b.ExecuteSQLWithParamsContext(WithSessionID(context.Background(), sessionID), "SELECT 1", nil, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return "http://" + resp.Message
+}
+
+func TestBalancerSessionRouting(t *testing.T) {
+	tests := []struct {
+		name string
+		run  func(t *testing.T, b *Balancer, urls []string)
+	}{
+		{"connect pins the session", func(t *testing.T, b *Balancer, urls []string) {
+			ctx := WithSessionID(context.Background(), "s1")
+			if _, err := b.ConnectDBContext(ctx, "postgresql", "dsn"); err != nil {
+				t.Fatal(err)
+			}
+			backend := b.SessionBackend("s1")
+			if backend == "" {
+				t.Fatal("session not pinned after connecting")
+			}
+			if got := servedBy(t, b, "s1"); got != backend {
+				t.Errorf("served by %s, want %s", got, backend)
+			}
+		}},
+		{"restore routes to the recorded backend", func(t *testing.T, b *Balancer, urls []string) {
+			for _, url := range urls {
+				sessionID := "restored-" + url
+				b.RestoreSession(sessionID, url)
+				if got := servedBy(t, b, sessionID); got != url {
+					t.Errorf("served by %s, want %s", got, url)
+				}
+			}
+		}},
+		{"restore keeps an existing pin", func(t *testing.T, b *Balancer, urls []string) {
+			b.RestoreSession("s2", urls[0])
+			b.RestoreSession("s2", urls[1])
+			if got := b.SessionBackend("s2"); got != urls[0] {
+				t.Errorf("pinned to %s, want %s", got, urls[0])
+			}
+		}},
+		{"unpinned sessions go where connect would", func(t *testing.T, b *Balancer, urls []string) {
+			for _, sessionID := range []string{"a", "b", "c", "d", "e", "f"} {
+				want := b.rank(sessionID)[0].client.BaseURL
+				if got := servedBy(t, b, sessionID); got != want {
+					t.Errorf("session %s served by %s, want %s", sessionID, got, want)
+				}
+			}
+		}},
+		{"unhealthy preferred backend is skipped", func(t *testing.T, b *Balancer, urls []string) {
+			for _, sessionID := range []string{"a", "b", "c", "d", "e", "f"} {
+				preferred := b.rank(sessionID)[0]
+				preferred.healthy = false
+				if got := servedBy(t, b, sessionID); got == preferred.client.BaseURL {
+					t.Errorf("session %s served by unhealthy backend %s", sessionID, got)
+				}
+				preferred.healthy = true
+			}
+		}},
+		{"idle sessions are forgotten", func(t *testing.T, b *Balancer, urls []string) {
+			now := time.Now()
+			b.now = func() time.Time { return now }
+			b.RestoreSession("idle", urls[0])
+			b.RestoreSession("busy", urls[0])
+
+			now = now.Add(b.SessionIdleTimeout / 2)
+			servedBy(t, b, "busy")
+			now = now.Add(b.SessionIdleTimeout/2 + time.Second)
+			b.forgetIdleSessions()
+
+			if got := b.SessionBackend("idle"); got != "" {
+				t.Errorf("idle session still pinned to %s", got)
+			}
+			if got := b.SessionBackend("busy"); got != urls[0] {
+				t.Errorf("busy session pinned to %q, want %s", got, urls[0])
+			}
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, urls := newTestBalancer(t, 3)
+			tt.run(t, b, urls)
+		})
+	}
+}
+
+func TestBalancerHealthCheckClosesBreaker(t *testing.T) {
+	var down atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if down.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		if r.URL.Path == "/capabilities" {
+			json.NewEncoder(w).Encode(Capabilities{APIVersion: APIVersion})
+			return
+		}
+		json.NewEncoder(w).Encode(ExecuteSQLResponse{Success: true})
+	}))
+	defer server.Close()
+
+	b, err := NewBalancer([]BackendConfig{{URL: server.URL, Weight: 1}}, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	backend := b.backends[0]
+	// The open timeout outlasts the checks, so only they can close it
+	backend.client.Breaker = NewCircuitBreaker(2, time.Hour)
+
+	down.Store(true)
+	for i := 0; i < 3; i++ {
+		b.CheckHealth(context.Background())
+	}
+	if !backend.client.Breaker.Open() || b.available(backend) {
+		t.Fatal("breaker still closed after failed health checks")
+	}
+
+	down.Store(false)
+	b.CheckHealth(context.Background())
+	if backend.client.Breaker.Open() || !b.available(backend) {
+		t.Fatalf("backend unavailable after recovering: %s", backend.lastError)
+	}
+	if _, err := b.ExecuteSQLWithParamsContext(WithSessionID(context.Background(), "s1"), "SELECT 1", nil, false); err != nil {
+		t.Errorf("request after recovery failed: %v", err)
+	}
+}
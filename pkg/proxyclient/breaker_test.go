@@ -0,0 +1,53 @@
+package proxyclient
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestBreakerCountsOnlyConnectionFailures(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		closed  bool // server is not listening
+		opens   bool
+	}{
+		{"service unavailable", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}, false, false},
+		{"bad gateway", func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusBadGateway)
+		}, false, false},
+		{"slow query", func(w http.ResponseWriter, r *http.Request) {
+			time.Sleep(50 * time.Millisecond)
+		}, false, false},
+		{"connection refused", nil, true, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(tt.handler)
+			if tt.closed {
+				server.Close()
+			} else {
+				defer server.Close()
+			}
+
+			client := NewClient(server.URL)
+			client.HTTPClient.Timeout = 10 * time.Millisecond
+			client.Breaker = NewCircuitBreaker(2, time.Minute)
+
+			for i := 0; i < 3; i++ {
+				if _, err := client.ExecuteSQLWithParamsContext(context.Background(), "SELECT 1", nil, false); err == nil {
+					t.Fatal("expected the request to fail")
+				}
+			}
+			if got := client.Breaker.Open(); got != tt.opens {
+				t.Errorf("breaker open = %v, want %v", got, tt.opens)
+			}
+		})
+	}
+}
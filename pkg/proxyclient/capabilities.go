@@ -0,0 +1,83 @@
+// pkg/proxyclient/capabilities.go
+package proxyclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+)
+
+// APIVersion is the version of the proxy API this client speaks. Proxies
+// reporting another version are not sent any requests.
+const APIVersion = 1
+
+// Optional proxy features, gated by the handlers
+const (
+	FeatureDryRun    = "dry_run"   // /execute-sql with dry_run
+	FeatureExplain   = "explain"   // /validate-sql
+	FeatureStreaming = "streaming" // /execute-sql/stream
+)
+
+// legacyFeatures are assumed for proxies that predate /capabilities
+var legacyFeatures = []string{FeatureDryRun, FeatureExplain}
+
+// Capabilities is the proxy's answer to the handshake
+type Capabilities struct {
+	APIVersion int      `json:"api_version"`
+	Version    string   `json:"version"`
+	Features   []string `json:"features"`
+
+	// Legacy is set when the proxy has no /capabilities endpoint
+	Legacy bool `json:"legacy,omitempty"`
+}
+
+// Supports reports whether the proxy offers a feature
+func (c *Capabilities) Supports(feature string) bool {
+	return slices.Contains(c.Features, feature)
+}
+
+// ErrIncompatibleProxy means the proxy speaks another API version
+var ErrIncompatibleProxy = errors.New("incompatible proxy API version")
+
+// FeatureSet is implemented by proxies and executors whose optional
+// features are known
+type FeatureSet interface {
+	Supports(feature string) bool
+}
+
+// Supports reports whether p offers a feature. Anything that does not
+// implement FeatureSet, such as the native executor, supports every feature.
+func Supports(p any, feature string) bool {
+	features, ok := p.(FeatureSet)
+	return !ok || features.Supports(feature)
+}
+
+// CapabilitiesContext performs the handshake with the /capabilities
+// endpoint. A proxy without the endpoint is reported as Legacy with the
+// features every proxy has; one speaking another API version returns
+// ErrIncompatibleProxy along with what it reported.
+func (c *Client) CapabilitiesContext(ctx context.Context) (*Capabilities, error) {
+	return c.capabilities(ctx, c.attempt)
+}
+
+// capabilities performs the handshake through get, which is attempt for
+// callers and send for health checks that must bypass the circuit breaker
+func (c *Client) capabilities(ctx context.Context, get func(context.Context, string, string, []byte, interface{}) error) (*Capabilities, error) {
+	var caps Capabilities
+	err := get(ctx, http.MethodGet, "/capabilities", nil, &caps)
+
+	var proxyErr *ProxyError
+	if errors.As(err, &proxyErr) && proxyErr.StatusCode == http.StatusNotFound {
+		return &Capabilities{APIVersion: APIVersion, Features: legacyFeatures, Legacy: true}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get proxy capabilities: %w", err)
+	}
+
+	if caps.APIVersion != APIVersion {
+		return &caps, fmt.Errorf("%w: proxy speaks version %d, expected %d", ErrIncompatibleProxy, caps.APIVersion, APIVersion)
+	}
+	return &caps, nil
+}
@@ -3,17 +3,56 @@ package proxyclient
 
 import (
 	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
 	"time"
 )
 
+// RequestIDHeader identifies a request so that it can be cancelled
+const RequestIDHeader = "X-Request-ID"
+
+// SessionIDHeader selects the proxy session, and with it the database
+// connection, a request operates on
+const SessionIDHeader = "X-Session-ID"
+
+type sessionIDKey struct{}
+
+// WithSessionID returns a context whose proxy requests target the given session
+func WithSessionID(ctx context.Context, sessionID string) context.Context {
+	return context.WithValue(ctx, sessionIDKey{}, sessionID)
+}
+
+// SessionID returns the proxy session set on ctx, if any
+func SessionID(ctx context.Context) (string, bool) {
+	sessionID, ok := ctx.Value(sessionIDKey{}).(string)
+	return sessionID, ok && sessionID != ""
+}
+
+// NewSessionID returns a random session identifier
+func NewSessionID() string {
+	return newRequestID()
+}
+
+// cancelTimeout bounds the cancel request sent after a context is cancelled
+const cancelTimeout = 5 * time.Second
+
 // Client handles communication with the AI SQL Assistant proxy server
 type Client struct {
 	BaseURL    string
 	HTTPClient *http.Client
+	Retry      RetryPolicy     // applied to requests without side effects
+	Breaker    *CircuitBreaker // nil disables the circuit breaker
+
+	// SigningSecret, when set, signs every request with HMAC-SHA256 so the
+	// proxy can reject requests that did not come from this server
+	SigningSecret []byte
 }
 
 // NewClient creates a new proxy client
@@ -23,6 +62,8 @@ func NewClient(baseURL string) *Client {
 		HTTPClient: &http.Client{
 			Timeout: 30 * time.Second,
 		},
+		Retry:   DefaultRetryPolicy,
+		Breaker: NewCircuitBreaker(5, 30*time.Second),
 	}
 }
 
@@ -68,10 +109,18 @@ type DisconnectDBResponse struct {
 	PreviousConnection ConnectionInfo `json:"previous_connection"`
 }
 
+// QueryParam is a typed value bound to a placeholder. Params are sent in
+// placeholder order and are never interpolated into the query text.
+type QueryParam struct {
+	Type  string `json:"type"`
+	Value any    `json:"value"`
+}
+
 // ExecuteSQLRequest represents the request to execute SQL
 type ExecuteSQLRequest struct {
-	Query  string `json:"query"`
-	DryRun bool   `json:"dry_run,omitempty"`
+	Query  string       `json:"query"`
+	Params []QueryParam `json:"params,omitempty"`
+	DryRun bool         `json:"dry_run,omitempty"`
 }
 
 // ExecuteSQLResponse represents the response from SQL execution
@@ -109,6 +158,17 @@ type DBInfoResponse struct {
 	Connected bool   `json:"connected"`
 }
 
+// CancelRequest represents the request to cancel an in-flight request
+type CancelRequest struct {
+	RequestID string `json:"request_id"`
+}
+
+// CancelResponse represents the response from request cancellation
+type CancelResponse struct {
+	Success bool   `json:"success"`
+	Message string `json:"message"`
+}
+
 // ErrorResponse represents an error response from the proxy
 type ErrorResponse struct {
 	Detail string `json:"detail"`
@@ -116,6 +176,11 @@ type ErrorResponse struct {
 
 // GenerateSQL calls the /generate-sql endpoint
 func (c *Client) GenerateSQL(question, dbType, dbSchema string) (*GenerateSQLResponse, error) {
+	return c.GenerateSQLContext(context.Background(), question, dbType, dbSchema)
+}
+
+// GenerateSQLContext calls the /generate-sql endpoint, cancelling it when ctx is done
+func (c *Client) GenerateSQLContext(ctx context.Context, question, dbType, dbSchema string) (*GenerateSQLResponse, error) {
 	req := GenerateSQLRequest{
 		Question: question,
 		DBType:   dbType,
@@ -123,7 +188,7 @@ func (c *Client) GenerateSQL(question, dbType, dbSchema string) (*GenerateSQLRes
 	}
 
 	var resp GenerateSQLResponse
-	if err := c.post("/generate-sql", req, &resp); err != nil {
+	if err := c.post(ctx, "/generate-sql", req, &resp); err != nil {
 		return nil, err
 	}
 
@@ -132,13 +197,18 @@ func (c *Client) GenerateSQL(question, dbType, dbSchema string) (*GenerateSQLRes
 
 // ConnectDB calls the /connect-db endpoint
 func (c *Client) ConnectDB(dbType, connectionString string) (*ConnectDBResponse, error) {
+	return c.ConnectDBContext(context.Background(), dbType, connectionString)
+}
+
+// ConnectDBContext calls the /connect-db endpoint, cancelling it when ctx is done
+func (c *Client) ConnectDBContext(ctx context.Context, dbType, connectionString string) (*ConnectDBResponse, error) {
 	req := ConnectDBRequest{
 		DBType:           dbType,
 		ConnectionString: connectionString,
 	}
 
 	var resp ConnectDBResponse
-	if err := c.post("/connect-db", req, &resp); err != nil {
+	if err := c.post(ctx, "/connect-db", req, &resp); err != nil {
 		return nil, err
 	}
 
@@ -147,8 +217,13 @@ func (c *Client) ConnectDB(dbType, connectionString string) (*ConnectDBResponse,
 
 // DisconnectDB calls the /disconnect-db endpoint
 func (c *Client) DisconnectDB() (*DisconnectDBResponse, error) {
+	return c.DisconnectDBContext(context.Background())
+}
+
+// DisconnectDBContext calls the /disconnect-db endpoint, cancelling it when ctx is done
+func (c *Client) DisconnectDBContext(ctx context.Context) (*DisconnectDBResponse, error) {
 	var resp DisconnectDBResponse
-	if err := c.post("/disconnect-db", nil, &resp); err != nil {
+	if err := c.post(ctx, "/disconnect-db", nil, &resp); err != nil {
 		return nil, err
 	}
 
@@ -157,13 +232,30 @@ func (c *Client) DisconnectDB() (*DisconnectDBResponse, error) {
 
 // ExecuteSQL calls the /execute-sql endpoint
 func (c *Client) ExecuteSQL(query string, dryRun bool) (*ExecuteSQLResponse, error) {
+	return c.ExecuteSQLWithParamsContext(context.Background(), query, nil, dryRun)
+}
+
+// ExecuteSQLContext calls the /execute-sql endpoint, cancelling the query when ctx is done
+func (c *Client) ExecuteSQLContext(ctx context.Context, query string, dryRun bool) (*ExecuteSQLResponse, error) {
+	return c.ExecuteSQLWithParamsContext(ctx, query, nil, dryRun)
+}
+
+// ExecuteSQLWithParams calls the /execute-sql endpoint with placeholder values
+func (c *Client) ExecuteSQLWithParams(query string, params []QueryParam, dryRun bool) (*ExecuteSQLResponse, error) {
+	return c.ExecuteSQLWithParamsContext(context.Background(), query, params, dryRun)
+}
+
+// ExecuteSQLWithParamsContext calls the /execute-sql endpoint with
+// placeholder values, cancelling the query when ctx is done
+func (c *Client) ExecuteSQLWithParamsContext(ctx context.Context, query string, params []QueryParam, dryRun bool) (*ExecuteSQLResponse, error) {
 	req := ExecuteSQLRequest{
 		Query:  query,
+		Params: params,
 		DryRun: dryRun,
 	}
 
 	var resp ExecuteSQLResponse
-	if err := c.post("/execute-sql", req, &resp); err != nil {
+	if err := c.post(ctx, "/execute-sql", req, &resp); err != nil {
 		return nil, err
 	}
 
@@ -172,12 +264,17 @@ func (c *Client) ExecuteSQL(query string, dryRun bool) (*ExecuteSQLResponse, err
 
 // ValidateSQL calls the /validate-sql endpoint
 func (c *Client) ValidateSQL(query string) (*ValidateSQLResponse, error) {
+	return c.ValidateSQLContext(context.Background(), query)
+}
+
+// ValidateSQLContext calls the /validate-sql endpoint, cancelling it when ctx is done
+func (c *Client) ValidateSQLContext(ctx context.Context, query string) (*ValidateSQLResponse, error) {
 	req := ValidateSQLRequest{
 		Query: query,
 	}
 
 	var resp ValidateSQLResponse
-	if err := c.post("/validate-sql", req, &resp); err != nil {
+	if err := c.post(ctx, "/validate-sql", req, &resp); err != nil {
 		return nil, err
 	}
 
@@ -186,70 +283,192 @@ func (c *Client) ValidateSQL(query string) (*ValidateSQLResponse, error) {
 
 // GetDBInfo calls the /db-info endpoint
 func (c *Client) GetDBInfo() (*DBInfoResponse, error) {
-	url := c.BaseURL + "/db-info"
+	return c.GetDBInfoContext(context.Background())
+}
 
-	resp, err := c.HTTPClient.Get(url)
-	if err != nil {
+// GetDBInfoContext calls the /db-info endpoint, cancelling it when ctx is done
+func (c *Client) GetDBInfoContext(ctx context.Context) (*DBInfoResponse, error) {
+	var dbInfo DBInfoResponse
+	if err := c.do(ctx, http.MethodGet, "/db-info", nil, &dbInfo); err != nil {
 		return nil, fmt.Errorf("failed to get database info: %w", err)
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, c.parseError(resp)
-	}
+	return &dbInfo, nil
+}
 
-	var dbInfo DBInfoResponse
-	if err := json.NewDecoder(resp.Body).Decode(&dbInfo); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
+// Cancel calls the /cancel endpoint to stop the request with the given ID.
+// Requests the proxy no longer tracks are ignored.
+func (c *Client) Cancel(ctx context.Context, requestID string) (*CancelResponse, error) {
+	var resp CancelResponse
+	if err := c.post(ctx, "/cancel", CancelRequest{RequestID: requestID}, &resp); err != nil {
+		return nil, err
 	}
 
-	return &dbInfo, nil
+	return &resp, nil
 }
 
 // post is a helper method to make POST requests
-func (c *Client) post(endpoint string, request interface{}, response interface{}) error {
-	url := c.BaseURL + endpoint
+func (c *Client) post(ctx context.Context, endpoint string, request interface{}, response interface{}) error {
+	return c.do(ctx, http.MethodPost, endpoint, request, response)
+}
 
-	var body io.Reader
+// do sends a request, retrying transient failures of safe endpoints with
+// jittered backoff. Failures are classified as ErrProxyUnavailable,
+// ErrProxyTimeout or ErrQueryFailed.
+func (c *Client) do(ctx context.Context, method, endpoint string, request interface{}, response interface{}) error {
+	var payload []byte
 	if request != nil {
 		jsonData, err := json.Marshal(request)
 		if err != nil {
 			return fmt.Errorf("failed to marshal request: %w", err)
 		}
-		body = bytes.NewBuffer(jsonData)
+		payload = jsonData
+	}
+
+	attempts := 1
+	if safeEndpoints[endpoint] && c.Retry.MaxAttempts > 1 {
+		attempts = c.Retry.MaxAttempts
+	}
+
+	var err error
+	for attempt := 0; attempt < attempts; attempt++ {
+		if attempt > 0 {
+			if sleepErr := sleep(ctx, c.Retry.backoff(attempt)); sleepErr != nil {
+				return fmt.Errorf("request cancelled: %w", sleepErr)
+			}
+		}
+
+		err = c.attempt(ctx, method, endpoint, payload, response)
+		if err == nil || !transient(err) || ctx.Err() != nil {
+			return err
+		}
+	}
+	return err
+}
+
+// attempt sends a single request tagged with a new request ID and the
+// session set on ctx with WithSessionID, subject to the circuit breaker.
+// Only failures to reach the proxy count against the breaker.
+// When ctx is cancelled before the proxy responds, the proxy is asked to
+// cancel the request so a long-running query does not keep running after
+// the caller has gone.
+func (c *Client) attempt(ctx context.Context, method, endpoint string, payload []byte, response interface{}) error {
+	if c.Breaker != nil && !c.Breaker.Allow() {
+		return ErrCircuitOpen
+	}
+
+	err := c.send(ctx, method, endpoint, payload, response)
+
+	if c.Breaker != nil {
+		switch {
+		case err != nil && ctx.Err() != nil:
+			c.Breaker.Release()
+		case err != nil && connectionFailure(err):
+			c.Breaker.Failure()
+		default:
+			c.Breaker.Success()
+		}
 	}
+	return err
+}
 
-	req, err := http.NewRequest("POST", url, body)
+func (c *Client) send(ctx context.Context, method, endpoint string, payload []byte, response interface{}) error {
+	resp, _, err := c.open(ctx, c.HTTPClient, method, endpoint, payload)
 	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
+		return err
 	}
+	defer resp.Body.Close()
 
-	if request != nil {
+	if err := json.NewDecoder(resp.Body).Decode(response); err != nil {
+		return readError(ctx, err, "failed to decode response")
+	}
+
+	return nil
+}
+
+// open sends a request and returns the response once its status is known.
+// Non-200 responses are returned as errors. The caller must close the body.
+func (c *Client) open(ctx context.Context, httpClient *http.Client, method, endpoint string, payload []byte) (*http.Response, string, error) {
+	url := c.BaseURL + endpoint
+
+	var body io.Reader
+	if payload != nil {
+		body = bytes.NewReader(payload)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to create request: %w", err)
+	}
+
+	if payload != nil {
 		req.Header.Set("Content-Type", "application/json")
 	}
 
-	resp, err := c.HTTPClient.Do(req)
+	requestID := newRequestID()
+	req.Header.Set(RequestIDHeader, requestID)
+	if sessionID, ok := SessionID(ctx); ok {
+		req.Header.Set(SessionIDHeader, sessionID)
+	}
+	if len(c.SigningSecret) > 0 {
+		sign(req, c.SigningSecret, payload, time.Now())
+	}
+
+	resp, err := httpClient.Do(req)
 	if err != nil {
-		return fmt.Errorf("failed to send request: %w", err)
+		if ctx.Err() != nil {
+			if endpoint != "/cancel" {
+				c.cancelInFlight(ctx, requestID)
+			}
+			return nil, "", fmt.Errorf("request cancelled: %w", ctx.Err())
+		}
+		if isTimeout(err) {
+			return nil, "", fmt.Errorf("%w: %w", ErrProxyTimeout, err)
+		}
+		return nil, "", fmt.Errorf("%w: %w", ErrProxyUnavailable, err)
 	}
-	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return c.parseError(resp)
+		defer resp.Body.Close()
+		return nil, "", c.parseError(resp)
 	}
 
-	if err := json.NewDecoder(resp.Body).Decode(response); err != nil {
-		return fmt.Errorf("failed to decode response: %w", err)
+	return resp, requestID, nil
+}
+
+// readError classifies an error reading a response body
+func readError(ctx context.Context, err error, message string) error {
+	if ctx.Err() != nil {
+		return fmt.Errorf("request cancelled: %w", ctx.Err())
 	}
+	if isTimeout(err) {
+		return fmt.Errorf("%w: %v", ErrProxyTimeout, err)
+	}
+	return fmt.Errorf("%s: %w", message, err)
+}
 
-	return nil
+func isTimeout(err error) bool {
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// cancelInFlight asks the proxy to stop a request whose caller has gone.
+// It is best effort: the proxy may have finished or never received it.
+func (c *Client) cancelInFlight(ctx context.Context, requestID string) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), cancelTimeout)
+	defer cancel()
+	c.Cancel(ctx, requestID)
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
 }
 
 // parseError parses error responses from the proxy
 func (c *Client) parseError(resp *http.Response) error {
 	var errResp ErrorResponse
-	if err := json.NewDecoder(resp.Body).Decode(&errResp); err != nil {
-		return fmt.Errorf("proxy request failed with status %d", resp.StatusCode)
-	}
-	return fmt.Errorf("proxy error (%d): %s", resp.StatusCode, errResp.Detail)
+	json.NewDecoder(resp.Body).Decode(&errResp)
+	return &ProxyError{StatusCode: resp.StatusCode, Detail: errResp.Detail}
 }
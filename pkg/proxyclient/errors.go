@@ -0,0 +1,102 @@
+// pkg/proxyclient/errors.go
+package proxyclient
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+)
+
+var (
+	// ErrProxyUnavailable means the proxy could not be reached or reported
+	// that it is unavailable
+	ErrProxyUnavailable = errors.New("proxy unavailable")
+
+	// ErrProxyTimeout means the proxy did not respond in time
+	ErrProxyTimeout = errors.New("proxy timed out")
+
+	// ErrQueryFailed means the proxy handled the request but it failed, e.g.
+	// invalid SQL or a database error
+	ErrQueryFailed = errors.New("proxy request failed")
+
+	// ErrSessionNotConnected means the proxy has no database connection for
+	// the request's session, e.g. because the proxy restarted or the request
+	// reached a proxy server other than the one the session connected through
+	ErrSessionNotConnected = fmt.Errorf("%w: session not connected", ErrQueryFailed)
+
+	// ErrCircuitOpen is returned without contacting the proxy while the
+	// circuit breaker is open
+	ErrCircuitOpen = fmt.Errorf("%w: circuit breaker open", ErrProxyUnavailable)
+)
+
+// ProxyError is an error response returned by the proxy
+type ProxyError struct {
+	StatusCode int
+	Detail     string
+}
+
+func (e *ProxyError) Error() string {
+	if e.Detail == "" {
+		return fmt.Sprintf("proxy request failed with status %d", e.StatusCode)
+	}
+	return fmt.Sprintf("proxy error (%d): %s", e.StatusCode, e.Detail)
+}
+
+// Unwrap classifies the response so callers can use errors.Is with the
+// sentinel errors
+func (e *ProxyError) Unwrap() error {
+	switch e.StatusCode {
+	case http.StatusBadGateway, http.StatusServiceUnavailable:
+		return ErrProxyUnavailable
+	case http.StatusGatewayTimeout:
+		return ErrProxyTimeout
+	case http.StatusConflict:
+		return ErrSessionNotConnected
+	case http.StatusBadRequest, http.StatusNotFound:
+		if sessionLost(e.Detail) {
+			return ErrSessionNotConnected
+		}
+	}
+	return ErrQueryFailed
+}
+
+// sessionLostMessages are the messages the proxy answers with when a
+// session has no database connection
+var sessionLostMessages = []string{"not connected", "no database connection", "no active connection", "unknown session", "session not found"}
+
+func sessionLost(detail string) bool {
+	detail = strings.ToLower(detail)
+	for _, message := range sessionLostMessages {
+		if strings.Contains(detail, message) {
+			return true
+		}
+	}
+	return false
+}
+
+// transient reports whether err may succeed when retried
+func transient(err error) bool {
+	if errors.Is(err, ErrCircuitOpen) {
+		return false
+	}
+	return errors.Is(err, ErrProxyUnavailable) || errors.Is(err, ErrProxyTimeout)
+}
+
+// connectionFailure reports whether err means the proxy could not be
+// reached. Requests that reached it count as successes for the circuit
+// breaker even when they failed or timed out, so that slow or failing
+// queries of one user do not cut every user off from the proxy.
+func connectionFailure(err error) bool {
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "dial" {
+		return true
+	}
+
+	var proxyErr *ProxyError
+	if errors.As(err, &proxyErr) || errors.Is(err, ErrCircuitOpen) || isTimeout(err) {
+		return false
+	}
+	return errors.Is(err, ErrProxyUnavailable)
+}
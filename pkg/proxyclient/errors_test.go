@@ -0,0 +1,35 @@
+package proxyclient
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestProxyErrorClassification(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    *ProxyError
+		target error
+	}{
+		{"unavailable", &ProxyError{StatusCode: http.StatusServiceUnavailable}, ErrProxyUnavailable},
+		{"timeout", &ProxyError{StatusCode: http.StatusGatewayTimeout}, ErrProxyTimeout},
+		{"conflict", &ProxyError{StatusCode: http.StatusConflict}, ErrSessionNotConnected},
+		{"no connection message", &ProxyError{StatusCode: http.StatusBadRequest, Detail: "No database connection. Please connect first."}, ErrSessionNotConnected},
+		{"unknown session message", &ProxyError{StatusCode: http.StatusNotFound, Detail: "Unknown session 9f1c"}, ErrSessionNotConnected},
+		{"query error", &ProxyError{StatusCode: http.StatusBadRequest, Detail: "syntax error at or near \"FORM\""}, ErrQueryFailed},
+		{"session lost is a query failure", &ProxyError{StatusCode: http.StatusConflict}, ErrQueryFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if !errors.Is(tt.err, tt.target) {
+				t.Errorf("errors.Is(%v, %v) = false", tt.err, tt.target)
+			}
+		})
+	}
+
+	if errors.Is(&ProxyError{StatusCode: http.StatusBadRequest, Detail: "syntax error"}, ErrSessionNotConnected) {
+		t.Error("query errors must not be reported as lost sessions")
+	}
+}
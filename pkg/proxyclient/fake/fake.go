@@ -0,0 +1,266 @@
+// pkg/proxyclient/fake/fake.go
+package fake
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"github.com/ephy-lab/ai-db-assistant/pkg/proxyclient"
+)
+
+// Method names recorded in Call.Method
+const (
+	MethodGenerateSQL  = "GenerateSQL"
+	MethodConnectDB    = "ConnectDB"
+	MethodDisconnectDB = "DisconnectDB"
+	MethodExecuteSQL   = "ExecuteSQL"
+	MethodStreamSQL    = "StreamSQL"
+	MethodValidateSQL  = "ValidateSQL"
+	MethodGetDBInfo    = "GetDBInfo"
+)
+
+// Call is one recorded proxy call. Only the fields that apply to the
+// method are set.
+type Call struct {
+	Method           string
+	SessionID        string
+	Question         string
+	DBType           string
+	DBSchema         string
+	ConnectionString string
+	Query            string
+	Params           []proxyclient.QueryParam
+	DryRun           bool
+}
+
+// Proxy is an in-memory proxyclient.Proxy. It records every call and
+// answers with the results scripted for it, or with an empty success.
+// Execution results are matched on the query text with surrounding
+// whitespace and a trailing semicolon ignored. It is safe for concurrent use.
+type Proxy struct {
+	mu       sync.Mutex
+	calls    []Call
+	generate map[string]result[proxyclient.GenerateSQLResponse]
+	execute  map[string]result[proxyclient.ExecuteSQLResponse]
+	validate map[string]result[proxyclient.ValidateSQLResponse]
+	connect  *result[proxyclient.ConnectDBResponse]
+	dbInfo   *result[proxyclient.DBInfoResponse]
+	disabled map[string]bool
+}
+
+type result[T any] struct {
+	resp *T
+	err  error
+}
+
+var (
+	_ proxyclient.Proxy      = (*Proxy)(nil)
+	_ proxyclient.FeatureSet = (*Proxy)(nil)
+)
+
+// New returns a fake proxy with no scripted results
+func New() *Proxy {
+	return &Proxy{
+		generate: make(map[string]result[proxyclient.GenerateSQLResponse]),
+		execute:  make(map[string]result[proxyclient.ExecuteSQLResponse]),
+		validate: make(map[string]result[proxyclient.ValidateSQLResponse]),
+		disabled: make(map[string]bool),
+	}
+}
+
+// WithoutFeatures makes the fake report the given proxyclient features as
+// unsupported, like a proxy that lacks them
+func (p *Proxy) WithoutFeatures(features ...string) *Proxy {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, feature := range features {
+		p.disabled[feature] = true
+	}
+	return p
+}
+
+// Supports reports whether a feature has not been disabled
+func (p *Proxy) Supports(feature string) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return !p.disabled[feature]
+}
+
+// OnGenerateSQL scripts the response to a question
+func (p *Proxy) OnGenerateSQL(question string, resp *proxyclient.GenerateSQLResponse, err error) *Proxy {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.generate[question] = result[proxyclient.GenerateSQLResponse]{resp, err}
+	return p
+}
+
+// OnConnectDB scripts the response to every connect
+func (p *Proxy) OnConnectDB(resp *proxyclient.ConnectDBResponse, err error) *Proxy {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.connect = &result[proxyclient.ConnectDBResponse]{resp, err}
+	return p
+}
+
+// OnExecuteSQL scripts the result of a query, streamed or not. Dry runs
+// of the query get the same result.
+func (p *Proxy) OnExecuteSQL(query string, resp *proxyclient.ExecuteSQLResponse, err error) *Proxy {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.execute[normalize(query)] = result[proxyclient.ExecuteSQLResponse]{resp, err}
+	return p
+}
+
+// OnValidateSQL scripts the response to validating a query
+func (p *Proxy) OnValidateSQL(query string, resp *proxyclient.ValidateSQLResponse, err error) *Proxy {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.validate[normalize(query)] = result[proxyclient.ValidateSQLResponse]{resp, err}
+	return p
+}
+
+// OnGetDBInfo scripts the database info response
+func (p *Proxy) OnGetDBInfo(resp *proxyclient.DBInfoResponse, err error) *Proxy {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.dbInfo = &result[proxyclient.DBInfoResponse]{resp, err}
+	return p
+}
+
+// Calls returns the calls made so far, in order
+func (p *Proxy) Calls() []Call {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]Call(nil), p.calls...)
+}
+
+// CallsTo returns the calls made to one method, in order
+func (p *Proxy) CallsTo(method string) []Call {
+	var calls []Call
+	for _, call := range p.Calls() {
+		if call.Method == method {
+			calls = append(calls, call)
+		}
+	}
+	return calls
+}
+
+// Reset forgets the recorded calls but keeps the scripted results
+func (p *Proxy) Reset() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.calls = nil
+}
+
+// GenerateSQLContext records the call and returns the response scripted for the question
+func (p *Proxy) GenerateSQLContext(ctx context.Context, question, dbType, dbSchema string) (*proxyclient.GenerateSQLResponse, error) {
+	p.record(ctx, Call{Method: MethodGenerateSQL, Question: question, DBType: dbType, DBSchema: dbSchema})
+
+	p.mu.Lock()
+	r, ok := p.generate[question]
+	p.mu.Unlock()
+	if ok {
+		return r.resp, r.err
+	}
+	return &proxyclient.GenerateSQLResponse{}, nil
+}
+
+// ConnectDBContext records the call and returns the scripted connect response
+func (p *Proxy) ConnectDBContext(ctx context.Context, dbType, connectionString string) (*proxyclient.ConnectDBResponse, error) {
+	p.record(ctx, Call{Method: MethodConnectDB, DBType: dbType, ConnectionString: connectionString})
+
+	p.mu.Lock()
+	r := p.connect
+	p.mu.Unlock()
+	if r != nil {
+		return r.resp, r.err
+	}
+	return &proxyclient.ConnectDBResponse{Success: true, Message: "Connected"}, nil
+}
+
+// DisconnectDBContext records the call and always succeeds
+func (p *Proxy) DisconnectDBContext(ctx context.Context) (*proxyclient.DisconnectDBResponse, error) {
+	p.record(ctx, Call{Method: MethodDisconnectDB})
+	return &proxyclient.DisconnectDBResponse{Success: true, Message: "Disconnected"}, nil
+}
+
+// ExecuteSQLWithParamsContext records the call and returns the result scripted for the query
+func (p *Proxy) ExecuteSQLWithParamsContext(ctx context.Context, query string, params []proxyclient.QueryParam, dryRun bool) (*proxyclient.ExecuteSQLResponse, error) {
+	p.record(ctx, Call{Method: MethodExecuteSQL, Query: query, Params: params, DryRun: dryRun})
+
+	resp, err := p.executeResult(query)
+	if resp != nil {
+		resp.DryRun = dryRun
+	}
+	return resp, err
+}
+
+// ExecuteSQLStreamContext records the call and streams the result scripted for the query
+func (p *Proxy) ExecuteSQLStreamContext(ctx context.Context, query string, params []proxyclient.QueryParam) (*proxyclient.RowIterator, error) {
+	p.record(ctx, Call{Method: MethodStreamSQL, Query: query, Params: params})
+
+	resp, err := p.executeResult(query)
+	if err != nil {
+		return nil, err
+	}
+	return proxyclient.NewRowIterator(resp), nil
+}
+
+// ValidateSQLContext records the call and returns the response scripted for the query
+func (p *Proxy) ValidateSQLContext(ctx context.Context, query string) (*proxyclient.ValidateSQLResponse, error) {
+	p.record(ctx, Call{Method: MethodValidateSQL, Query: query})
+
+	p.mu.Lock()
+	r, ok := p.validate[normalize(query)]
+	p.mu.Unlock()
+	if ok {
+		return r.resp, r.err
+	}
+	return &proxyclient.ValidateSQLResponse{Success: true, DryRun: true}, nil
+}
+
+// GetDBInfoContext records the call and returns the scripted database info
+func (p *Proxy) GetDBInfoContext(ctx context.Context) (*proxyclient.DBInfoResponse, error) {
+	p.record(ctx, Call{Method: MethodGetDBInfo})
+
+	p.mu.Lock()
+	r := p.dbInfo
+	p.mu.Unlock()
+	if r != nil {
+		return r.resp, r.err
+	}
+	return &proxyclient.DBInfoResponse{Connected: true}, nil
+}
+
+func (p *Proxy) executeResult(query string) (*proxyclient.ExecuteSQLResponse, error) {
+	p.mu.Lock()
+	r, ok := p.execute[normalize(query)]
+	p.mu.Unlock()
+	if !ok {
+		return &proxyclient.ExecuteSQLResponse{Success: true, Message: "Query executed successfully"}, nil
+	}
+	if r.resp == nil {
+		return nil, r.err
+	}
+
+	// Callers may mask rows in place; keep the scripted result intact
+	resp := *r.resp
+	resp.Rows = make([][]any, len(r.resp.Rows))
+	for i, row := range r.resp.Rows {
+		resp.Rows[i] = append([]any(nil), row...)
+	}
+	return &resp, r.err
+}
+
+func (p *Proxy) record(ctx context.Context, call Call) {
+	call.SessionID, _ = proxyclient.SessionID(ctx)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.calls = append(p.calls, call)
+}
+
+func normalize(query string) string {
+	return strings.TrimSuffix(strings.TrimSpace(query), ";")
+}
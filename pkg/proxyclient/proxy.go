@@ -0,0 +1,20 @@
+// pkg/proxyclient/proxy.go
+package proxyclient
+
+import (
+	"context"
+)
+
+// Proxy is the set of proxy operations the handlers use. *Client implements
+// it against the proxy server; package fake provides an in-memory version.
+type Proxy interface {
+	GenerateSQLContext(ctx context.Context, question, dbType, dbSchema string) (*GenerateSQLResponse, error)
+	ConnectDBContext(ctx context.Context, dbType, connectionString string) (*ConnectDBResponse, error)
+	DisconnectDBContext(ctx context.Context) (*DisconnectDBResponse, error)
+	ExecuteSQLWithParamsContext(ctx context.Context, query string, params []QueryParam, dryRun bool) (*ExecuteSQLResponse, error)
+	ExecuteSQLStreamContext(ctx context.Context, query string, params []QueryParam) (*RowIterator, error)
+	ValidateSQLContext(ctx context.Context, query string) (*ValidateSQLResponse, error)
+	GetDBInfoContext(ctx context.Context) (*DBInfoResponse, error)
+}
+
+var _ Proxy = (*Client)(nil)
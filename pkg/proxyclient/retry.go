@@ -0,0 +1,57 @@
+// pkg/proxyclient/retry.go
+package proxyclient
+
+import (
+	"context"
+	"math/rand"
+	"time"
+)
+
+// RetryPolicy controls how safe requests are retried after transient
+// failures
+type RetryPolicy struct {
+	MaxAttempts int           // total attempts including the first; 1 disables retries
+	BaseDelay   time.Duration // delay cap before the first retry
+	MaxDelay    time.Duration // upper bound for any delay
+}
+
+// DefaultRetryPolicy makes up to three attempts over roughly a second
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 3,
+	BaseDelay:   200 * time.Millisecond,
+	MaxDelay:    2 * time.Second,
+}
+
+// safeEndpoints have no side effects on the proxy or the database and can be
+// retried without risk of running anything twice
+var safeEndpoints = map[string]bool{
+	"/generate-sql": true,
+	"/validate-sql": true,
+	"/db-info":      true,
+}
+
+// backoff returns the delay before the given retry (1 for the first retry)
+// using exponential backoff with full jitter
+func (p RetryPolicy) backoff(retry int) time.Duration {
+	limit := p.BaseDelay << (retry - 1)
+	if limit <= 0 || limit > p.MaxDelay {
+		limit = p.MaxDelay
+	}
+	if limit <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(limit) + 1))
+}
+
+// sleep waits for d or until ctx is done
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,245 @@
+// pkg/proxyclient/stream.go
+package proxyclient
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// maxStreamLine bounds a single NDJSON line from the proxy
+const maxStreamLine = 16 << 20
+
+// Stream message types sent by the proxy, one JSON object per line
+const (
+	StreamHeader = "header"
+	StreamRow    = "row"
+	StreamEnd    = "end"
+	StreamError  = "error"
+)
+
+// StreamMessage is one line of a streamed result. The proxy sends a header
+// with the columns, one row message per row and then an end message with
+// the totals, or an error message if the query fails part way through.
+type StreamMessage struct {
+	Type         string   `json:"type"`
+	QueryType    string   `json:"query_type,omitempty"`
+	Columns      []string `json:"columns,omitempty"`
+	Row          []any    `json:"row,omitempty"`
+	RowCount     int      `json:"row_count,omitempty"`
+	AffectedRows int      `json:"affected_rows,omitempty"`
+	Message      string   `json:"message,omitempty"`
+	Detail       string   `json:"detail,omitempty"`
+}
+
+// RowIterator reads the rows of a streamed result. Callers must call Close
+// when done; closing before the end of the stream stops the query.
+type RowIterator struct {
+	header  StreamMessage
+	next    func() (StreamMessage, error)
+	release func(aborted bool)
+
+	row     []any
+	summary *ExecuteSQLResponse
+	err     error
+	done    bool
+}
+
+// NewRowIteratorFunc returns an iterator over the messages returned by
+// next, which must be row messages followed by an end or error message.
+// release is called once when the iterator finishes, with aborted set if
+// it was closed before the end of the stream.
+func NewRowIteratorFunc(queryType string, columns []string, next func() (StreamMessage, error), release func(aborted bool)) *RowIterator {
+	return &RowIterator{
+		header:  StreamMessage{Type: StreamHeader, QueryType: queryType, Columns: columns},
+		next:    next,
+		release: release,
+	}
+}
+
+// NewRowIterator returns an iterator over a buffered result, for callers
+// that need to present a non-streamed result as a stream
+func NewRowIterator(resp *ExecuteSQLResponse) *RowIterator {
+	rows := resp.Rows
+	next := func() (StreamMessage, error) {
+		if len(rows) == 0 {
+			return StreamMessage{Type: StreamEnd, RowCount: resp.RowCount, AffectedRows: resp.AffectedRows, Message: resp.Message}, nil
+		}
+		row := rows[0]
+		rows = rows[1:]
+		return StreamMessage{Type: StreamRow, Row: row}, nil
+	}
+	return NewRowIteratorFunc(resp.QueryType, resp.Columns, next, func(bool) {})
+}
+
+// ExecuteSQLStreamContext executes a query and returns an iterator over its
+// rows as the proxy produces them, instead of buffering the whole result.
+// Streams are not retried. The response header has been read when it
+// returns, so Columns and QueryType are available straight away.
+func (c *Client) ExecuteSQLStreamContext(ctx context.Context, query string, params []QueryParam) (*RowIterator, error) {
+	payload, err := json.Marshal(ExecuteSQLRequest{Query: query, Params: params})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	if c.Breaker != nil && !c.Breaker.Allow() {
+		return nil, ErrCircuitOpen
+	}
+
+	// A stream lasts as long as the caller reads it, so the client's overall
+	// timeout does not apply; ctx bounds it instead.
+	httpClient := &http.Client{Transport: c.HTTPClient.Transport}
+
+	ctx, cancel := context.WithCancel(ctx)
+	resp, requestID, err := c.open(ctx, httpClient, http.MethodPost, "/execute-sql/stream", payload)
+	if c.Breaker != nil {
+		switch {
+		case err != nil && ctx.Err() != nil:
+			c.Breaker.Release()
+		case err != nil && connectionFailure(err):
+			c.Breaker.Failure()
+		default:
+			c.Breaker.Success()
+		}
+	}
+	if err != nil {
+		cancel()
+		return nil, fmt.Errorf("failed to execute SQL: %w", err)
+	}
+
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 64*1024), maxStreamLine)
+
+	read := func() (StreamMessage, error) {
+		var msg StreamMessage
+		for scanner.Scan() {
+			line := scanner.Bytes()
+			if len(line) == 0 {
+				continue
+			}
+			if err := json.Unmarshal(line, &msg); err != nil {
+				return msg, fmt.Errorf("failed to decode stream: %w", err)
+			}
+			return msg, nil
+		}
+
+		err := scanner.Err()
+		if err == nil {
+			err = io.ErrUnexpectedEOF
+		}
+		return msg, readError(ctx, err, "failed to read stream")
+	}
+	release := func(aborted bool) {
+		cancel()
+		resp.Body.Close()
+		if aborted {
+			c.cancelInFlight(ctx, requestID)
+		}
+	}
+
+	msg, err := read()
+	if err != nil {
+		release(true)
+		return nil, fmt.Errorf("failed to execute SQL: %w", err)
+	}
+	if msg.Type != StreamHeader {
+		release(false)
+		it := &RowIterator{}
+		if err := it.handle(msg); err != nil {
+			return nil, fmt.Errorf("failed to execute SQL: %w", err)
+		}
+		return nil, fmt.Errorf("failed to execute SQL: %w: stream did not start with a header", ErrQueryFailed)
+	}
+
+	return NewRowIteratorFunc(msg.QueryType, msg.Columns, read, release), nil
+}
+
+// Columns returns the result's column names
+func (it *RowIterator) Columns() []string {
+	return it.header.Columns
+}
+
+// QueryType returns the type of the executed statement
+func (it *RowIterator) QueryType() string {
+	return it.header.QueryType
+}
+
+// Next advances to the next row. It returns false at the end of the
+// stream or on error; check Err to tell them apart.
+func (it *RowIterator) Next() bool {
+	if it.done {
+		return false
+	}
+
+	it.row = nil
+	msg, err := it.next()
+	if err != nil {
+		it.err = err
+		it.Close()
+		return false
+	}
+	if msg.Type == StreamRow {
+		it.row = msg.Row
+		return true
+	}
+
+	it.err = it.handle(msg)
+	it.finish(false)
+	return false
+}
+
+// Row returns the current row
+func (it *RowIterator) Row() []any {
+	return it.row
+}
+
+// Err returns the error that ended the stream, if any
+func (it *RowIterator) Err() error {
+	return it.err
+}
+
+// Summary returns the totals sent at the end of the stream, or nil if the
+// stream has not ended successfully. Rows is always empty.
+func (it *RowIterator) Summary() *ExecuteSQLResponse {
+	return it.summary
+}
+
+// Close releases the stream. If it has not been read to the end, the
+// query is cancelled.
+func (it *RowIterator) Close() error {
+	if !it.done {
+		it.finish(true)
+	}
+	return nil
+}
+
+func (it *RowIterator) finish(aborted bool) {
+	it.done = true
+	it.release(aborted)
+}
+
+// handle processes a message that ends the stream
+func (it *RowIterator) handle(msg StreamMessage) error {
+	switch msg.Type {
+	case StreamEnd:
+		it.summary = &ExecuteSQLResponse{
+			Success:      true,
+			QueryType:    it.header.QueryType,
+			Columns:      it.header.Columns,
+			RowCount:     msg.RowCount,
+			AffectedRows: msg.AffectedRows,
+			Message:      msg.Message,
+		}
+		return nil
+	case StreamError:
+		detail := msg.Detail
+		if detail == "" {
+			detail = msg.Message
+		}
+		return fmt.Errorf("%w: %s", ErrQueryFailed, detail)
+	}
+	return fmt.Errorf("%w: unexpected stream message %q", ErrQueryFailed, msg.Type)
+}
@@ -2,7 +2,10 @@
 package querygen
 
 import (
+	"encoding/json"
+
 	"github.com/ephy-lab/ai-db-assistant/pkg/chathelpers"
+	"github.com/ephy-lab/ai-db-assistant/pkg/sqlparams"
 )
 
 
@@ -10,6 +13,8 @@ import (
 type AIResponse struct {
 	Content string  `json:"content"`
 	Query   *string `json:"query,omitempty"`
+	// Params holds values for placeholders in Query, to be sent with it on execution
+	Params map[string]sqlparams.Param `json:"params,omitempty"`
 }
 
 // GenerateResponse generates an AI response with optional SQL query based on user message
@@ -67,19 +72,23 @@ func handleSelectQueries(msg, dbType string) AIResponse {
 	// List columns
 	if chathelpers.Contains(msg, "column", "columns", "field", "fields") {
 		var query string
+		var params map[string]sqlparams.Param
 		tableName := extractTableName(msg)
 		if tableName == "" {
 			tableName = "your_table_name"
 		}
 		
 		if dbType == "postgresql" {
-			query = "SELECT column_name, data_type FROM information_schema.columns WHERE table_name = '" + tableName + "';"
+			query = "SELECT column_name, data_type FROM information_schema.columns WHERE table_name = :table_name;"
+			value, _ := json.Marshal(tableName)
+			params = map[string]sqlparams.Param{"table_name": {Type: sqlparams.TypeString, Value: value}}
 		} else {
 			query = "DESCRIBE " + tableName + ";"
 		}
 		return AIResponse{
 			Content: "Here's a query to show the columns:",
 			Query:   &query,
+			Params:  params,
 		}
 	}
 
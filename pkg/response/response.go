@@ -38,4 +38,13 @@ func Error(w http.ResponseWriter, statusCode int, message string) {
 		Success: false,
 		Error:   message,
 	})
-}
\ No newline at end of file
+}
+func ErrorWithData(w http.ResponseWriter, statusCode int, message string, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	json.NewEncoder(w).Encode(Response{
+		Success: false,
+		Data:    data,
+		Error:   message,
+	})
+}
@@ -0,0 +1,306 @@
+// pkg/rolegen/rolegen.go
+package rolegen
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Flags mirrors a project's permission flags
+type Flags struct {
+	DDL    bool
+	Write  bool
+	Read   bool
+	Delete bool
+}
+
+// Plan is the set of statements that create a least-privilege role
+type Plan struct {
+	Role       string   `json:"role"`
+	Database   string   `json:"database"`
+	Statements []string `json:"statements"`
+	Notes      []string `json:"notes,omitempty"`
+}
+
+// Report compares the privileges held by the connected user with a project's flags
+type Report struct {
+	Privileges []string `json:"privileges"`
+	Excess     []string `json:"excess,omitempty"`
+	Compliant  bool     `json:"compliant"`
+}
+
+var roleNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]{0,62}$`)
+
+// ValidateRoleName checks that a role name is a plain identifier
+func ValidateRoleName(role string) error {
+	if !roleNamePattern.MatchString(role) {
+		return fmt.Errorf("role name must start with a letter or underscore and contain only letters, digits and underscores (max 63 characters)")
+	}
+	return nil
+}
+
+// Generate builds the CREATE ROLE and GRANT statements that mirror the given flags
+func Generate(dbType, role, database string, flags Flags) (*Plan, error) {
+	if err := ValidateRoleName(role); err != nil {
+		return nil, err
+	}
+	if database == "" {
+		return nil, fmt.Errorf("database name is required")
+	}
+
+	switch dbType {
+	case "postgresql":
+		return generatePostgres(role, database, flags), nil
+	case "mysql":
+		return generateMySQL(role, database, flags), nil
+	default:
+		return nil, fmt.Errorf("unsupported database type: %s", dbType)
+	}
+}
+
+func generatePostgres(role, database string, flags Flags) *Plan {
+	r := quotePostgres(role)
+	plan := &Plan{
+		Role:     role,
+		Database: database,
+		Statements: []string{
+			fmt.Sprintf("CREATE ROLE %s NOLOGIN;", r),
+			fmt.Sprintf("GRANT CONNECT ON DATABASE %s TO %s;", quotePostgres(database), r),
+			fmt.Sprintf("GRANT USAGE ON SCHEMA public TO %s;", r),
+		},
+	}
+
+	var tablePrivileges []string
+	if flags.Read {
+		tablePrivileges = append(tablePrivileges, "SELECT")
+	}
+	if flags.Write {
+		tablePrivileges = append(tablePrivileges, "INSERT", "UPDATE")
+	}
+	if flags.Delete {
+		tablePrivileges = append(tablePrivileges, "DELETE")
+	}
+	if flags.DDL {
+		tablePrivileges = append(tablePrivileges, "TRUNCATE", "REFERENCES", "TRIGGER")
+	}
+
+	if len(tablePrivileges) > 0 {
+		privs := strings.Join(tablePrivileges, ", ")
+		plan.Statements = append(plan.Statements,
+			fmt.Sprintf("GRANT %s ON ALL TABLES IN SCHEMA public TO %s;", privs, r),
+			fmt.Sprintf("ALTER DEFAULT PRIVILEGES IN SCHEMA public GRANT %s ON TABLES TO %s;", privs, r),
+		)
+	}
+
+	if flags.Write {
+		// Inserts into serial columns need access to the backing sequences
+		plan.Statements = append(plan.Statements,
+			fmt.Sprintf("GRANT USAGE, SELECT ON ALL SEQUENCES IN SCHEMA public TO %s;", r),
+			fmt.Sprintf("ALTER DEFAULT PRIVILEGES IN SCHEMA public GRANT USAGE, SELECT ON SEQUENCES TO %s;", r),
+		)
+	}
+
+	if flags.DDL {
+		plan.Statements = append(plan.Statements, fmt.Sprintf("GRANT CREATE ON SCHEMA public TO %s;", r))
+		plan.Notes = append(plan.Notes, "PostgreSQL only allows ALTER and DROP on a table by its owner; transfer ownership of existing tables to the role if it must modify them.")
+	}
+
+	plan.Notes = append(plan.Notes, fmt.Sprintf("The role is created with NOLOGIN; grant it to a login user with: GRANT %s TO <login_user>;", r))
+	return plan
+}
+
+func generateMySQL(role, database string, flags Flags) *Plan {
+	r := quoteMySQLAccount(role)
+	plan := &Plan{
+		Role:     role,
+		Database: database,
+		Statements: []string{
+			fmt.Sprintf("CREATE ROLE IF NOT EXISTS %s;", r),
+		},
+	}
+
+	var privileges []string
+	if flags.Read {
+		privileges = append(privileges, "SELECT", "SHOW VIEW")
+	}
+	if flags.Write {
+		privileges = append(privileges, "INSERT", "UPDATE")
+	}
+	if flags.Delete {
+		privileges = append(privileges, "DELETE")
+	}
+	if flags.DDL {
+		privileges = append(privileges, "CREATE", "ALTER", "DROP", "INDEX", "REFERENCES", "CREATE VIEW", "TRIGGER")
+	}
+
+	if len(privileges) > 0 {
+		plan.Statements = append(plan.Statements,
+			fmt.Sprintf("GRANT %s ON %s.* TO %s;", strings.Join(privileges, ", "), quoteMySQL(database), r))
+	}
+
+	plan.Notes = append(plan.Notes,
+		"Roles require MySQL 8.0 or later.",
+		fmt.Sprintf("Grant the role to a login user and activate it with: GRANT %s TO <user>; SET DEFAULT ROLE %s TO <user>;", r, r),
+	)
+	return plan
+}
+
+// GrantsQuery returns a query listing the privileges held by the connected user
+func GrantsQuery(dbType string) (string, error) {
+	switch dbType {
+	case "postgresql":
+		return `SELECT DISTINCT privilege_type AS privilege FROM information_schema.role_table_grants WHERE grantee = current_user AND table_schema NOT IN ('pg_catalog', 'information_schema')
+UNION SELECT 'CREATE' WHERE has_schema_privilege(current_user, 'public', 'CREATE')
+UNION SELECT 'SUPERUSER' FROM pg_roles WHERE rolname = current_user AND rolsuper
+UNION SELECT 'CREATEROLE' FROM pg_roles WHERE rolname = current_user AND rolcreaterole
+UNION SELECT 'CREATEDB' FROM pg_roles WHERE rolname = current_user AND rolcreatedb`, nil
+	case "mysql":
+		return "SHOW GRANTS FOR CURRENT_USER()", nil
+	default:
+		return "", fmt.Errorf("unsupported database type: %s", dbType)
+	}
+}
+
+var mysqlGrantPattern = regexp.MustCompile(`(?i)^GRANT\s+(.+?)\s+ON\s+`)
+
+// ParseGrants extracts privilege names from the rows returned by GrantsQuery
+func ParseGrants(dbType string, rows [][]any) []string {
+	seen := map[string]bool{}
+	for _, row := range rows {
+		if len(row) == 0 {
+			continue
+		}
+		value, ok := row[0].(string)
+		if !ok {
+			continue
+		}
+
+		if dbType == "mysql" {
+			match := mysqlGrantPattern.FindStringSubmatch(value)
+			if match == nil {
+				continue
+			}
+			for _, priv := range strings.Split(match[1], ",") {
+				priv = strings.ToUpper(strings.TrimSpace(priv))
+				// Column-level grants look like "SELECT (col)"
+				if i := strings.Index(priv, "("); i >= 0 {
+					priv = strings.TrimSpace(priv[:i])
+				}
+				if priv != "" && priv != "USAGE" {
+					seen[priv] = true
+				}
+			}
+			if strings.Contains(strings.ToUpper(value), "WITH GRANT OPTION") {
+				seen["GRANT OPTION"] = true
+			}
+			continue
+		}
+
+		seen[strings.ToUpper(strings.TrimSpace(value))] = true
+	}
+
+	privileges := make([]string, 0, len(seen))
+	for priv := range seen {
+		privileges = append(privileges, priv)
+	}
+	sort.Strings(privileges)
+	return privileges
+}
+
+// privilegeFlag maps a database privilege to the project flag that covers it.
+// Privileges missing from the map are administrative and never covered by a flag.
+var privilegeFlag = map[string]string{
+	"SELECT":                  "read",
+	"SHOW VIEW":               "read",
+	"INSERT":                  "write",
+	"UPDATE":                  "write",
+	"DELETE":                  "delete",
+	"CREATE":                  "ddl",
+	"ALTER":                   "ddl",
+	"DROP":                    "ddl",
+	"INDEX":                   "ddl",
+	"REFERENCES":              "ddl",
+	"TRIGGER":                 "ddl",
+	"TRUNCATE":                "ddl",
+	"CREATE VIEW":             "ddl",
+	"CREATE ROUTINE":          "ddl",
+	"ALTER ROUTINE":           "ddl",
+	"EVENT":                   "ddl",
+	"CREATEDB":                "ddl",
+	"CREATE TEMPORARY TABLES": "ddl",
+}
+
+// Check reports privileges held by the connected user that exceed the given flags
+func Check(privileges []string, flags Flags) *Report {
+	allowed := map[string]bool{
+		"read":   flags.Read,
+		"write":  flags.Write,
+		"delete": flags.Delete,
+		"ddl":    flags.DDL,
+	}
+
+	report := &Report{Privileges: privileges}
+	for _, priv := range privileges {
+		if priv == "ALL" || priv == "ALL PRIVILEGES" {
+			for _, flag := range []string{"read", "write", "delete", "ddl"} {
+				if !allowed[flag] {
+					report.Excess = append(report.Excess, fmt.Sprintf("%s grants %s access, which the project does not allow", priv, flag))
+				}
+			}
+			continue
+		}
+
+		flag, known := privilegeFlag[priv]
+		if !known {
+			report.Excess = append(report.Excess, fmt.Sprintf("%s is an administrative privilege not covered by any project permission", priv))
+			continue
+		}
+		if !allowed[flag] {
+			report.Excess = append(report.Excess, fmt.Sprintf("%s requires %s access, which the project does not allow", priv, flag))
+		}
+	}
+
+	report.Compliant = len(report.Excess) == 0
+	return report
+}
+
+var mysqlDSNDatabase = regexp.MustCompile(`\)/([^?]+)`)
+
+// DatabaseName extracts the database name from a project connection string
+func DatabaseName(dbType, connectionString string) string {
+	if u, err := url.Parse(connectionString); err == nil && u.Scheme != "" && u.Host != "" {
+		return strings.TrimPrefix(u.Path, "/")
+	}
+
+	switch dbType {
+	case "mysql":
+		// user:pass@tcp(host:port)/dbname?params
+		if match := mysqlDSNDatabase.FindStringSubmatch(connectionString); match != nil {
+			return match[1]
+		}
+	case "postgresql":
+		// host=... dbname=... key/value form
+		for _, field := range strings.Fields(connectionString) {
+			if strings.HasPrefix(field, "dbname=") {
+				return strings.Trim(strings.TrimPrefix(field, "dbname="), "'")
+			}
+		}
+	}
+
+	return ""
+}
+
+func quotePostgres(identifier string) string {
+	return `"` + strings.ReplaceAll(identifier, `"`, `""`) + `"`
+}
+
+func quoteMySQL(identifier string) string {
+	return "`" + strings.ReplaceAll(identifier, "`", "``") + "`"
+}
+
+func quoteMySQLAccount(name string) string {
+	return "'" + strings.ReplaceAll(name, "'", "''") + "'"
+}
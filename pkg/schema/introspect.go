@@ -0,0 +1,334 @@
+// pkg/schema/introspect.go
+package schema
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/ephy-lab/ai-db-assistant/pkg/sqlparser"
+)
+
+// Introspect reads the tables, columns, primary keys, foreign keys and
+// indexes of the database db is connected to. PostgreSQL schemas are all
+// read except the system ones; MySQL is limited to the current database.
+func Introspect(ctx context.Context, db *sql.DB, dialect sqlparser.Dialect) (*Schema, error) {
+	var (
+		tables *tableSet
+		err    error
+	)
+	switch dialect {
+	case sqlparser.DialectPostgres:
+		tables, err = introspectPostgres(ctx, db)
+	case sqlparser.DialectMySQL:
+		tables, err = introspectMySQL(ctx, db)
+	default:
+		return nil, fmt.Errorf("schema introspection is not supported for database type %q", dialect)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &Schema{Dialect: string(dialect), Tables: tables.sorted()}, nil
+}
+
+const postgresSystemSchemas = `n.nspname NOT IN ('pg_catalog', 'information_schema')
+	AND n.nspname NOT LIKE 'pg\_toast%' AND n.nspname NOT LIKE 'pg\_temp\_%'`
+
+const postgresColumnsQuery = `
+SELECT n.nspname, c.relname, c.relkind IN ('v', 'm'), a.attname,
+	format_type(a.atttypid, a.atttypmod), NOT a.attnotnull, pg_get_expr(d.adbin, d.adrelid)
+FROM pg_catalog.pg_class c
+JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
+JOIN pg_catalog.pg_attribute a ON a.attrelid = c.oid AND a.attnum > 0 AND NOT a.attisdropped
+LEFT JOIN pg_catalog.pg_attrdef d ON d.adrelid = c.oid AND d.adnum = a.attnum
+WHERE c.relkind IN ('r', 'p', 'v', 'm', 'f') AND NOT c.relispartition
+	AND ` + postgresSystemSchemas + `
+ORDER BY n.nspname, c.relname, a.attnum`
+
+const postgresConstraintsQuery = `
+SELECT n.nspname, c.relname, con.conname, con.contype = 'p',
+	(SELECT json_agg(a.attname ORDER BY k.ord)
+		FROM unnest(con.conkey) WITH ORDINALITY k(attnum, ord)
+		JOIN pg_catalog.pg_attribute a ON a.attrelid = con.conrelid AND a.attnum = k.attnum)::text,
+	COALESCE(fn.nspname, ''), COALESCE(fc.relname, ''),
+	COALESCE((SELECT json_agg(a.attname ORDER BY k.ord)
+		FROM unnest(con.confkey) WITH ORDINALITY k(attnum, ord)
+		JOIN pg_catalog.pg_attribute a ON a.attrelid = con.confrelid AND a.attnum = k.attnum)::text, '[]')
+FROM pg_catalog.pg_constraint con
+JOIN pg_catalog.pg_class c ON c.oid = con.conrelid
+JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
+LEFT JOIN pg_catalog.pg_class fc ON fc.oid = con.confrelid
+LEFT JOIN pg_catalog.pg_namespace fn ON fn.oid = fc.relnamespace
+WHERE con.contype IN ('p', 'f') AND ` + postgresSystemSchemas + `
+ORDER BY n.nspname, c.relname, con.conname`
+
+const postgresIndexesQuery = `
+SELECT n.nspname, c.relname, i.relname, ix.indisunique, ix.indisprimary,
+	(SELECT json_agg(COALESCE(a.attname, pg_get_indexdef(ix.indexrelid, k.ord::int, true)) ORDER BY k.ord)
+		FROM unnest(ix.indkey::int2[]) WITH ORDINALITY k(attnum, ord)
+		LEFT JOIN pg_catalog.pg_attribute a ON a.attrelid = ix.indrelid AND a.attnum = k.attnum AND k.attnum > 0)::text
+FROM pg_catalog.pg_index ix
+JOIN pg_catalog.pg_class c ON c.oid = ix.indrelid
+JOIN pg_catalog.pg_class i ON i.oid = ix.indexrelid
+JOIN pg_catalog.pg_namespace n ON n.oid = c.relnamespace
+WHERE ` + postgresSystemSchemas + `
+ORDER BY n.nspname, c.relname, i.relname`
+
+func introspectPostgres(ctx context.Context, db *sql.DB) (*tableSet, error) {
+	tables := newTableSet()
+
+	err := query(ctx, db, postgresColumnsQuery, func(rows *sql.Rows) error {
+		var (
+			schema, table string
+			view          bool
+			column        Column
+			defaultValue  sql.NullString
+		)
+		if err := rows.Scan(&schema, &table, &view, &column.Name, &column.Type, &column.Nullable, &defaultValue); err != nil {
+			return err
+		}
+		kind := KindTable
+		if view {
+			kind = KindView
+		}
+		column.Default = nullString(defaultValue)
+		t := tables.get(schema, table, kind)
+		t.Columns = append(t.Columns, column)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read columns: %w", err)
+	}
+
+	err = query(ctx, db, postgresConstraintsQuery, func(rows *sql.Rows) error {
+		var (
+			schema, table, name string
+			primary             bool
+			columns, refColumns string
+			refSchema, refTable string
+		)
+		if err := rows.Scan(&schema, &table, &name, &primary, &columns, &refSchema, &refTable, &refColumns); err != nil {
+			return err
+		}
+		t := tables.lookup(schema, table)
+		if t == nil {
+			return nil
+		}
+
+		var columnNames []string
+		if err := json.Unmarshal([]byte(columns), &columnNames); err != nil {
+			return err
+		}
+		if primary {
+			t.PrimaryKey = columnNames
+			return nil
+		}
+
+		fk := ForeignKey{Name: name, Columns: columnNames, RefSchema: refSchema, RefTable: refTable}
+		if err := json.Unmarshal([]byte(refColumns), &fk.RefColumns); err != nil {
+			return err
+		}
+		t.ForeignKeys = append(t.ForeignKeys, fk)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read constraints: %w", err)
+	}
+
+	err = query(ctx, db, postgresIndexesQuery, func(rows *sql.Rows) error {
+		var (
+			schema, table, columns string
+			index                  Index
+		)
+		if err := rows.Scan(&schema, &table, &index.Name, &index.Unique, &index.Primary, &columns); err != nil {
+			return err
+		}
+		t := tables.lookup(schema, table)
+		if t == nil {
+			return nil
+		}
+		if err := json.Unmarshal([]byte(columns), &index.Columns); err != nil {
+			return err
+		}
+		t.Indexes = append(t.Indexes, index)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read indexes: %w", err)
+	}
+
+	return tables, nil
+}
+
+const mysqlColumnsQuery = `
+SELECT c.TABLE_SCHEMA, c.TABLE_NAME, t.TABLE_TYPE <> 'BASE TABLE', c.COLUMN_NAME,
+	c.COLUMN_TYPE, c.IS_NULLABLE = 'YES', c.COLUMN_DEFAULT
+FROM information_schema.COLUMNS c
+JOIN information_schema.TABLES t ON t.TABLE_SCHEMA = c.TABLE_SCHEMA AND t.TABLE_NAME = c.TABLE_NAME
+WHERE c.TABLE_SCHEMA = DATABASE()
+ORDER BY c.TABLE_NAME, c.ORDINAL_POSITION`
+
+const mysqlIndexesQuery = `
+SELECT TABLE_SCHEMA, TABLE_NAME, INDEX_NAME, NON_UNIQUE = 0, COLUMN_NAME
+FROM information_schema.STATISTICS
+WHERE TABLE_SCHEMA = DATABASE()
+ORDER BY TABLE_NAME, INDEX_NAME, SEQ_IN_INDEX`
+
+const mysqlForeignKeysQuery = `
+SELECT TABLE_SCHEMA, TABLE_NAME, CONSTRAINT_NAME, COLUMN_NAME,
+	REFERENCED_TABLE_SCHEMA, REFERENCED_TABLE_NAME, REFERENCED_COLUMN_NAME
+FROM information_schema.KEY_COLUMN_USAGE
+WHERE TABLE_SCHEMA = DATABASE() AND REFERENCED_TABLE_NAME IS NOT NULL
+ORDER BY TABLE_NAME, CONSTRAINT_NAME, ORDINAL_POSITION`
+
+func introspectMySQL(ctx context.Context, db *sql.DB) (*tableSet, error) {
+	tables := newTableSet()
+
+	err := query(ctx, db, mysqlColumnsQuery, func(rows *sql.Rows) error {
+		var (
+			schema, table string
+			view          bool
+			column        Column
+			defaultValue  sql.NullString
+		)
+		if err := rows.Scan(&schema, &table, &view, &column.Name, &column.Type, &column.Nullable, &defaultValue); err != nil {
+			return err
+		}
+		kind := KindTable
+		if view {
+			kind = KindView
+		}
+		column.Default = nullString(defaultValue)
+		t := tables.get(schema, table, kind)
+		t.Columns = append(t.Columns, column)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read columns: %w", err)
+	}
+
+	// Rows come one per index column, grouped by index
+	err = query(ctx, db, mysqlIndexesQuery, func(rows *sql.Rows) error {
+		var (
+			schema, table, name string
+			unique              bool
+			column              sql.NullString
+		)
+		if err := rows.Scan(&schema, &table, &name, &unique, &column); err != nil {
+			return err
+		}
+		t := tables.lookup(schema, table)
+		if t == nil {
+			return nil
+		}
+
+		columnName := column.String
+		if !column.Valid {
+			columnName = "(expression)"
+		}
+		if n := len(t.Indexes); n == 0 || t.Indexes[n-1].Name != name {
+			t.Indexes = append(t.Indexes, Index{Name: name, Unique: unique, Primary: name == "PRIMARY"})
+		}
+		index := &t.Indexes[len(t.Indexes)-1]
+		index.Columns = append(index.Columns, columnName)
+		if index.Primary {
+			t.PrimaryKey = index.Columns
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read indexes: %w", err)
+	}
+
+	// Rows come one per key column, grouped by constraint
+	err = query(ctx, db, mysqlForeignKeysQuery, func(rows *sql.Rows) error {
+		var schema, table, name, column, refSchema, refTable, refColumn string
+		if err := rows.Scan(&schema, &table, &name, &column, &refSchema, &refTable, &refColumn); err != nil {
+			return err
+		}
+		t := tables.lookup(schema, table)
+		if t == nil {
+			return nil
+		}
+
+		if n := len(t.ForeignKeys); n == 0 || t.ForeignKeys[n-1].Name != name {
+			t.ForeignKeys = append(t.ForeignKeys, ForeignKey{Name: name, RefSchema: refSchema, RefTable: refTable})
+		}
+		fk := &t.ForeignKeys[len(t.ForeignKeys)-1]
+		fk.Columns = append(fk.Columns, column)
+		fk.RefColumns = append(fk.RefColumns, refColumn)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read foreign keys: %w", err)
+	}
+
+	return tables, nil
+}
+
+// query runs a catalog query and calls scan for every row
+func query(ctx context.Context, db *sql.DB, text string, scan func(*sql.Rows) error) error {
+	rows, err := db.QueryContext(ctx, text)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		if err := scan(rows); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+func nullString(s sql.NullString) *string {
+	if !s.Valid {
+		return nil
+	}
+	return &s.String
+}
+
+// tableSet collects tables while the catalog queries are read
+type tableSet struct {
+	tables map[[2]string]*Table
+}
+
+func newTableSet() *tableSet {
+	return &tableSet{tables: make(map[[2]string]*Table)}
+}
+
+// get returns a table, adding it if it is new
+func (s *tableSet) get(schema, name, kind string) *Table {
+	key := [2]string{schema, name}
+	t, ok := s.tables[key]
+	if !ok {
+		t = &Table{Schema: schema, Name: name, Kind: kind}
+		s.tables[key] = t
+	}
+	return t
+}
+
+// lookup returns a table read by the columns query, or nil for tables
+// outside it such as partitions
+func (s *tableSet) lookup(schema, name string) *Table {
+	return s.tables[[2]string{schema, name}]
+}
+
+// sorted returns the tables ordered by schema and name
+func (s *tableSet) sorted() []Table {
+	tables := make([]Table, 0, len(s.tables))
+	for _, t := range s.tables {
+		tables = append(tables, *t)
+	}
+	sort.Slice(tables, func(i, j int) bool {
+		if tables[i].Schema != tables[j].Schema {
+			return tables[i].Schema < tables[j].Schema
+		}
+		return tables[i].Name < tables[j].Name
+	})
+	return tables
+}
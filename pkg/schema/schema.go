@@ -0,0 +1,75 @@
+// pkg/schema/schema.go
+package schema
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+)
+
+// Table kinds
+const (
+	KindTable = "table"
+	KindView  = "view"
+)
+
+// Schema describes the tables of a database
+type Schema struct {
+	Dialect string  `json:"dialect"`
+	Tables  []Table `json:"tables"`
+}
+
+// Table is a table or view with its columns and constraints
+type Table struct {
+	Schema      string       `json:"schema"`
+	Name        string       `json:"name"`
+	Kind        string       `json:"kind"` // "table" or "view"
+	Columns     []Column     `json:"columns"`
+	PrimaryKey  []string     `json:"primary_key,omitempty"`
+	ForeignKeys []ForeignKey `json:"foreign_keys,omitempty"`
+	Indexes     []Index      `json:"indexes,omitempty"`
+}
+
+// Column is a table column in ordinal order
+type Column struct {
+	Name     string  `json:"name"`
+	Type     string  `json:"type"`
+	Nullable bool    `json:"nullable"`
+	Default  *string `json:"default,omitempty"`
+}
+
+// ForeignKey references the columns of another table
+type ForeignKey struct {
+	Name       string   `json:"name"`
+	Columns    []string `json:"columns"`
+	RefSchema  string   `json:"ref_schema"`
+	RefTable   string   `json:"ref_table"`
+	RefColumns []string `json:"ref_columns"`
+}
+
+// Index is an index on a table. Expression indexes list the expression in
+// place of a column name.
+type Index struct {
+	Name    string   `json:"name"`
+	Columns []string `json:"columns"`
+	Unique  bool     `json:"unique"`
+	Primary bool     `json:"primary,omitempty"`
+}
+
+// Checksum identifies the schema's content, so that unchanged schemas can be
+// recognised without comparing them field by field
+func (s *Schema) Checksum() string {
+	data, _ := json.Marshal(s)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// Table returns the table with the given schema and name, or nil
+func (s *Schema) Table(schema, name string) *Table {
+	for i := range s.Tables {
+		if s.Tables[i].Schema == schema && s.Tables[i].Name == name {
+			return &s.Tables[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,339 @@
+// pkg/schema/select.go
+package schema
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/ephy-lab/ai-db-assistant/pkg/sqlparser"
+)
+
+// DefaultBudget is the default size in bytes of the schema text sent with a
+// question
+const DefaultBudget = 8000
+
+// Selection is the part of a schema sent with a question
+type Selection struct {
+	Text    string   // compact DDL, one statement per line
+	Tables  []string // selected tables, most relevant first
+	Omitted int      // tables left out to stay within the budget
+}
+
+// Select renders the tables most relevant to question as compact DDL
+// within budget bytes (no limit when budget <= 0). Tables are ranked by how
+// well their names and column names match the words of the question, then
+// tables linked to a match by a foreign key, then the rest. A final comment
+// counts the tables left out.
+func Select(s *Schema, question string, budget int) Selection {
+	dialect := sqlparser.ParseDialect(s.Dialect)
+	ranked := rank(s, question)
+
+	var (
+		selection Selection
+		lines     []string
+		size      int
+	)
+	for _, t := range ranked {
+		line := t.DDL(dialect)
+		if budget > 0 && size+len(line)+1 > budget {
+			selection.Omitted++
+			continue
+		}
+		lines = append(lines, line)
+		selection.Tables = append(selection.Tables, t.QualifiedName(dialect))
+		size += len(line) + 1
+	}
+
+	// Make room for the note by dropping the least relevant tables
+	if selection.Omitted > 0 {
+		for len(lines) > 0 && size+len(omittedNote(selection.Omitted)) > budget {
+			last := len(lines) - 1
+			size -= len(lines[last]) + 1
+			lines = lines[:last]
+			selection.Tables = selection.Tables[:last]
+			selection.Omitted++
+		}
+		if size+len(omittedNote(selection.Omitted)) <= budget {
+			lines = append(lines, omittedNote(selection.Omitted))
+		}
+	}
+
+	selection.Text = strings.Join(lines, "\n")
+	return selection
+}
+
+func omittedNote(n int) string {
+	if n == 1 {
+		return "-- 1 more table not shown"
+	}
+	return fmt.Sprintf("-- %d more tables not shown", n)
+}
+
+// rank orders the tables by relevance to the question
+func rank(s *Schema, question string) []*Table {
+	words := make(map[string]bool)
+	for _, word := range nameWords(question) {
+		words[word] = true
+	}
+
+	scores := make(map[*Table]float64, len(s.Tables))
+	byName := make(map[[2]string]*Table, len(s.Tables))
+	for i := range s.Tables {
+		t := &s.Tables[i]
+		scores[t] = score(t, words)
+		byName[[2]string{t.Schema, t.Name}] = t
+	}
+
+	// Tables joined to a match by a foreign key are likely needed to answer
+	// the question, so they inherit half of its score
+	inherited := make(map[*Table]float64)
+	link := func(a, b *Table) {
+		if b == nil {
+			return
+		}
+		if half := scores[a] / 2; half > inherited[b] {
+			inherited[b] = half
+		}
+		if half := scores[b] / 2; half > inherited[a] {
+			inherited[a] = half
+		}
+	}
+	for i := range s.Tables {
+		t := &s.Tables[i]
+		for _, fk := range t.ForeignKeys {
+			link(t, byName[[2]string{fk.RefSchema, fk.RefTable}])
+		}
+	}
+	for t, bonus := range inherited {
+		if bonus > scores[t] {
+			scores[t] = bonus
+		}
+	}
+
+	ranked := make([]*Table, 0, len(s.Tables))
+	for i := range s.Tables {
+		ranked = append(ranked, &s.Tables[i])
+	}
+	sort.SliceStable(ranked, func(i, j int) bool {
+		return scores[ranked[i]] > scores[ranked[j]]
+	})
+	return ranked
+}
+
+// maxColumnMatches caps the score wide tables get from their columns
+const maxColumnMatches = 3
+
+// score rates how well a table matches the words of a question: its name
+// counts most, its column names less
+func score(t *Table, words map[string]bool) float64 {
+	var total float64
+
+	nameParts := nameWords(t.Name)
+	matched := 0
+	for _, part := range nameParts {
+		total += 3 * match(part, words)
+		if words[part] {
+			matched++
+		}
+	}
+	if len(nameParts) > 0 && matched == len(nameParts) {
+		total += 5
+	}
+
+	columnMatches := 0
+	for _, column := range t.Columns {
+		var best float64
+		for _, part := range nameWords(column.Name) {
+			if m := match(part, words); m > best {
+				best = m
+			}
+		}
+		if best > 0 && columnMatches < maxColumnMatches {
+			total += best
+			columnMatches++
+		}
+	}
+	return total
+}
+
+// match is 1 for a word of the question, 0.5 for a near match (a shared
+// prefix of four letters or more, or one edit apart) and 0 otherwise
+func match(part string, words map[string]bool) float64 {
+	if words[part] {
+		return 1
+	}
+	if len(part) < 4 {
+		return 0
+	}
+	for word := range words {
+		if len(word) < 4 {
+			continue
+		}
+		if strings.HasPrefix(word, part) || strings.HasPrefix(part, word) || oneEdit(word, part) {
+			return 0.5
+		}
+	}
+	return 0
+}
+
+// nameWords splits text or an identifier into lower-case singular words,
+// breaking on punctuation, underscores and camelCase
+func nameWords(text string) []string {
+	var (
+		words   []string
+		current []rune
+	)
+	flush := func() {
+		if len(current) > 1 {
+			words = append(words, singular(string(current)))
+		}
+		current = current[:0]
+	}
+
+	runes := []rune(text)
+	for i, r := range runes {
+		switch {
+		case unicode.IsUpper(r):
+			if i > 0 && unicode.IsLower(runes[i-1]) {
+				flush()
+			}
+			current = append(current, unicode.ToLower(r))
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			current = append(current, r)
+		default:
+			flush()
+		}
+	}
+	flush()
+	return words
+}
+
+// singular strips common English plural endings
+func singular(word string) string {
+	switch {
+	case strings.HasSuffix(word, "ies") && len(word) > 4:
+		return word[:len(word)-3] + "y"
+	case strings.HasSuffix(word, "sses"), strings.HasSuffix(word, "xes"), strings.HasSuffix(word, "ches"), strings.HasSuffix(word, "shes"):
+		return word[:len(word)-2]
+	case strings.HasSuffix(word, "s") && !strings.HasSuffix(word, "ss") && len(word) > 3:
+		return word[:len(word)-1]
+	}
+	return word
+}
+
+// oneEdit reports whether a and b differ by exactly one insertion,
+// deletion or substitution
+func oneEdit(a, b string) bool {
+	if len(a) > len(b) {
+		a, b = b, a
+	}
+	if len(b)-len(a) > 1 || a == b {
+		return false
+	}
+
+	i := 0
+	for i < len(a) && a[i] == b[i] {
+		i++
+	}
+	if len(a) == len(b) {
+		return a[i+1:] == b[i+1:]
+	}
+	return a[i:] == b[i+1:]
+}
+
+// DDL renders the table as a single-line CREATE statement with its columns,
+// nullability, primary key, single-column unique indexes and foreign keys.
+// Defaults and other indexes are left out to keep it short.
+func (t *Table) DDL(dialect sqlparser.Dialect) string {
+	unique := make(map[string]bool)
+	for _, index := range t.Indexes {
+		if index.Unique && !index.Primary && len(index.Columns) == 1 {
+			unique[index.Columns[0]] = true
+		}
+	}
+
+	inlineFK := make(map[string]ForeignKey)
+	var tableFKs []ForeignKey
+	for _, fk := range t.ForeignKeys {
+		if len(fk.Columns) == 1 && len(fk.RefColumns) == 1 {
+			inlineFK[fk.Columns[0]] = fk
+		} else {
+			tableFKs = append(tableFKs, fk)
+		}
+	}
+
+	var parts []string
+	for _, column := range t.Columns {
+		part := quoteIdent(column.Name, dialect) + " " + column.Type
+		switch {
+		case len(t.PrimaryKey) == 1 && t.PrimaryKey[0] == column.Name:
+			part += " PRIMARY KEY"
+		case !column.Nullable:
+			part += " NOT NULL"
+		}
+		if unique[column.Name] {
+			part += " UNIQUE"
+		}
+		if fk, ok := inlineFK[column.Name]; ok {
+			part += " REFERENCES " + qualify(fk.RefSchema, fk.RefTable, dialect) + "(" + quoteIdent(fk.RefColumns[0], dialect) + ")"
+		}
+		parts = append(parts, part)
+	}
+	if len(t.PrimaryKey) > 1 {
+		parts = append(parts, "PRIMARY KEY ("+quoteIdents(t.PrimaryKey, dialect)+")")
+	}
+	for _, fk := range tableFKs {
+		parts = append(parts, "FOREIGN KEY ("+quoteIdents(fk.Columns, dialect)+") REFERENCES "+
+			qualify(fk.RefSchema, fk.RefTable, dialect)+"("+quoteIdents(fk.RefColumns, dialect)+")")
+	}
+
+	keyword := "TABLE"
+	if t.Kind == KindView {
+		keyword = "VIEW"
+	}
+	return "CREATE " + keyword + " " + t.QualifiedName(dialect) + " (" + strings.Join(parts, ", ") + ");"
+}
+
+// QualifiedName is the table's name as it is written in queries: with its
+// schema unless it is in PostgreSQL's public schema or the current MySQL
+// database
+func (t *Table) QualifiedName(dialect sqlparser.Dialect) string {
+	return qualify(t.Schema, t.Name, dialect)
+}
+
+func qualify(schema, name string, dialect sqlparser.Dialect) string {
+	if schema == "" || dialect == sqlparser.DialectMySQL || (dialect == sqlparser.DialectPostgres && schema == "public") {
+		return quoteIdent(name, dialect)
+	}
+	return quoteIdent(schema, dialect) + "." + quoteIdent(name, dialect)
+}
+
+var (
+	plainPostgresIdent = regexp.MustCompile(`^[a-z_][a-z0-9_]*$`)
+	plainMySQLIdent    = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_$]*$`)
+)
+
+// quoteIdent quotes an identifier only when it cannot be written bare
+func quoteIdent(name string, dialect sqlparser.Dialect) string {
+	if dialect == sqlparser.DialectMySQL {
+		if plainMySQLIdent.MatchString(name) {
+			return name
+		}
+		return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+	}
+	if plainPostgresIdent.MatchString(name) {
+		return name
+	}
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+func quoteIdents(names []string, dialect sqlparser.Dialect) string {
+	quoted := make([]string, len(names))
+	for i, name := range names {
+		quoted[i] = quoteIdent(name, dialect)
+	}
+	return strings.Join(quoted, ", ")
+}
@@ -0,0 +1,593 @@
+// pkg/sqlformat/format.go
+package sqlformat
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/ephy-lab/ai-db-assistant/pkg/sqlparser"
+)
+
+// KeywordCase controls how SQL keywords are written
+type KeywordCase string
+
+const (
+	KeywordCaseUpper    KeywordCase = "upper"
+	KeywordCaseLower    KeywordCase = "lower"
+	KeywordCasePreserve KeywordCase = "preserve"
+)
+
+// Options configures the formatter
+type Options struct {
+	Dialect     sqlparser.Dialect
+	KeywordCase KeywordCase
+	IndentWidth int  // spaces per indentation level
+	UseTabs     bool // indent with tabs instead of spaces
+}
+
+// DefaultOptions returns upper-case keywords and two-space indentation
+func DefaultOptions(dialect sqlparser.Dialect) Options {
+	return Options{
+		Dialect:     dialect,
+		KeywordCase: KeywordCaseUpper,
+		IndentWidth: 2,
+	}
+}
+
+// Validate checks that the options are usable
+func (o Options) Validate() error {
+	switch o.KeywordCase {
+	case KeywordCaseUpper, KeywordCaseLower, KeywordCasePreserve:
+	default:
+		return fmt.Errorf("unsupported keyword case %q", o.KeywordCase)
+	}
+	if o.IndentWidth < 0 || o.IndentWidth > 8 {
+		return fmt.Errorf("indent width must be between 0 and 8")
+	}
+	return nil
+}
+
+// clauseKeywords start a clause on its own line, with the clause body on the
+// following lines one level deeper
+var clauseKeywords = map[string]bool{
+	"SELECT": true, "FROM": true, "WHERE": true, "GROUP BY": true, "ORDER BY": true, "HAVING": true,
+	"VALUES": true, "SET": true, "RETURNING": true, "WINDOW": true, "QUALIFY": true,
+}
+
+// inlineClauseKeywords start a clause on its own line with the body on the same line
+var inlineClauseKeywords = map[string]bool{
+	"LIMIT": true, "OFFSET": true, "FETCH": true, "FOR": true, "WITH": true,
+	"INSERT INTO": true, "REPLACE INTO": true, "UPDATE": true, "DELETE FROM": true, "DELETE": true,
+	"MERGE INTO": true, "USING": true, "ON CONFLICT": true, "ON DUPLICATE KEY UPDATE": true, "WHEN": true,
+}
+
+// setOperators separate whole queries
+var setOperators = map[string]bool{
+	"UNION": true, "UNION ALL": true, "UNION DISTINCT": true, "INTERSECT": true, "INTERSECT ALL": true,
+	"EXCEPT": true, "EXCEPT ALL": true, "MINUS": true,
+}
+
+// joinKeywords start a join on its own line within the FROM clause
+var joinKeywords = map[string]bool{
+	"JOIN": true, "INNER JOIN": true, "LEFT JOIN": true, "RIGHT JOIN": true, "FULL JOIN": true,
+	"LEFT OUTER JOIN": true, "RIGHT OUTER JOIN": true, "FULL OUTER JOIN": true, "CROSS JOIN": true,
+	"NATURAL JOIN": true, "NATURAL LEFT JOIN": true, "NATURAL RIGHT JOIN": true, "STRAIGHT_JOIN": true,
+	"CROSS APPLY": true, "OUTER APPLY": true, "LEFT JOIN LATERAL": true, "JOIN LATERAL": true,
+}
+
+// listClauses put each top-level comma-separated item on its own line
+var listClauses = map[string]bool{
+	"SELECT": true, "SET": true, "VALUES": true, "RETURNING": true, "GROUP BY": true, "ORDER BY": true, "FROM": true,
+}
+
+// spacedParenKeywords keep a space before a following parenthesis
+var spacedParenKeywords = map[string]bool{
+	"IN": true, "VALUES": true, "AS": true, "ON": true, "USING": true, "EXISTS": true, "ANY": true, "ALL": true,
+	"SOME": true, "AND": true, "OR": true, "NOT": true, "OVER": true, "FILTER": true, "FROM": true, "JOIN": true,
+	"WHERE": true, "SELECT": true, "RETURNING": true, "THEN": true, "ELSE": true, "WHEN": true, "BY": true,
+	"SET": true, "INTO": true, "LATERAL": true, "CONFLICT": true, "CHECK": true, "KEY": true, "REFERENCES": true,
+	"UNIQUE": true, "DEFAULT": true, "WITHIN": true, "IS": true, "LIKE": true, "BETWEEN": true, "CASE": true,
+}
+
+type frame struct {
+	query      bool // parenthesised query, CTE body or the statement itself
+	list       bool // column definition list: one item per line
+	indent     int  // indentation level of the frame's clauses
+	openIndent int  // indentation level of the line holding the opening parenthesis
+	clause     string
+	between    bool // inside BETWEEN x AND y
+	cast       bool // CAST or CONVERT arguments: a type follows AS or the comma
+}
+
+type formatter struct {
+	opts         Options
+	src          string
+	out          strings.Builder
+	frames       []*frame
+	lineLevel    int
+	lineStart    bool
+	prev         *sqlparser.Token
+	prevWord     string // upper-cased text of the previous token when it is a word
+	target       bool   // the previous word names the target of INSERT/UPDATE/CREATE TABLE
+	expectTarget bool   // the next word names such a target
+	unary        bool   // the previous token is a unary sign
+}
+
+// Format pretty-prints every statement in query. Statements are separated by
+// a blank line and terminated with a semicolon when the input had one.
+// Literals, quoted identifiers, comments and MySQL executable comments are
+// kept verbatim.
+func Format(query string, opts Options) string {
+	if opts.KeywordCase == "" {
+		opts.KeywordCase = KeywordCaseUpper
+	}
+
+	f := &formatter{opts: opts, src: query, lineStart: true}
+	f.frames = []*frame{{query: true}}
+
+	tokens := sqlparser.Tokenize(query, opts.Dialect)
+	for i := 0; i < len(tokens); i++ {
+		i = f.token(tokens, i)
+	}
+
+	return strings.TrimSpace(f.out.String())
+}
+
+func (f *formatter) top() *frame {
+	return f.frames[len(f.frames)-1]
+}
+
+func (f *formatter) indentString(level int) string {
+	if f.opts.UseTabs {
+		return strings.Repeat("\t", level)
+	}
+	return strings.Repeat(" ", level*f.opts.IndentWidth)
+}
+
+func (f *formatter) newline(level int) {
+	if f.out.Len() == 0 {
+		f.lineLevel = level
+		f.lineStart = true
+		return
+	}
+	if !f.lineStart {
+		f.out.WriteString("\n")
+	}
+	f.lineLevel = level
+	f.lineStart = true
+}
+
+// write emits text, preceded by a space unless spacing rules forbid it
+func (f *formatter) write(text string, space bool) {
+	if f.lineStart {
+		f.out.WriteString(f.indentString(f.lineLevel))
+		f.lineStart = false
+	} else if space {
+		f.out.WriteString(" ")
+	}
+	f.out.WriteString(text)
+}
+
+// keyword renders a keyword in the configured case
+func (f *formatter) keyword(text string) string {
+	switch f.opts.KeywordCase {
+	case KeywordCaseUpper:
+		return strings.ToUpper(text)
+	case KeywordCaseLower:
+		return strings.ToLower(text)
+	}
+	return text
+}
+
+// phrase joins consecutive word tokens starting at i into the longest
+// multi-word keyword known to the formatter, returning it and its length
+func phrase(tokens []sqlparser.Token, i int) (string, int) {
+	var words []string
+	best, bestLen := "", 0
+	for j := i; j < len(tokens) && len(words) < 4; j++ {
+		tok := tokens[j]
+		if tok.Kind == sqlparser.TokenComment {
+			break
+		}
+		if tok.Kind != sqlparser.TokenWord {
+			break
+		}
+		words = append(words, tok.Upper())
+		candidate := strings.Join(words, " ")
+		if clauseKeywords[candidate] || inlineClauseKeywords[candidate] || setOperators[candidate] || joinKeywords[candidate] {
+			best, bestLen = candidate, len(words)
+		}
+	}
+	return best, bestLen
+}
+
+// token formats tokens[i] and returns the index of the last token consumed
+func (f *formatter) token(tokens []sqlparser.Token, i int) int {
+	tok := tokens[i]
+	fr := f.top()
+
+	switch {
+	case tok.IsExecCommentStart():
+		// MySQL executable comments run only on some server versions, so
+		// they are kept exactly as written
+		end := i
+		for end+1 < len(tokens) && !tokens[end].IsExecCommentEnd() {
+			end++
+		}
+		f.write(f.src[tok.Pos:tokens[end].End], f.prev != nil)
+		return end
+
+	case tok.Kind == sqlparser.TokenComment:
+		f.write(strings.TrimRight(tok.Text, "\r\n"), true)
+		if strings.HasPrefix(tok.Text, "--") || strings.HasPrefix(tok.Text, "#") {
+			f.newline(f.lineLevel)
+		}
+		return i
+
+	case tok.IsPunct(";"):
+		f.write(";", false)
+		f.frames = []*frame{{query: true}}
+		f.out.WriteString("\n\n")
+		f.lineStart = true
+		f.lineLevel = 0
+		f.prev, f.prevWord = nil, ""
+		return i
+
+	case tok.IsPunct("("):
+		f.openParen(tokens, i)
+		f.remember(tok)
+		return i
+
+	case tok.IsPunct(")"):
+		f.closeParen()
+		f.remember(tok)
+		return i
+
+	case tok.IsPunct(","):
+		f.write(",", false)
+		switch {
+		case fr.list:
+			f.newline(fr.indent)
+		case fr.query && listClauses[fr.clause]:
+			f.newline(fr.indent + 1)
+		case fr.query && fr.clause == "WITH":
+			f.newline(fr.indent)
+		}
+		f.remember(tok)
+		return i
+
+	case tok.IsPunct("."):
+		f.write(".", false)
+		f.remember(tok)
+		return i
+
+	case tok.Kind == sqlparser.TokenWord || tok.Kind == sqlparser.TokenQuotedIdent:
+		text := tok.Text
+		if tok.Kind == sqlparser.TokenWord {
+			if fr.query {
+				if next, ok := f.clauseWord(tokens, i); ok {
+					return next
+				}
+			}
+			if isKeyword(tok.Upper()) && f.keywordPosition(tokens, i) {
+				text = f.keyword(text)
+			}
+			switch {
+			case tok.IsKeyword("BETWEEN"):
+				fr.between = true
+			case tok.IsKeyword("AND") && fr.between:
+				fr.between = false
+			case tok.IsKeyword("AND", "OR") && fr.query && (fr.clause == "WHERE" || fr.clause == "HAVING"):
+				f.newline(fr.indent + 1)
+			case tok.IsKeyword("AND", "OR") && fr.query && fr.clause == "JOIN":
+				f.newline(fr.indent + 2)
+			}
+		}
+		f.write(text, f.spaceBefore(tok))
+		f.updateTarget(tok)
+		f.remember(tok)
+		return i
+	}
+
+	f.write(tok.Text, f.spaceBefore(tok))
+	unary := (tok.Text == "-" || tok.Text == "+") && (f.prev == nil || f.prev.Kind == sqlparser.TokenOperator || f.prev.IsPunct("(") || f.prev.IsPunct(",") || (f.prev.Kind == sqlparser.TokenWord && isKeyword(f.prevWord)))
+	f.remember(tok)
+	f.unary = unary
+	return i
+}
+
+func (f *formatter) remember(tok sqlparser.Token) {
+	t := tok
+	f.prev = &t
+	f.unary = false
+	f.prevWord = ""
+	if tok.Kind == sqlparser.TokenWord {
+		f.prevWord = tok.Upper()
+	}
+	if !tok.IsPunct(".") && tok.Kind != sqlparser.TokenWord && tok.Kind != sqlparser.TokenQuotedIdent {
+		f.target, f.expectTarget = false, false
+	}
+}
+
+// keywordPosition reports whether the keyword at tokens[i] is used as a
+// keyword rather than as a name. Reserved words are keywords unless they are
+// qualified with a dot. Unreserved ones such as DATE or KEY are also common
+// column names, so they are only treated as keywords where an operand or
+// name cannot stand: as a type, before a typed literal, or next to other
+// keywords.
+func (f *formatter) keywordPosition(tokens []sqlparser.Token, i int) bool {
+	tok := tokens[i]
+	next := nextSignificant(tokens, i+1)
+	if (f.prev != nil && f.prev.IsPunct(".")) || (next != nil && next.IsPunct(".")) {
+		return false
+	}
+
+	upper := tok.Upper()
+	if !unreservedKeywords[upper] {
+		return true
+	}
+
+	fr := f.top()
+	switch {
+	case f.prev != nil && f.prev.Kind == sqlparser.TokenOperator && f.prev.Text == "::":
+		return true
+	case fr.cast && f.prev != nil && (f.prevWord == "AS" || f.prev.IsPunct(",")):
+		return true
+	case next != nil && next.Kind == sqlparser.TokenString:
+		// Typed literal: DATE '2024-01-01', INTERVAL '1 day'
+		return true
+	case upper == "MATCHED" && (f.prevWord == "WHEN" || f.prevWord == "NOT"):
+		// MERGE ... WHEN [NOT] MATCHED
+		return true
+	case f.expectTarget && !tok.IsKeyword("IF", "IGNORE"):
+		// Table name of INSERT INTO, UPDATE, CREATE TABLE, ...
+		return false
+	case fr.list && f.prev != nil && (f.prev.IsPunct("(") || f.prev.IsPunct(",")):
+		// Column name in a column definition list
+		return false
+	}
+	return !f.operandExpected() || !followsOperand(next)
+}
+
+// operandExpected reports whether the previous token is followed by an
+// operand or a name: an opening parenthesis, a comma, an operator or a
+// keyword such as SELECT, WHERE or AS
+func (f *formatter) operandExpected() bool {
+	switch {
+	case f.prev == nil:
+		return false
+	case f.prev.IsPunct("("), f.prev.IsPunct(","):
+		return true
+	case f.prev.Kind == sqlparser.TokenOperator:
+		return f.prev.Text != "::"
+	case f.prev.Kind == sqlparser.TokenWord:
+		return operandKeywords[f.prevWord]
+	}
+	return false
+}
+
+// followsOperand reports whether next can follow an operand or a name: the
+// end of the statement, punctuation, an operator, an alias or a keyword such
+// as FROM, DESC or AND
+func followsOperand(next *sqlparser.Token) bool {
+	if next == nil {
+		return true
+	}
+	switch next.Kind {
+	case sqlparser.TokenPunct, sqlparser.TokenOperator, sqlparser.TokenQuotedIdent:
+		return true
+	case sqlparser.TokenWord:
+		upper := next.Upper()
+		return postOperandKeywords[upper] || !isKeyword(upper)
+	}
+	return false
+}
+
+// updateTarget tracks whether the word just written names the table of an
+// INSERT, UPDATE, DELETE or CREATE TABLE, whose column list keeps a space
+// before its parenthesis
+func (f *formatter) updateTarget(tok sqlparser.Token) {
+	isWord := tok.Kind == sqlparser.TokenWord
+	switch {
+	case f.expectTarget && isWord && tok.IsKeyword("IF", "NOT", "EXISTS", "ONLY", "IGNORE", "LOW_PRIORITY"):
+		// Modifiers before the target name
+	case f.expectTarget:
+		f.target, f.expectTarget = true, false
+	case f.target && f.prev != nil && f.prev.IsPunct("."):
+		// Qualified target name
+	case isWord && tok.IsKeyword("INTO", "TABLE"):
+		f.target, f.expectTarget = false, true
+	default:
+		f.target = false
+	}
+}
+
+// clauseWord lays out clause, join and set-operator keywords inside a query
+// frame. It returns the index of the last token consumed and whether the
+// word was handled.
+func (f *formatter) clauseWord(tokens []sqlparser.Token, i int) (int, bool) {
+	fr := f.top()
+	word, n := phrase(tokens, i)
+	if n == 0 {
+		return i, false
+	}
+
+	// Qualified names such as t.offset are never clauses
+	if next := nextSignificant(tokens, i+1); (f.prev != nil && f.prev.IsPunct(".")) || (n == 1 && next != nil && next.IsPunct(".")) {
+		return i, false
+	}
+
+	// DELETE FROM keeps its target on the same line
+	if word == "FROM" && fr.clause == "DELETE" {
+		return i, false
+	}
+	// Keywords used inside other constructs
+	if word == "FOR" && (i+1 >= len(tokens) || !tokens[i+1].IsKeyword("UPDATE", "SHARE", "NO", "KEY")) {
+		return i, false
+	}
+	if word == "SET" && fr.clause == "ON DUPLICATE KEY UPDATE" {
+		return i, false
+	}
+	if word == "USING" && fr.clause != "MERGE INTO" {
+		return i, false
+	}
+	if word == "UPDATE" && (f.prevWord == "FOR" || f.prevWord == "THEN" || f.prevWord == "DO") {
+		return i, false
+	}
+	if word == "DELETE" && f.prevWord == "THEN" {
+		return i, false
+	}
+	if word == "WHEN" && fr.clause != "MERGE INTO" && fr.clause != "USING" && fr.clause != "WHEN" {
+		return i, false
+	}
+	if word == "WITH" && f.prev != nil && !f.prev.IsPunct(")") && f.out.Len() > 0 && !f.lineStart && fr.clause != "" {
+		// WITH inside a statement, e.g. WITH TIES or WITH ROLLUP
+		return i, false
+	}
+
+	var parts []string
+	for j := i; j < i+n; j++ {
+		parts = append(parts, f.keyword(tokens[j].Text))
+	}
+	text := strings.Join(parts, " ")
+	fr.between = false
+
+	switch {
+	case setOperators[word]:
+		f.newline(fr.indent)
+		f.write(text, false)
+		f.newline(fr.indent)
+		fr.clause = ""
+	case joinKeywords[word]:
+		f.newline(fr.indent + 1)
+		f.write(text, false)
+		fr.clause = "JOIN"
+	case clauseKeywords[word]:
+		f.newline(fr.indent)
+		f.write(text, false)
+		// Modifiers stay on the clause line
+		for i+n < len(tokens) && tokens[i+n].IsKeyword("DISTINCT", "ALL", "TOP", "SQL_CALC_FOUND_ROWS", "STRAIGHT_JOIN") {
+			f.write(f.keyword(tokens[i+n].Text), true)
+			if tokens[i+n].IsKeyword("DISTINCT") && i+n+1 < len(tokens) && tokens[i+n+1].IsKeyword("ON") {
+				n++
+				f.write(f.keyword(tokens[i+n].Text), true)
+			}
+			n++
+		}
+		f.newline(fr.indent + 1)
+		fr.clause = word
+	default:
+		f.newline(fr.indent)
+		f.write(text, false)
+		fr.clause = word
+	}
+
+	last := tokens[i+n-1]
+	f.remember(last)
+	f.target = false
+	f.expectTarget = word == "INSERT INTO" || word == "REPLACE INTO" || word == "MERGE INTO" || word == "UPDATE" || word == "DELETE FROM"
+	return i + n - 1, true
+}
+
+func (f *formatter) openParen(tokens []sqlparser.Token, i int) {
+	fr := f.top()
+	next := nextSignificant(tokens, i+1)
+	isQuery := next != nil && next.IsKeyword("SELECT", "WITH", "VALUES")
+
+	f.write("(", f.spaceBeforeParen())
+
+	child := &frame{openIndent: f.lineLevel}
+	child.cast = f.prevWord == "CAST" || f.prevWord == "TRY_CAST" || f.prevWord == "CONVERT"
+	switch {
+	case isQuery:
+		child.query = true
+		child.indent = f.lineLevel + 1
+		f.newline(child.indent)
+	case f.target && fr.clause == "" && f.prev != nil && isCreateTable(tokens, i):
+		// CREATE TABLE column definitions, one per line
+		child.list = true
+		child.indent = f.lineLevel + 1
+		f.newline(child.indent)
+	default:
+		child.indent = fr.indent
+	}
+	f.frames = append(f.frames, child)
+}
+
+func (f *formatter) closeParen() {
+	if len(f.frames) == 1 {
+		f.write(")", false)
+		return
+	}
+	child := f.top()
+	f.frames = f.frames[:len(f.frames)-1]
+	if child.query || child.list {
+		f.newline(child.openIndent)
+	}
+	f.write(")", false)
+}
+
+// spaceBefore reports whether a space separates the token from the previous one
+func (f *formatter) spaceBefore(tok sqlparser.Token) bool {
+	if f.prev == nil || f.unary {
+		return false
+	}
+	if f.prev.IsPunct("(") || f.prev.IsPunct(".") {
+		return false
+	}
+	if tok.Kind == sqlparser.TokenOperator && tok.Text == "::" {
+		return false
+	}
+	if f.prev.Kind == sqlparser.TokenOperator && f.prev.Text == "::" {
+		return false
+	}
+	return true
+}
+
+// spaceBeforeParen separates a parenthesis from keywords and operators but
+// not from the function or type name it belongs to
+func (f *formatter) spaceBeforeParen() bool {
+	if f.prev == nil {
+		return false
+	}
+	switch {
+	case f.prev.IsPunct("("), f.prev.IsPunct("."):
+		return false
+	case f.prev.Kind == sqlparser.TokenOperator && f.prev.Text == "::":
+		return false
+	case f.prev.Kind == sqlparser.TokenWord:
+		return f.target || spacedParenKeywords[f.prevWord]
+	case f.prev.Kind == sqlparser.TokenQuotedIdent:
+		return f.target
+	}
+	return !f.unary
+}
+
+func nextSignificant(tokens []sqlparser.Token, i int) *sqlparser.Token {
+	for ; i < len(tokens); i++ {
+		if tokens[i].Kind != sqlparser.TokenComment {
+			return &tokens[i]
+		}
+	}
+	return nil
+}
+
+// isCreateTable reports whether the parenthesis at tokens[open] follows the
+// table name of a CREATE TABLE statement
+func isCreateTable(tokens []sqlparser.Token, open int) bool {
+	for j := open - 1; j >= 0; j-- {
+		tok := tokens[j]
+		if tok.IsPunct(";") {
+			return false
+		}
+		if tok.IsKeyword("TABLE") {
+			for k := j - 1; k >= 0 && !tokens[k].IsPunct(";"); k-- {
+				if tokens[k].IsKeyword("CREATE") {
+					return true
+				}
+			}
+			return false
+		}
+	}
+	return false
+}
@@ -0,0 +1,71 @@
+package sqlformat
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ephy-lab/ai-db-assistant/pkg/sqlparser"
+)
+
+func TestFormatKeepsExecutableComments(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   string
+		comment string
+	}{
+		{"select list", "select 1 /*!50000 , 2 */ from t", "/*!50000 , 2 */"},
+		{"whole statement", "/*!40101 set names utf8 */", "/*!40101 set names utf8 */"},
+		{"mariadb", "select a /*M!100100 , b */ from t", "/*M!100100 , b */"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Format(tt.query, DefaultOptions(sqlparser.DialectMySQL))
+			if !strings.Contains(got, tt.comment) {
+				t.Errorf("Format(%q) = %q, want it to contain %q", tt.query, got, tt.comment)
+			}
+		})
+	}
+}
+
+func TestFormatKeywordCase(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		want  string
+	}{
+		{
+			"column named like a type",
+			"select date, key from logs where date > now() order by date desc",
+			"SELECT\n  date,\n  key\nFROM\n  logs\nWHERE\n  date > now()\nORDER BY\n  date DESC",
+		},
+		{
+			"types and typed literals",
+			"select cast(date as date), x::timestamp, interval '1 day' from t",
+			"SELECT\n  CAST(date AS DATE),\n  x::TIMESTAMP,\n  INTERVAL '1 day'\nFROM\n  t",
+		},
+		{
+			"column definitions",
+			"create table t (id serial primary key, date date not null, text text)",
+			"CREATE TABLE t (\n  id SERIAL PRIMARY KEY,\n  date DATE NOT NULL,\n  text TEXT\n)",
+		},
+		{
+			"qualified names",
+			"select t.offset, t.date from t",
+			"SELECT\n  t.offset,\n  t.date\nFROM\n  t",
+		},
+		{
+			"keywords next to keywords",
+			"select x from t order by x nulls last",
+			"SELECT\n  x\nFROM\n  t\nORDER BY\n  x NULLS LAST",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Format(tt.query, DefaultOptions(sqlparser.DialectPostgres)); got != tt.want {
+				t.Errorf("Format(%q) =\n%s\nwant\n%s", tt.query, got, tt.want)
+			}
+		})
+	}
+}
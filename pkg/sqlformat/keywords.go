@@ -0,0 +1,72 @@
+// pkg/sqlformat/keywords.go
+package sqlformat
+
+// keywords are the words whose case is normalised when they are used as
+// keywords. Function names and identifiers are left as written.
+var keywords = map[string]bool{}
+
+// unreservedKeywords can also be used as column or table names, e.g. a
+// column named date, so their case is only changed in keyword position
+var unreservedKeywords = map[string]bool{}
+
+// operandKeywords are followed by an operand or a name
+var operandKeywords = map[string]bool{
+	"SELECT": true, "DISTINCT": true, "FROM": true, "JOIN": true, "WHERE": true, "BY": true, "SET": true,
+	"ON": true, "AND": true, "OR": true, "NOT": true, "HAVING": true, "RETURNING": true, "WHEN": true,
+	"THEN": true, "ELSE": true, "CASE": true, "AS": true, "LIKE": true, "COLUMN": true,
+}
+
+// postOperandKeywords can follow an operand or a name
+var postOperandKeywords = map[string]bool{
+	"FROM": true, "AS": true, "ASC": true, "DESC": true, "AND": true, "OR": true, "IS": true, "IN": true,
+	"NOT": true, "LIKE": true, "ILIKE": true, "SIMILAR": true, "REGEXP": true, "BETWEEN": true,
+	"ESCAPE": true, "COLLATE": true, "THEN": true, "WHEN": true, "ELSE": true, "END": true, "ON": true,
+	"USING": true, "JOIN": true, "INNER": true, "LEFT": true, "RIGHT": true, "FULL": true, "CROSS": true,
+	"NATURAL": true, "WHERE": true, "GROUP": true, "ORDER": true, "HAVING": true, "LIMIT": true,
+	"OFFSET": true, "FETCH": true, "UNION": true, "INTERSECT": true, "EXCEPT": true, "MINUS": true,
+	"WINDOW": true, "RETURNING": true, "FOR": true, "INTO": true, "SET": true, "VALUES": true,
+	"NULLS": true, "TO": true,
+}
+
+func init() {
+	for _, word := range []string{
+		"ADD", "ALL", "ALTER", "ANALYZE", "AND", "ANY", "AS", "ASC", "AUTO_INCREMENT", "BEGIN", "BETWEEN",
+		"BIGINT", "BIGSERIAL", "BINARY", "BOOLEAN", "BOTH", "BY", "CALL", "CASCADE", "CASE", "CAST", "CHAR",
+		"CHARACTER", "CHECK", "COLLATE", "COLUMN", "COMMENT", "COMMIT", "CONCURRENTLY", "CONFLICT",
+		"CONSTRAINT", "COPY", "CREATE", "CROSS", "CURRENT_DATE", "CURRENT_TIME", "CURRENT_TIMESTAMP",
+		"CURRENT_USER", "DATABASE", "DATE", "DATETIME", "DECIMAL", "DEFAULT", "DELETE", "DESC", "DESCRIBE",
+		"DISTINCT", "DO", "DOUBLE", "DROP", "DUPLICATE", "ELSE", "END", "ENGINE", "ESCAPE", "EXCEPT",
+		"EXISTS", "EXPLAIN", "FALSE", "FETCH", "FILTER", "FIRST", "FLOAT", "FOLLOWING", "FOR", "FOREIGN",
+		"FROM", "FULL", "FUNCTION", "GRANT", "GROUP", "HAVING", "IF", "IGNORE", "ILIKE", "IN", "INDEX",
+		"INNER", "INSERT", "INT", "INTEGER", "INTERSECT", "INTERVAL", "INTO", "IS", "JOIN", "JSON", "JSONB",
+		"KEY", "LAST", "LATERAL", "LEADING", "LEFT", "LIKE", "LIMIT", "LOCK", "MATCHED", "MATERIALIZED",
+		"MERGE", "MINUS", "NATURAL", "NEXT", "NO", "NOT", "NOTHING", "NULL", "NULLS", "NUMERIC", "OF",
+		"OFFSET", "ON", "ONLY", "OR", "ORDER", "OUTER", "OVER", "PARTITION", "PRECEDING", "PRIMARY",
+		"PROCEDURE", "QUALIFY", "RANGE", "REAL", "RECURSIVE", "REFERENCES", "REGEXP", "RENAME", "REPLACE",
+		"RESTRICT", "RETURNING", "REVOKE", "RIGHT", "ROLLBACK", "ROW", "ROWS", "SCHEMA", "SELECT", "SERIAL",
+		"SESSION", "SET", "SHARE", "SHOW", "SIMILAR", "SKIP", "SMALLINT", "SOME", "STRAIGHT_JOIN", "TABLE",
+		"TEMP", "TEMPORARY", "TEXT", "THEN", "TIES", "TIME", "TIMESTAMP", "TIMESTAMPTZ", "TO", "TOP",
+		"TRAILING", "TRANSACTION", "TRIGGER", "TRUE", "TRUNCATE", "UNBOUNDED", "UNION", "UNIQUE", "UNSIGNED",
+		"UPDATE", "USING", "UUID", "VALUES", "VARCHAR", "VIEW", "WHEN", "WHERE", "WINDOW", "WITH", "WITHIN",
+		"WITHOUT", "ZONE",
+	} {
+		keywords[word] = true
+	}
+	for _, word := range []string{
+		"BEGIN", "BIGINT", "BIGSERIAL", "BOOLEAN", "CASCADE", "CHAR", "CHARACTER", "COMMENT", "COMMIT",
+		"CONFLICT", "COPY", "DATABASE", "DATE", "DATETIME", "DECIMAL", "DESCRIBE", "DOUBLE", "DUPLICATE",
+		"ENGINE", "ESCAPE", "EXPLAIN", "FILTER", "FIRST", "FLOAT", "FOLLOWING", "FUNCTION", "IF", "IGNORE",
+		"INDEX", "INT", "INTEGER", "INTERVAL", "JSON", "JSONB", "KEY", "LAST", "LOCK", "MATCHED",
+		"MATERIALIZED", "MERGE", "MINUS", "NEXT", "NO", "NOTHING", "NULLS", "NUMERIC", "OVER", "PARTITION",
+		"PRECEDING", "PROCEDURE", "QUALIFY", "RANGE", "REAL", "RECURSIVE", "RENAME", "REPLACE", "RESTRICT",
+		"REVOKE", "ROLLBACK", "ROW", "ROWS", "SCHEMA", "SERIAL", "SESSION", "SHARE", "SHOW", "SKIP",
+		"SMALLINT", "TEMP", "TEMPORARY", "TEXT", "TIES", "TIME", "TIMESTAMP", "TIMESTAMPTZ", "TRANSACTION",
+		"TRIGGER", "TRUNCATE", "UNBOUNDED", "UNSIGNED", "UUID", "VARCHAR", "VIEW", "WITHIN", "WITHOUT", "ZONE",
+	} {
+		unreservedKeywords[word] = true
+	}
+}
+
+func isKeyword(upper string) bool {
+	return keywords[upper]
+}
@@ -0,0 +1,266 @@
+// pkg/sqllint/lint.go
+package sqllint
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/ephy-lab/ai-db-assistant/pkg/sqlparser"
+)
+
+// Severity ranks how likely a warning is to indicate a real problem
+type Severity string
+
+const (
+	SeverityInfo    Severity = "info"
+	SeverityWarning Severity = "warning"
+	SeverityError   Severity = "error"
+)
+
+// Rule identifiers
+const (
+	RuleSelectStarWithoutLimit = "select-star-without-limit"
+	RuleMissingWhere           = "missing-where"
+	RuleImplicitCrossJoin      = "implicit-cross-join"
+	RuleFunctionOnColumn       = "function-on-column"
+	RuleNotInSubquery          = "not-in-subquery"
+)
+
+// Warning is a single lint finding. Position is the byte offset of the
+// offending token in the query; Line and Column are 1-based.
+type Warning struct {
+	Rule     string   `json:"rule"`
+	Severity Severity `json:"severity"`
+	Message  string   `json:"message"`
+	Position int      `json:"position"`
+	Line     int      `json:"line"`
+	Column   int      `json:"column"`
+}
+
+// comparisonOperators follow an expression that is compared in a predicate
+var comparisonOperators = map[string]bool{
+	"=": true, "<": true, ">": true, "<=": true, ">=": true, "<>": true, "!=": true, "<=>": true,
+}
+
+// nonColumnFunctions take no column or are not applied to one in a way that
+// defeats an index
+var nonColumnFunctions = map[string]bool{
+	"NOW": true, "CURRENT_DATE": true, "CURRENT_TIMESTAMP": true, "COALESCE": true, "EXISTS": true,
+	"IN": true, "ANY": true, "ALL": true, "SOME": true, "NOT": true, "AND": true, "OR": true,
+}
+
+// frame tracks one query level: the whole statement or a parenthesised subquery
+type frame struct {
+	query    bool
+	clause   string
+	star     *sqlparser.Token
+	hasLimit bool
+}
+
+type linter struct {
+	query    string
+	tokens   []sqlparser.Token
+	warnings []Warning
+}
+
+// Lint checks the query for common mistakes and risky patterns. It never
+// fails: unparseable input simply produces fewer warnings.
+func Lint(query string, dialect sqlparser.Dialect) []Warning {
+	l := &linter{query: query}
+	for _, tok := range sqlparser.Tokenize(query, dialect) {
+		if tok.Kind != sqlparser.TokenComment {
+			l.tokens = append(l.tokens, tok)
+		}
+	}
+
+	l.checkMissingWhere(dialect)
+	l.walk()
+
+	if l.warnings == nil {
+		return []Warning{}
+	}
+	sort.SliceStable(l.warnings, func(i, j int) bool {
+		return l.warnings[i].Position < l.warnings[j].Position
+	})
+	return l.warnings
+}
+
+func (l *linter) add(rule string, severity Severity, message string, pos int) {
+	line, column := 1, 1
+	for _, c := range l.query[:pos] {
+		if c == '\n' {
+			line++
+			column = 1
+		} else {
+			column++
+		}
+	}
+	l.warnings = append(l.warnings, Warning{
+		Rule:     rule,
+		Severity: severity,
+		Message:  message,
+		Position: pos,
+		Line:     line,
+		Column:   column,
+	})
+}
+
+// checkMissingWhere flags UPDATE and DELETE statements that would touch
+// every row, including those nested in CTEs
+func (l *linter) checkMissingWhere(dialect sqlparser.Dialect) {
+	for _, statement := range sqlparser.SplitStatements(l.query, dialect) {
+		if !sqlparser.ModifiesRows(statement.Text, dialect) {
+			continue
+		}
+		if !sqlparser.HasWhereClause(statement.Text, dialect) {
+			kind := string(statement.Type)
+			if statement.Type != sqlparser.QueryTypeUpdate && statement.Type != sqlparser.QueryTypeDelete {
+				kind = "UPDATE or DELETE"
+			}
+			l.add(RuleMissingWhere, SeverityError, kind+" without a WHERE clause affects every row in the table", statement.Start)
+		}
+	}
+}
+
+// walk runs the token-level rules
+func (l *linter) walk() {
+	frames := []*frame{{query: true}}
+
+	for i, tok := range l.tokens {
+		f := frames[len(frames)-1]
+
+		switch {
+		case tok.IsPunct(";"):
+			l.finishStatement(frames[0])
+			frames = []*frame{{query: true}}
+
+		case tok.IsPunct("("):
+			next := l.at(i + 1)
+			frames = append(frames, &frame{query: next.IsKeyword("SELECT", "WITH", "VALUES"), clause: f.clause})
+
+		case tok.IsPunct(")"):
+			if len(frames) > 1 {
+				frames = frames[:len(frames)-1]
+			}
+
+		case tok.IsPunct(","):
+			if f.query && f.clause == "FROM" {
+				l.add(RuleImplicitCrossJoin, SeverityWarning, "Comma-separated tables form an implicit cross join; use an explicit JOIN ... ON", tok.Pos)
+			}
+
+		case tok.Kind == sqlparser.TokenOperator && tok.Text == "*":
+			prev := l.at(i - 1)
+			if f.query && f.clause == "SELECT" && (prev.IsKeyword("SELECT", "DISTINCT", "ALL") || prev.IsPunct(",") || prev.IsPunct(".")) && f.star == nil {
+				t := tok
+				f.star = &t
+			}
+
+		case tok.Kind == sqlparser.TokenWord:
+			l.word(frames, i)
+		}
+	}
+
+	l.finishStatement(frames[0])
+}
+
+func (l *linter) word(frames []*frame, i int) {
+	tok := l.tokens[i]
+	f := frames[len(frames)-1]
+	next := l.at(i + 1)
+
+	switch tok.Upper() {
+	case "SELECT", "WHERE", "HAVING", "ON":
+		if f.query || tok.IsKeyword("ON") {
+			f.clause = tok.Upper()
+		}
+	case "FROM":
+		// FROM inside EXTRACT(... FROM ...) or SUBSTRING(... FROM ...) is not a clause
+		if f.query {
+			f.clause = "FROM"
+		}
+	case "JOIN", "GROUP", "ORDER", "WINDOW", "UNION", "INTERSECT", "EXCEPT", "RETURNING", "SET", "VALUES":
+		if f.query {
+			f.clause = tok.Upper()
+		}
+	case "LIMIT", "FETCH", "TOP":
+		if f.query {
+			f.hasLimit = true
+			f.clause = tok.Upper()
+		}
+	case "NOT":
+		if next.IsKeyword("IN") && l.at(i+2).IsPunct("(") && l.at(i+3).IsKeyword("SELECT", "WITH") {
+			l.add(RuleNotInSubquery, SeverityWarning, "NOT IN with a subquery returns no rows when the subquery yields a NULL; use NOT EXISTS instead", tok.Pos)
+		}
+	}
+
+	// A function applied to a column on the left of a comparison prevents
+	// the use of an index on that column
+	if next.IsPunct("(") && (f.clause == "WHERE" || f.clause == "ON") && !nonColumnFunctions[tok.Upper()] {
+		if end, hasColumn := l.callEnd(i + 1); hasColumn && l.isComparison(end+1) && !l.at(i-1).IsPunct(".") {
+			l.add(RuleFunctionOnColumn, SeverityWarning, "Function "+tok.Text+"() applied to a column in a predicate may prevent index use", tok.Pos)
+		}
+	}
+}
+
+// finishStatement reports an unbounded SELECT * in the outermost query
+func (l *linter) finishStatement(f *frame) {
+	if f.star != nil && !f.hasLimit {
+		l.add(RuleSelectStarWithoutLimit, SeverityWarning, "SELECT * without LIMIT may return a very large result set", f.star.Pos)
+	}
+}
+
+// callEnd returns the index of the parenthesis closing the call that opens at
+// tokens[open] and whether its arguments reference a column
+func (l *linter) callEnd(open int) (int, bool) {
+	depth := 0
+	hasColumn := false
+	for j := open; j < len(l.tokens); j++ {
+		tok := l.tokens[j]
+		switch {
+		case tok.IsPunct("("):
+			depth++
+		case tok.IsPunct(")"):
+			depth--
+			if depth == 0 {
+				return j, hasColumn
+			}
+		case tok.IsKeyword("SELECT"):
+			return j, false
+		case tok.Kind == sqlparser.TokenQuotedIdent:
+			hasColumn = true
+		case tok.Kind == sqlparser.TokenWord && !l.at(j+1).IsPunct("(") && !isLiteralKeyword(tok):
+			hasColumn = true
+		}
+	}
+	return len(l.tokens), false
+}
+
+func (l *linter) isComparison(i int) bool {
+	tok := l.at(i)
+	if tok.Kind == sqlparser.TokenOperator && comparisonOperators[tok.Text] {
+		return true
+	}
+	if tok.IsKeyword("NOT") {
+		tok = l.at(i + 1)
+	}
+	return tok.IsKeyword("LIKE", "ILIKE", "IN", "BETWEEN", "IS")
+}
+
+func (l *linter) at(i int) sqlparser.Token {
+	if i >= 0 && i < len(l.tokens) {
+		return l.tokens[i]
+	}
+	return sqlparser.Token{Kind: sqlparser.TokenPunct}
+}
+
+// isLiteralKeyword reports words inside a call that are not column names,
+// e.g. the AS and type of CAST(x AS int) or the unit of an INTERVAL
+func isLiteralKeyword(tok sqlparser.Token) bool {
+	switch strings.ToUpper(tok.Text) {
+	case "AS", "NULL", "TRUE", "FALSE", "INTERVAL", "DISTINCT", "FROM", "FOR", "YEAR", "MONTH", "DAY",
+		"HOUR", "MINUTE", "SECOND", "EPOCH", "DOW", "WEEK", "QUARTER", "BOTH", "LEADING", "TRAILING",
+		"CURRENT_DATE", "CURRENT_TIMESTAMP":
+		return true
+	}
+	return false
+}
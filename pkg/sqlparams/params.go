@@ -0,0 +1,282 @@
+// pkg/sqlparams/params.go
+package sqlparams
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ephy-lab/ai-db-assistant/pkg/sqlparser"
+)
+
+// Type is the declared type of a parameter value
+type Type string
+
+const (
+	TypeString    Type = "string"
+	TypeInt       Type = "int"
+	TypeFloat     Type = "float"
+	TypeBool      Type = "bool"
+	TypeNull      Type = "null"
+	TypeDate      Type = "date"      // YYYY-MM-DD
+	TypeTimestamp Type = "timestamp" // RFC 3339
+	TypeJSON      Type = "json"
+	TypeUUID      Type = "uuid"
+)
+
+// Param is a typed parameter value as supplied by the caller
+type Param struct {
+	Type  Type            `json:"type"`
+	Value json.RawMessage `json:"value"`
+}
+
+// Arg is a validated parameter value in placeholder order. Value holds a
+// string, int64, float64, bool or nil; dates, timestamps, JSON documents and
+// UUIDs are carried as normalised strings.
+type Arg struct {
+	Type  Type `json:"type"`
+	Value any  `json:"value"`
+}
+
+// Bound is a query whose placeholders have been matched with their values
+type Bound struct {
+	Query string // query rewritten to the dialect's positional placeholders
+	Args  []Arg
+}
+
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// Bind validates params against the placeholders in query and returns the
+// query with its arguments in positional order. Values are never
+// interpolated into the SQL text.
+//
+// Named placeholders (:name) are looked up by name and rewritten to $n for
+// PostgreSQL or ? for MySQL. Positional placeholders are looked up by their
+// 1-based position: $n in PostgreSQL, ? in order of appearance in MySQL.
+// Named and positional placeholders cannot be mixed, and every parameter
+// must be used.
+func Bind(query string, dialect sqlparser.Dialect, params map[string]Param) (*Bound, error) {
+	var placeholders []sqlparser.Token
+	for _, tok := range sqlparser.Tokenize(query, dialect) {
+		if tok.Kind == sqlparser.TokenPlaceholder {
+			placeholders = append(placeholders, tok)
+		}
+	}
+
+	if len(placeholders) == 0 {
+		if len(params) > 0 {
+			return nil, fmt.Errorf("query has no placeholders but %d parameters were given", len(params))
+		}
+		return &Bound{Query: query}, nil
+	}
+
+	named, positional := 0, 0
+	for _, tok := range placeholders {
+		if strings.HasPrefix(tok.Text, ":") {
+			named++
+		} else {
+			positional++
+		}
+	}
+	if named > 0 && positional > 0 {
+		return nil, fmt.Errorf("named and positional placeholders cannot be mixed")
+	}
+
+	values := make(map[string]Arg, len(params))
+	for key, param := range params {
+		arg, err := convert(param)
+		if err != nil {
+			return nil, fmt.Errorf("parameter %q: %w", key, err)
+		}
+		values[key] = arg
+	}
+
+	used := map[string]bool{}
+	var bound *Bound
+	var err error
+	if named > 0 {
+		bound, err = bindNamed(query, dialect, placeholders, values, used)
+	} else {
+		bound, err = bindPositional(query, placeholders, values, used)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var unused []string
+	for key := range params {
+		if !used[key] {
+			unused = append(unused, key)
+		}
+	}
+	if len(unused) > 0 {
+		sort.Strings(unused)
+		return nil, fmt.Errorf("unused parameters: %s", strings.Join(unused, ", "))
+	}
+
+	return bound, nil
+}
+
+func bindNamed(query string, dialect sqlparser.Dialect, placeholders []sqlparser.Token, values map[string]Arg, used map[string]bool) (*Bound, error) {
+	var out strings.Builder
+	var args []Arg
+	positions := map[string]int{}
+	last := 0
+
+	for _, tok := range placeholders {
+		name := tok.Text[1:]
+		arg, ok := values[name]
+		if !ok {
+			return nil, fmt.Errorf("missing parameter %q", name)
+		}
+		used[name] = true
+
+		out.WriteString(query[last:tok.Pos])
+		if dialect == sqlparser.DialectPostgres {
+			// PostgreSQL can refer to the same argument several times
+			position, seen := positions[name]
+			if !seen {
+				args = append(args, arg)
+				position = len(args)
+				positions[name] = position
+			}
+			out.WriteString("$" + strconv.Itoa(position))
+		} else {
+			args = append(args, arg)
+			out.WriteString("?")
+		}
+		last = tok.End
+	}
+	out.WriteString(query[last:])
+
+	return &Bound{Query: out.String(), Args: args}, nil
+}
+
+func bindPositional(query string, placeholders []sqlparser.Token, values map[string]Arg, used map[string]bool) (*Bound, error) {
+	count := 0
+	for i, tok := range placeholders {
+		position := i + 1
+		if strings.HasPrefix(tok.Text, "$") {
+			n, err := strconv.Atoi(tok.Text[1:])
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("invalid placeholder %s", tok.Text)
+			}
+			position = n
+		}
+		if position > count {
+			count = position
+		}
+	}
+
+	args := make([]Arg, count)
+	for position := 1; position <= count; position++ {
+		key := strconv.Itoa(position)
+		arg, ok := values[key]
+		if !ok {
+			return nil, fmt.Errorf("missing parameter %s", key)
+		}
+		used[key] = true
+		args[position-1] = arg
+	}
+
+	return &Bound{Query: query, Args: args}, nil
+}
+
+// convert checks that the raw value matches the declared type
+func convert(param Param) (Arg, error) {
+	raw := bytes.TrimSpace(param.Value)
+	isNull := len(raw) == 0 || bytes.Equal(raw, []byte("null"))
+
+	if param.Type == TypeNull {
+		if !isNull {
+			return Arg{}, fmt.Errorf("null parameter must not have a value")
+		}
+		return Arg{Type: TypeNull}, nil
+	}
+	if isNull {
+		// Typed NULLs keep their type so the database can infer the column type
+		return Arg{Type: param.Type}, validType(param.Type)
+	}
+
+	arg := Arg{Type: param.Type}
+	switch param.Type {
+	case TypeString:
+		var s string
+		if err := json.Unmarshal(raw, &s); err != nil {
+			return Arg{}, fmt.Errorf("expected a string")
+		}
+		arg.Value = s
+	case TypeInt:
+		var n int64
+		if err := json.Unmarshal(raw, &n); err != nil {
+			return Arg{}, fmt.Errorf("expected an integer")
+		}
+		arg.Value = n
+	case TypeFloat:
+		var f float64
+		if err := json.Unmarshal(raw, &f); err != nil {
+			return Arg{}, fmt.Errorf("expected a number")
+		}
+		arg.Value = f
+	case TypeBool:
+		var b bool
+		if err := json.Unmarshal(raw, &b); err != nil {
+			return Arg{}, fmt.Errorf("expected a boolean")
+		}
+		arg.Value = b
+	case TypeDate:
+		var s string
+		if err := json.Unmarshal(raw, &s); err != nil {
+			return Arg{}, fmt.Errorf("expected a date string")
+		}
+		d, err := time.Parse("2006-01-02", s)
+		if err != nil {
+			return Arg{}, fmt.Errorf("expected a date in YYYY-MM-DD format")
+		}
+		arg.Value = d.Format("2006-01-02")
+	case TypeTimestamp:
+		var s string
+		if err := json.Unmarshal(raw, &s); err != nil {
+			return Arg{}, fmt.Errorf("expected a timestamp string")
+		}
+		t, err := time.Parse(time.RFC3339Nano, s)
+		if err != nil {
+			return Arg{}, fmt.Errorf("expected an RFC 3339 timestamp")
+		}
+		arg.Value = t.Format(time.RFC3339Nano)
+	case TypeJSON:
+		if !json.Valid(raw) {
+			return Arg{}, fmt.Errorf("expected a JSON value")
+		}
+		var compact bytes.Buffer
+		if err := json.Compact(&compact, raw); err != nil {
+			return Arg{}, fmt.Errorf("expected a JSON value")
+		}
+		arg.Value = compact.String()
+	case TypeUUID:
+		var s string
+		if err := json.Unmarshal(raw, &s); err != nil || !uuidPattern.MatchString(s) {
+			return Arg{}, fmt.Errorf("expected a UUID")
+		}
+		arg.Value = strings.ToLower(s)
+	default:
+		return Arg{}, validType(param.Type)
+	}
+
+	return arg, nil
+}
+
+func validType(t Type) error {
+	switch t {
+	case TypeString, TypeInt, TypeFloat, TypeBool, TypeNull, TypeDate, TypeTimestamp, TypeJSON, TypeUUID:
+		return nil
+	case "":
+		return fmt.Errorf("type is required")
+	}
+	return fmt.Errorf("unsupported type %q", t)
+}
@@ -0,0 +1,154 @@
+package sqlparams
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ephy-lab/ai-db-assistant/pkg/sqlparser"
+)
+
+func param(t Type, value string) Param {
+	return Param{Type: t, Value: json.RawMessage(value)}
+}
+
+func TestBind(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   string
+		dialect sqlparser.Dialect
+		params  map[string]Param
+		want    string
+		args    []Arg
+		err     string
+	}{
+		{
+			name: "named postgres", query: "SELECT * FROM t WHERE a = :a AND b = :b OR a = :a", dialect: sqlparser.DialectPostgres,
+			params: map[string]Param{"a": param(TypeInt, "1"), "b": param(TypeString, `"x"`)},
+			want:   "SELECT * FROM t WHERE a = $1 AND b = $2 OR a = $1",
+			args:   []Arg{{Type: TypeInt, Value: int64(1)}, {Type: TypeString, Value: "x"}},
+		},
+		{
+			name: "named mysql repeats values", query: "SELECT * FROM t WHERE a = :a OR b = :a", dialect: sqlparser.DialectMySQL,
+			params: map[string]Param{"a": param(TypeBool, "true")},
+			want:   "SELECT * FROM t WHERE a = ? OR b = ?",
+			args:   []Arg{{Type: TypeBool, Value: true}, {Type: TypeBool, Value: true}},
+		},
+		{
+			name: "positional postgres", query: "SELECT * FROM t WHERE a = $2 AND b = $1", dialect: sqlparser.DialectPostgres,
+			params: map[string]Param{"1": param(TypeString, `"x"`), "2": param(TypeFloat, "1.5")},
+			want:   "SELECT * FROM t WHERE a = $2 AND b = $1",
+			args:   []Arg{{Type: TypeString, Value: "x"}, {Type: TypeFloat, Value: 1.5}},
+		},
+		{
+			name: "positional mysql", query: "SELECT * FROM t WHERE a = ? AND b = ?", dialect: sqlparser.DialectMySQL,
+			params: map[string]Param{"1": param(TypeNull, "null"), "2": param(TypeDate, `"2025-01-02"`)},
+			want:   "SELECT * FROM t WHERE a = ? AND b = ?",
+			args:   []Arg{{Type: TypeNull}, {Type: TypeDate, Value: "2025-01-02"}},
+		},
+		{
+			name: "placeholders in strings and comments are ignored", query: "SELECT ':a', '?' /* :b */ FROM t WHERE a = :a", dialect: sqlparser.DialectPostgres,
+			params: map[string]Param{"a": param(TypeInt, "1")},
+			want:   "SELECT ':a', '?' /* :b */ FROM t WHERE a = $1",
+			args:   []Arg{{Type: TypeInt, Value: int64(1)}},
+		},
+		{
+			name: "cast is not a placeholder", query: "SELECT :a::int", dialect: sqlparser.DialectPostgres,
+			params: map[string]Param{"a": param(TypeString, `"1"`)},
+			want:   "SELECT $1::int",
+			args:   []Arg{{Type: TypeString, Value: "1"}},
+		},
+		{
+			name: "no placeholders", query: "SELECT 1", dialect: sqlparser.DialectPostgres,
+			want: "SELECT 1",
+		},
+		{
+			name: "parameters without placeholders", query: "SELECT 1", dialect: sqlparser.DialectPostgres,
+			params: map[string]Param{"a": param(TypeInt, "1")},
+			err:    "no placeholders",
+		},
+		{
+			name: "named and positional mixed", query: "SELECT * FROM t WHERE a = :a AND b = $1", dialect: sqlparser.DialectPostgres,
+			params: map[string]Param{"a": param(TypeInt, "1"), "1": param(TypeInt, "2")},
+			err:    "cannot be mixed",
+		},
+		{
+			name: "positional values for named placeholders", query: "SELECT * FROM t WHERE a = :a", dialect: sqlparser.DialectPostgres,
+			params: map[string]Param{"1": param(TypeInt, "1")},
+			err:    `missing parameter "a"`,
+		},
+		{
+			name: "named values for positional placeholders", query: "SELECT * FROM t WHERE a = ?", dialect: sqlparser.DialectMySQL,
+			params: map[string]Param{"a": param(TypeInt, "1")},
+			err:    "missing parameter 1",
+		},
+		{
+			name: "gap in positions", query: "SELECT * FROM t WHERE a = $2", dialect: sqlparser.DialectPostgres,
+			params: map[string]Param{"2": param(TypeInt, "1")},
+			err:    "missing parameter 1",
+		},
+		{
+			name: "unused parameter", query: "SELECT * FROM t WHERE a = :a", dialect: sqlparser.DialectPostgres,
+			params: map[string]Param{"a": param(TypeInt, "1"), "b": param(TypeInt, "2")},
+			err:    "unused parameters: b",
+		},
+		{
+			name: "wrong type", query: "SELECT * FROM t WHERE a = :a", dialect: sqlparser.DialectPostgres,
+			params: map[string]Param{"a": param(TypeInt, `"1"`)},
+			err:    `parameter "a": expected an integer`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bound, err := Bind(tt.query, tt.dialect, tt.params)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("Bind(%q) error = %v, want %q", tt.query, err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Bind(%q) error: %v", tt.query, err)
+			}
+			if bound.Query != tt.want {
+				t.Errorf("query = %q, want %q", bound.Query, tt.want)
+			}
+			if !reflect.DeepEqual(bound.Args, tt.args) {
+				t.Errorf("args = %+v, want %+v", bound.Args, tt.args)
+			}
+		})
+	}
+}
+
+func TestConvert(t *testing.T) {
+	tests := []struct {
+		name  string
+		param Param
+		want  any
+		err   bool
+	}{
+		{"timestamp is normalised", param(TypeTimestamp, `"2025-01-02T03:04:05Z"`), "2025-01-02T03:04:05Z", false},
+		{"json is compacted", param(TypeJSON, `{ "a": [1, 2] }`), `{"a":[1,2]}`, false},
+		{"uuid is lower-cased", param(TypeUUID, `"6F9619FF-8B86-D011-B42D-00C04FC964FF"`), "6f9619ff-8b86-d011-b42d-00c04fc964ff", false},
+		{"typed null", param(TypeInt, "null"), nil, false},
+		{"bad date", param(TypeDate, `"02/01/2025"`), nil, true},
+		{"bad uuid", param(TypeUUID, `"not-a-uuid"`), nil, true},
+		{"null with value", param(TypeNull, "1"), nil, true},
+		{"missing type", param("", "1"), nil, true},
+		{"unsupported type", param("blob", "1"), nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			arg, err := convert(tt.param)
+			if (err != nil) != tt.err {
+				t.Fatalf("convert(%+v) error = %v, want error %v", tt.param, err, tt.err)
+			}
+			if err == nil && arg.Value != tt.want {
+				t.Errorf("convert(%+v) = %v, want %v", tt.param, arg.Value, tt.want)
+			}
+		})
+	}
+}
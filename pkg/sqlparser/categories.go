@@ -0,0 +1,309 @@
+// pkg/sqlparser/categories.go
+package sqlparser
+
+// commonKeywords maps leading keywords shared by MySQL and PostgreSQL to their
+// statement type. Keywords whose meaning depends on what follows are handled
+// in statementType.
+var commonKeywords = map[string]QueryType{
+	"SELECT":    QueryTypeSelect,
+	"VALUES":    QueryTypeSelect,
+	"TABLE":     QueryTypeSelect,
+	"SHOW":      QueryTypeSelect,
+	"EXPLAIN":   QueryTypeSelect,
+	"INSERT":    QueryTypeInsert,
+	"UPDATE":    QueryTypeUpdate,
+	"DELETE":    QueryTypeDelete,
+	"CREATE":    QueryTypeDDL,
+	"DROP":      QueryTypeDDL,
+	"ALTER":     QueryTypeDDL,
+	"TRUNCATE":  QueryTypeDDL,
+	"GRANT":     QueryTypeDCL,
+	"REVOKE":    QueryTypeDCL,
+	"BEGIN":     QueryTypeTCL,
+	"COMMIT":    QueryTypeTCL,
+	"ROLLBACK":  QueryTypeTCL,
+	"SAVEPOINT": QueryTypeTCL,
+	"RELEASE":   QueryTypeTCL,
+	"CALL":      QueryTypeCall,
+	"DO":        QueryTypeCall,
+	"PREPARE":   QueryTypeCall,
+	"EXECUTE":   QueryTypeCall,
+	"ANALYZE":   QueryTypeAdmin,
+	"LOCK":      QueryTypeAdmin,
+}
+
+// mysqlKeywords are leading keywords specific to MySQL
+var mysqlKeywords = map[string]QueryType{
+	"DESCRIBE":   QueryTypeSelect,
+	"DESC":       QueryTypeSelect,
+	"HANDLER":    QueryTypeSelect,
+	"RENAME":     QueryTypeDDL,
+	"XA":         QueryTypeTCL,
+	"DEALLOCATE": QueryTypeCall,
+	"UNLOCK":     QueryTypeAdmin,
+	"USE":        QueryTypeAdmin,
+	"OPTIMIZE":   QueryTypeAdmin,
+	"CHECK":      QueryTypeAdmin,
+	"CHECKSUM":   QueryTypeAdmin,
+	"REPAIR":     QueryTypeAdmin,
+	"FLUSH":      QueryTypeAdmin,
+	"RESET":      QueryTypeAdmin,
+	"PURGE":      QueryTypeAdmin,
+	"KILL":       QueryTypeAdmin,
+	"INSTALL":    QueryTypeAdmin,
+	"UNINSTALL":  QueryTypeAdmin,
+	"SHUTDOWN":   QueryTypeAdmin,
+	"RESTART":    QueryTypeAdmin,
+	"CACHE":      QueryTypeAdmin,
+	"BINLOG":     QueryTypeAdmin,
+	"CHANGE":     QueryTypeAdmin,
+	"STOP":       QueryTypeAdmin,
+	"IMPORT":     QueryTypeBulkLoad,
+}
+
+// postgresKeywords are leading keywords specific to PostgreSQL
+var postgresKeywords = map[string]QueryType{
+	"MERGE":      QueryTypeUpdate,
+	"COMMENT":    QueryTypeDDL,
+	"IMPORT":     QueryTypeDDL, // IMPORT FOREIGN SCHEMA
+	"SECURITY":   QueryTypeDCL, // SECURITY LABEL
+	"REASSIGN":   QueryTypeDCL, // REASSIGN OWNED
+	"ABORT":      QueryTypeTCL,
+	"END":        QueryTypeTCL,
+	"DEALLOCATE": QueryTypeCall,
+	"VACUUM":     QueryTypeAdmin,
+	"CLUSTER":    QueryTypeAdmin,
+	"REINDEX":    QueryTypeAdmin,
+	"CHECKPOINT": QueryTypeAdmin,
+	"DISCARD":    QueryTypeAdmin,
+	"REFRESH":    QueryTypeAdmin,
+	"RESET":      QueryTypeAdmin,
+	"LISTEN":     QueryTypeAdmin,
+	"UNLISTEN":   QueryTypeAdmin,
+	"NOTIFY":     QueryTypeAdmin,
+	"LOAD":       QueryTypeAdmin, // LOAD 'library'
+}
+
+// leadingKeyword returns the statement type for a leading keyword under the
+// dialect's rules. Generic queries accept the keywords of both dialects.
+func leadingKeyword(keyword string, dialect Dialect) (QueryType, bool) {
+	if queryType, ok := commonKeywords[keyword]; ok {
+		return queryType, true
+	}
+	if dialect != DialectPostgres {
+		if queryType, ok := mysqlKeywords[keyword]; ok {
+			return queryType, true
+		}
+	}
+	if dialect != DialectMySQL {
+		if queryType, ok := postgresKeywords[keyword]; ok {
+			return queryType, true
+		}
+	}
+	return QueryTypeOther, false
+}
+
+// statementType classifies the statement starting at tokens[i]. It refines
+// the leading keyword with the words that follow where they change the
+// statement's category, e.g. CREATE USER is access control, not DDL.
+func statementType(tokens []Token, i int, dialect Dialect) QueryType {
+	keyword := tokens[i].Upper()
+	next := func(offset int) Token {
+		if i+offset < len(tokens) {
+			return tokens[i+offset]
+		}
+		return Token{Kind: TokenPunct}
+	}
+
+	switch keyword {
+	case "CREATE", "ALTER", "DROP", "RENAME":
+		if next(1).IsKeyword("USER", "ROLE", "GROUP") || (keyword == "ALTER" && next(1).IsKeyword("DEFAULT") && next(2).IsKeyword("PRIVILEGES")) {
+			return QueryTypeDCL
+		}
+		if keyword == "DROP" && next(1).IsKeyword("OWNED") {
+			return QueryTypeDCL
+		}
+		if keyword == "RENAME" && dialect == DialectPostgres {
+			return QueryTypeOther
+		}
+	case "START":
+		if next(1).IsKeyword("TRANSACTION") {
+			return QueryTypeTCL
+		}
+		if dialect != DialectPostgres && next(1).IsKeyword("SLAVE", "REPLICA", "GROUP_REPLICATION") {
+			return QueryTypeAdmin
+		}
+		return QueryTypeOther
+	case "SET":
+		return setStatementType(tokens, i, dialect)
+	case "PREPARE", "COMMIT", "ROLLBACK":
+		// Two-phase commit: PREPARE TRANSACTION, COMMIT/ROLLBACK PREPARED
+		if keyword == "PREPARE" && next(1).IsKeyword("TRANSACTION") {
+			return QueryTypeTCL
+		}
+	case "REPLACE":
+		if dialect == DialectPostgres {
+			return QueryTypeOther
+		}
+		return QueryTypeInsert
+	case "LOAD":
+		if dialect != DialectPostgres && next(1).IsKeyword("DATA", "XML") {
+			return QueryTypeBulkLoad
+		}
+		if dialect != DialectPostgres && next(1).IsKeyword("INDEX") {
+			return QueryTypeAdmin
+		}
+	case "COPY":
+		if dialect == DialectMySQL {
+			return QueryTypeOther
+		}
+		return copyStatementType(tokens, i)
+	case "CACHE":
+		if !next(1).IsKeyword("INDEX") {
+			return QueryTypeOther
+		}
+	}
+
+	queryType, _ := leadingKeyword(keyword, dialect)
+	return queryType
+}
+
+// setStatementType classifies SET statements: role and password changes are
+// access control, transaction characteristics are transaction control, user
+// variable assignments are reads and everything else changes server or
+// session configuration. A MySQL SET may list several assignments; it takes
+// the most privileged type among them.
+func setStatementType(tokens []Token, i int, dialect Dialect) QueryType {
+	j := i + 1
+	if j < len(tokens) && tokens[j].IsKeyword("SESSION", "LOCAL", "GLOBAL", "PERSIST", "PERSIST_ONLY") {
+		j++
+	}
+	if j < len(tokens) && tokens[j].IsKeyword("CHARACTERISTICS", "TRANSACTION", "CONSTRAINTS") {
+		// The commas of these statements separate modes or constraint names
+		return QueryTypeTCL
+	}
+
+	var queryType QueryType
+	depth := 0
+	start := i + 1
+	for j := start; ; j++ {
+		end := j >= len(tokens) || tokens[j].IsPunct(";") || depth == 0 && tokens[j].IsPunct(")")
+		if end || depth == 0 && tokens[j].IsPunct(",") {
+			assignment := setAssignmentType(tokens[:j], start, dialect)
+			if queryType == "" || setPrivilege[assignment] > setPrivilege[queryType] {
+				queryType = assignment
+			}
+			if end {
+				return queryType
+			}
+			start = j + 1
+			continue
+		}
+		if tokens[j].IsPunct("(") {
+			depth++
+		} else if tokens[j].IsPunct(")") {
+			depth--
+		}
+	}
+}
+
+// setPrivilege orders the types of SET assignments from least to most
+// privileged
+var setPrivilege = map[QueryType]int{
+	QueryTypeSelect: 0,
+	QueryTypeTCL:    1,
+	QueryTypeAdmin:  2,
+	QueryTypeDCL:    3,
+}
+
+// setAssignmentType classifies the SET assignment starting at tokens[j]
+func setAssignmentType(tokens []Token, j int, dialect Dialect) QueryType {
+	if j < len(tokens) && tokens[j].IsKeyword("SESSION", "LOCAL", "GLOBAL", "PERSIST", "PERSIST_ONLY") {
+		if j+1 < len(tokens) && tokens[j+1].IsKeyword("AUTHORIZATION") {
+			return QueryTypeDCL
+		}
+		j++
+	}
+	if j >= len(tokens) {
+		return QueryTypeAdmin
+	}
+
+	tok := tokens[j]
+	switch {
+	case tok.IsKeyword("ROLE"), tok.IsKeyword("DEFAULT") && j+1 < len(tokens) && tokens[j+1].IsKeyword("ROLE"):
+		return QueryTypeDCL
+	case tok.IsKeyword("PASSWORD") && dialect != DialectPostgres:
+		return QueryTypeDCL
+	case tok.IsKeyword("AUTOCOMMIT"):
+		return QueryTypeTCL
+	case tok.Kind == TokenVariable && dialect != DialectPostgres && len(tok.Text) > 1 && tok.Text[1] != '@':
+		return QueryTypeSelect
+	}
+	return QueryTypeAdmin
+}
+
+// copyStatementType classifies PostgreSQL COPY. COPY ... TO STDOUT streams a
+// table or query result to the client; every other form reads or writes
+// files or programs on the server.
+func copyStatementType(tokens []Token, i int) QueryType {
+	depth := 0
+	for j := i + 1; j < len(tokens); j++ {
+		tok := tokens[j]
+		switch {
+		case tok.IsPunct("("):
+			depth++
+		case tok.IsPunct(")"):
+			depth--
+		case tok.IsPunct(";"):
+			return QueryTypeBulkLoad
+		case depth == 0 && tok.IsKeyword("FROM"):
+			return QueryTypeBulkLoad
+		case depth == 0 && tok.IsKeyword("TO"):
+			if j+1 < len(tokens) && tokens[j+1].IsKeyword("STDOUT") {
+				return QueryTypeSelect
+			}
+			return QueryTypeBulkLoad
+		}
+	}
+	return QueryTypeBulkLoad
+}
+
+// explainBody returns the index of the statement explained by an EXPLAIN,
+// DESCRIBE or DESC at tokens[i] when the explained statement is executed
+// (EXPLAIN ANALYZE), or -1 when it is only planned.
+func explainBody(tokens []Token, i int, dialect Dialect) int {
+	j := i + 1
+	analyze := false
+
+	if dialect != DialectMySQL && j < len(tokens) && tokens[j].IsPunct("(") {
+		// PostgreSQL option list: EXPLAIN (ANALYZE [true], BUFFERS) ...
+		for j++; j < len(tokens) && !tokens[j].IsPunct(")"); j++ {
+			if tokens[j].IsKeyword("ANALYZE", "ANALYSE") {
+				analyze = !(j+1 < len(tokens) && (tokens[j+1].IsKeyword("FALSE", "OFF") || tokens[j+1].Text == "0"))
+			}
+		}
+		j++
+	}
+
+	for ; j < len(tokens); j++ {
+		tok := tokens[j]
+		if tok.IsKeyword("ANALYZE", "ANALYSE") {
+			analyze = true
+			continue
+		}
+		if tok.IsKeyword("VERBOSE", "EXTENDED", "PARTITIONS") {
+			continue
+		}
+		if tok.IsKeyword("FORMAT") && j+2 < len(tokens) {
+			// MySQL: EXPLAIN FORMAT=JSON ...
+			j += 2
+			continue
+		}
+		break
+	}
+
+	if !analyze || j >= len(tokens) {
+		return -1
+	}
+	return j
+}
@@ -0,0 +1,151 @@
+// pkg/sqlparser/fingerprint.go
+package sqlparser
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+)
+
+// Normalize reduces a query to its shape: comments and redundant whitespace
+// are removed, unquoted words are lower-cased and every literal and
+// placeholder becomes ?. Lists of values such as IN (1, 2, 3) collapse to
+// in(?+) and repeated VALUES rows collapse to the first row, so queries that
+// differ only in their literals normalize to the same text.
+func Normalize(query string, dialect Dialect) string {
+	var words []Token
+	for _, tok := range significant(Tokenize(query, dialect)) {
+		if tok.IsPunct(";") {
+			continue
+		}
+
+		switch tok.Kind {
+		case TokenString, TokenNumber, TokenPlaceholder:
+			// A sign directly before a number is part of the literal
+			if n := len(words); n > 0 && tok.Kind == TokenNumber && isSign(words[n-1]) && !isOperand(words, n-2) {
+				words = words[:n-1]
+			}
+			tok = Token{Kind: TokenPlaceholder, Text: "?"}
+		case TokenWord:
+			tok.Text = strings.ToLower(tok.Text)
+		}
+		words = append(words, tok)
+	}
+
+	words = collapseLists(words)
+
+	var b strings.Builder
+	for i, tok := range words {
+		if i > 0 && needsSpace(words[i-1], tok) {
+			b.WriteByte(' ')
+		}
+		b.WriteString(tok.Text)
+	}
+	return b.String()
+}
+
+// Fingerprint returns a stable 64-bit hash of the normalized query as 16 hex
+// characters
+func Fingerprint(query string, dialect Dialect) string {
+	sum := sha256.Sum256([]byte(Normalize(query, dialect)))
+	return hex.EncodeToString(sum[:8])
+}
+
+func isSign(tok Token) bool {
+	return tok.Kind == TokenOperator && (tok.Text == "-" || tok.Text == "+")
+}
+
+// isOperand reports whether words[i] ends an expression, which makes a
+// following sign a binary operator rather than part of a number
+func isOperand(words []Token, i int) bool {
+	if i < 0 {
+		return false
+	}
+	tok := words[i]
+	switch tok.Kind {
+	case TokenPlaceholder, TokenQuotedIdent, TokenVariable:
+		return true
+	case TokenWord:
+		return !reservedWords[tok.Upper()]
+	case TokenPunct:
+		return tok.Text == ")" || tok.Text == "]"
+	}
+	return false
+}
+
+// collapseLists replaces IN lists of values with in(?+) and drops VALUES
+// rows that repeat the first row
+func collapseLists(words []Token) []Token {
+	out := make([]Token, 0, len(words))
+	for i := 0; i < len(words); i++ {
+		tok := words[i]
+		out = append(out, tok)
+
+		if tok.IsKeyword("IN") && i+1 < len(words) && words[i+1].IsPunct("(") {
+			if end, ok := valueList(words, i+1); ok {
+				out = append(out, words[i+1], Token{Kind: TokenPlaceholder, Text: "?+"}, words[end])
+				i = end
+			}
+			continue
+		}
+
+		if tok.IsKeyword("VALUES") && i+1 < len(words) && words[i+1].IsPunct("(") {
+			end := closingParen(words, i+1)
+			if end < 0 {
+				continue
+			}
+			row := words[i+1 : end+1]
+			out = append(out, row...)
+			i = end
+			for i+1 < len(words) && words[i+1].IsPunct(",") && sameTokens(words[i+2:], row) {
+				i += 1 + len(row)
+			}
+		}
+	}
+	return out
+}
+
+// valueList reports whether the parenthesis at words[open] encloses only
+// literals separated by commas, and returns the index of its closing
+// parenthesis
+func valueList(words []Token, open int) (int, bool) {
+	for j := open + 1; j < len(words); j++ {
+		tok := words[j]
+		expectValue := (j-open)%2 == 1
+		switch {
+		case expectValue && tok.Kind == TokenPlaceholder:
+		case !expectValue && tok.IsPunct(","):
+		case !expectValue && tok.IsPunct(")"):
+			return j, true
+		default:
+			return 0, false
+		}
+	}
+	return 0, false
+}
+
+func sameTokens(words, row []Token) bool {
+	if len(words) < len(row) {
+		return false
+	}
+	for k, tok := range row {
+		if words[k].Kind != tok.Kind || words[k].Text != tok.Text {
+			return false
+		}
+	}
+	return true
+}
+
+func needsSpace(prev, tok Token) bool {
+	switch {
+	case prev.IsPunct("("), prev.IsPunct("."), prev.IsPunct("["):
+		return false
+	case tok.IsPunct(")"), tok.IsPunct(","), tok.IsPunct("."), tok.IsPunct("]"), tok.IsPunct("["):
+		return false
+	case tok.IsPunct("(") && (prev.Kind == TokenWord || prev.Kind == TokenQuotedIdent):
+		return false
+	case prev.Kind == TokenOperator && prev.Text == "::", tok.Kind == TokenOperator && tok.Text == "::":
+		return false
+	}
+	return true
+}
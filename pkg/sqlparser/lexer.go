@@ -0,0 +1,440 @@
+// pkg/sqlparser/lexer.go
+package sqlparser
+
+import (
+	"strings"
+)
+
+// Dialect selects the lexical rules used when tokenizing SQL
+type Dialect string
+
+const (
+	// DialectGeneric follows the ANSI rules shared by most databases
+	DialectGeneric  Dialect = ""
+	DialectMySQL    Dialect = "mysql"
+	DialectPostgres Dialect = "postgresql"
+)
+
+// ParseDialect maps a project database type to a Dialect
+func ParseDialect(databaseType string) Dialect {
+	switch strings.ToLower(databaseType) {
+	case "mysql", "mariadb":
+		return DialectMySQL
+	case "postgresql", "postgres":
+		return DialectPostgres
+	default:
+		return DialectGeneric
+	}
+}
+
+// TokenKind identifies the lexical class of a token
+type TokenKind int
+
+const (
+	TokenWord        TokenKind = iota // unquoted identifier or keyword
+	TokenQuotedIdent                  // "ident" or `ident`
+	TokenString                       // 'text', E'text', $$text$$
+	TokenNumber                       // 42, 3.14, 1e10, 0xFF
+	TokenPlaceholder                  // ?, $1, :name
+	TokenVariable                     // @var, @@session.var (MySQL)
+	TokenOperator                     // =, <>, ::, ||, ...
+	TokenPunct                        // ( ) , ; . [ ]
+	TokenComment                      // -- ..., # ..., /* ... */, and the /*!NNNNN and */ around MySQL executable comments
+)
+
+// Token is a single lexical element of a SQL string
+type Token struct {
+	Kind TokenKind
+	Text string
+	Pos  int // byte offset of the first character
+	End  int // byte offset just past the last character
+}
+
+// Upper returns the token text in upper case
+func (t Token) Upper() string {
+	return strings.ToUpper(t.Text)
+}
+
+// IsKeyword reports whether the token is an unquoted word matching any of the keywords
+func (t Token) IsKeyword(keywords ...string) bool {
+	if t.Kind != TokenWord {
+		return false
+	}
+	for _, keyword := range keywords {
+		if strings.EqualFold(t.Text, keyword) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsExecCommentStart reports whether the token opens a MySQL executable
+// comment (/*!NNNNN or /*M!NNNNN), whose contents are lexed as code
+func (t Token) IsExecCommentStart() bool {
+	if t.Kind != TokenComment {
+		return false
+	}
+	version, ok := strings.CutPrefix(t.Text, "/*!")
+	if !ok {
+		version, ok = strings.CutPrefix(t.Text, "/*M!")
+	}
+	return ok && strings.Trim(version, "0123456789") == ""
+}
+
+// IsExecCommentEnd reports whether the token closes a MySQL executable comment
+func (t Token) IsExecCommentEnd() bool {
+	return t.Kind == TokenComment && t.Text == "*/"
+}
+
+// IsPunct reports whether the token is the given punctuation character
+func (t Token) IsPunct(punct string) bool {
+	return t.Kind == TokenPunct && t.Text == punct
+}
+
+// Tokenize splits a SQL string into tokens according to the dialect's rules.
+// Whitespace is dropped; comments are kept as TokenComment. Unterminated
+// strings, identifiers and comments extend to the end of the input.
+func Tokenize(query string, dialect Dialect) []Token {
+	l := &lexer{src: query, dialect: dialect}
+	l.run()
+	return l.tokens
+}
+
+// significant drops comments from a token list
+func significant(tokens []Token) []Token {
+	out := make([]Token, 0, len(tokens))
+	for _, tok := range tokens {
+		if tok.Kind != TokenComment {
+			out = append(out, tok)
+		}
+	}
+	return out
+}
+
+type lexer struct {
+	src     string
+	pos     int
+	dialect Dialect
+	tokens  []Token
+
+	// inExecComment is set while inside a MySQL /*! ... */ comment, whose
+	// contents the server executes as regular SQL
+	inExecComment bool
+}
+
+func (l *lexer) peek(offset int) byte {
+	if l.pos+offset < len(l.src) {
+		return l.src[l.pos+offset]
+	}
+	return 0
+}
+
+func (l *lexer) emit(kind TokenKind, start int) {
+	l.tokens = append(l.tokens, Token{Kind: kind, Text: l.src[start:l.pos], Pos: start, End: l.pos})
+}
+
+func (l *lexer) run() {
+	for l.pos < len(l.src) {
+		c := l.src[l.pos]
+		switch {
+		case isSpace(c):
+			l.pos++
+		case l.inExecComment && c == '*' && l.peek(1) == '/':
+			l.inExecComment = false
+			l.pos += 2
+			l.emit(TokenComment, l.pos-2)
+		case c == '-' && l.peek(1) == '-' && l.lineCommentStart():
+			l.lexLineComment()
+		case c == '#' && l.dialect == DialectMySQL:
+			l.lexLineComment()
+		case c == '/' && l.peek(1) == '*':
+			l.lexBlockComment()
+		case c == '\'':
+			l.lexString(l.pos, l.dialect == DialectMySQL)
+		case c == '"' && l.dialect == DialectMySQL:
+			l.lexString(l.pos, true)
+		case c == '"' || c == '`':
+			l.lexQuotedIdent(c)
+		case c == '$' && l.dialect != DialectMySQL && l.lexDollar():
+		case isDigit(c) || (c == '.' && isDigit(l.peek(1))):
+			l.lexNumber()
+		case isWordStart(c):
+			l.lexWord()
+		case c == '?' && l.dialect != DialectPostgres:
+			l.pos++
+			l.emit(TokenPlaceholder, l.pos-1)
+		case c == ':' && l.peek(1) == ':':
+			l.pos += 2
+			l.emit(TokenOperator, l.pos-2)
+		case c == ':' && isWordStart(l.peek(1)):
+			start := l.pos
+			l.pos++
+			l.consumeWord()
+			l.emit(TokenPlaceholder, start)
+		case c == '@' && l.dialect == DialectMySQL:
+			l.lexVariable()
+		case strings.IndexByte("(),;.[]", c) >= 0:
+			l.pos++
+			l.emit(TokenPunct, l.pos-1)
+		default:
+			l.lexOperator()
+		}
+	}
+}
+
+// lineCommentStart reports whether "--" at the current position opens a
+// comment. MySQL requires whitespace after the dashes.
+func (l *lexer) lineCommentStart() bool {
+	if l.dialect != DialectMySQL {
+		return true
+	}
+	next := l.peek(2)
+	return next == 0 || isSpace(next) || next < 0x20
+}
+
+func (l *lexer) lexLineComment() {
+	start := l.pos
+	for l.pos < len(l.src) && l.src[l.pos] != '\n' {
+		l.pos++
+	}
+	l.emit(TokenComment, start)
+}
+
+func (l *lexer) lexBlockComment() {
+	start := l.pos
+
+	if l.dialect == DialectMySQL && (l.peek(2) == '!' || (l.peek(2) == 'M' && l.peek(3) == '!')) {
+		// Executable comment: /*!50001 ... */ runs its body on servers at or
+		// above the given version, so its contents must be lexed as code
+		l.pos += 3
+		if l.src[l.pos-1] == 'M' {
+			l.pos++
+		}
+		for l.pos < len(l.src) && isDigit(l.src[l.pos]) {
+			l.pos++
+		}
+		l.inExecComment = true
+		l.emit(TokenComment, start)
+		return
+	}
+
+	l.pos += 2
+	depth := 1
+	for l.pos < len(l.src) && depth > 0 {
+		switch {
+		case l.src[l.pos] == '*' && l.peek(1) == '/':
+			depth--
+			l.pos += 2
+		case l.src[l.pos] == '/' && l.peek(1) == '*' && l.dialect != DialectMySQL:
+			// PostgreSQL block comments nest
+			depth++
+			l.pos += 2
+		default:
+			l.pos++
+		}
+	}
+	l.emit(TokenComment, start)
+}
+
+// lexString consumes a quoted string starting at the current quote character.
+// start may precede the quote when the string has a prefix such as E or X.
+func (l *lexer) lexString(start int, backslashEscapes bool) {
+	quote := l.src[l.pos]
+	l.pos++
+	for l.pos < len(l.src) {
+		c := l.src[l.pos]
+		switch {
+		case c == '\\' && backslashEscapes:
+			l.pos += 2
+		case c == quote && l.peek(1) == quote:
+			l.pos += 2
+		case c == quote:
+			l.pos++
+			l.emit(TokenString, start)
+			return
+		default:
+			l.pos++
+		}
+	}
+	l.pos = len(l.src)
+	l.emit(TokenString, start)
+}
+
+func (l *lexer) lexQuotedIdent(quote byte) {
+	start := l.pos
+	l.pos++
+	for l.pos < len(l.src) {
+		if l.src[l.pos] == quote {
+			if l.peek(1) == quote {
+				l.pos += 2
+				continue
+			}
+			l.pos++
+			l.emit(TokenQuotedIdent, start)
+			return
+		}
+		l.pos++
+	}
+	l.emit(TokenQuotedIdent, start)
+}
+
+// lexDollar handles PostgreSQL $1 placeholders and $tag$...$tag$ strings.
+// It returns false when the dollar sign starts neither.
+func (l *lexer) lexDollar() bool {
+	start := l.pos
+
+	if isDigit(l.peek(1)) {
+		l.pos++
+		for l.pos < len(l.src) && isDigit(l.src[l.pos]) {
+			l.pos++
+		}
+		l.emit(TokenPlaceholder, start)
+		return true
+	}
+
+	end := l.pos + 1
+	if end < len(l.src) && isWordStart(l.src[end]) {
+		for end < len(l.src) && isWordChar(l.src[end]) && l.src[end] != '$' {
+			end++
+		}
+	}
+	if end >= len(l.src) || l.src[end] != '$' {
+		return false
+	}
+
+	delimiter := l.src[start : end+1]
+	closing := strings.Index(l.src[end+1:], delimiter)
+	if closing < 0 {
+		l.pos = len(l.src)
+	} else {
+		l.pos = end + 1 + closing + len(delimiter)
+	}
+	l.emit(TokenString, start)
+	return true
+}
+
+func (l *lexer) lexNumber() {
+	start := l.pos
+
+	if l.src[l.pos] == '0' && (l.peek(1) == 'x' || l.peek(1) == 'X') && isHexDigit(l.peek(2)) {
+		l.pos += 2
+		for l.pos < len(l.src) && isHexDigit(l.src[l.pos]) {
+			l.pos++
+		}
+		l.emit(TokenNumber, start)
+		return
+	}
+
+	for l.pos < len(l.src) && (isDigit(l.src[l.pos]) || l.src[l.pos] == '.') {
+		l.pos++
+	}
+	if l.pos < len(l.src) && (l.src[l.pos] == 'e' || l.src[l.pos] == 'E') {
+		next := l.peek(1)
+		if isDigit(next) || ((next == '+' || next == '-') && isDigit(l.peek(2))) {
+			l.pos += 2
+			for l.pos < len(l.src) && isDigit(l.src[l.pos]) {
+				l.pos++
+			}
+		}
+	}
+	l.emit(TokenNumber, start)
+}
+
+func (l *lexer) lexWord() {
+	start := l.pos
+
+	// Prefixed strings: E'...', B'...', X'...', N'...'
+	if l.peek(1) == '\'' {
+		switch l.src[l.pos] {
+		case 'E', 'e':
+			l.pos++
+			l.lexString(start, true)
+			return
+		case 'B', 'b', 'X', 'x', 'N', 'n':
+			l.pos++
+			l.lexString(start, l.dialect == DialectMySQL)
+			return
+		}
+	}
+
+	l.consumeWord()
+	l.emit(TokenWord, start)
+}
+
+func (l *lexer) consumeWord() {
+	for l.pos < len(l.src) && isWordChar(l.src[l.pos]) {
+		l.pos++
+	}
+}
+
+func (l *lexer) lexVariable() {
+	start := l.pos
+	l.pos++
+	if l.peek(0) == '@' {
+		l.pos++
+	}
+
+	switch c := l.peek(0); {
+	case c == '\'' || c == '"':
+		l.lexString(start, true)
+		l.tokens[len(l.tokens)-1].Kind = TokenVariable
+		return
+	case c == '`':
+		l.lexQuotedIdent('`')
+		l.tokens[len(l.tokens)-1] = Token{Kind: TokenVariable, Text: l.src[start:l.pos], Pos: start, End: l.pos}
+		return
+	}
+
+	for l.pos < len(l.src) && (isWordChar(l.src[l.pos]) || l.src[l.pos] == '.') {
+		l.pos++
+	}
+	l.emit(TokenVariable, start)
+}
+
+const operatorChars = "+-*/<>=~!@#%^&|?:"
+
+func (l *lexer) lexOperator() {
+	start := l.pos
+	if strings.IndexByte(operatorChars, l.src[l.pos]) < 0 {
+		// Unknown character: emit it on its own so lexing always progresses
+		l.pos++
+		l.emit(TokenOperator, start)
+		return
+	}
+
+	for l.pos < len(l.src) && strings.IndexByte(operatorChars, l.src[l.pos]) >= 0 {
+		c := l.src[l.pos]
+		// Never swallow the start of a comment
+		if l.pos > start && ((c == '-' && l.peek(1) == '-') || (c == '/' && l.peek(1) == '*')) {
+			break
+		}
+		if l.pos > start && c == '?' && l.dialect != DialectPostgres {
+			break
+		}
+		if l.pos > start && (c == '@' || c == '#') && l.dialect == DialectMySQL {
+			break
+		}
+		l.pos++
+	}
+	l.emit(TokenOperator, start)
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f' || c == '\v'
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isHexDigit(c byte) bool {
+	return isDigit(c) || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F')
+}
+
+func isWordStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || c >= 0x80
+}
+
+func isWordChar(c byte) bool {
+	return isWordStart(c) || isDigit(c) || c == '$'
+}
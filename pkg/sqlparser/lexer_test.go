@@ -0,0 +1,122 @@
+package sqlparser
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTokenize(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   string
+		dialect Dialect
+		want    []string // kind:text of each token
+	}{
+		{"string with keyword", "SELECT 'DELETE FROM t'", DialectPostgres, []string{"word:SELECT", "string:'DELETE FROM t'"}},
+		{"doubled quote", "SELECT 'it''s'", DialectPostgres, []string{"word:SELECT", "string:'it''s'"}},
+		{"backslash escape", `SELECT 'a\'b'`, DialectMySQL, []string{"word:SELECT", `string:'a\'b'`}},
+		{"quoted identifier", `SELECT "delete" FROM t`, DialectPostgres, []string{"word:SELECT", `ident:"delete"`, "word:FROM", "word:t"}},
+		{"backtick identifier", "SELECT `drop` FROM t", DialectMySQL, []string{"word:SELECT", "ident:`drop`", "word:FROM", "word:t"}},
+		{"dollar quoted", "SELECT $fn$ DROP TABLE t; $fn$", DialectPostgres, []string{"word:SELECT", "string:$fn$ DROP TABLE t; $fn$"}},
+		{"positional placeholder", "SELECT $1", DialectPostgres, []string{"word:SELECT", "placeholder:$1"}},
+		{"nested comment", "SELECT /* a /* b */ DROP */ 1", DialectPostgres, []string{"word:SELECT", "comment:/* a /* b */ DROP */", "number:1"}},
+		{"line comments", "SELECT 1 -- DROP\n# x\n", DialectMySQL, []string{"word:SELECT", "number:1", "comment:-- DROP", "comment:# x"}},
+		{"cast operator", "SELECT a::int", DialectPostgres, []string{"word:SELECT", "word:a", "op:::", "word:int"}},
+		{"executable comment", "SELECT /*!50000 SQL_NO_CACHE */ 1", DialectMySQL, []string{"word:SELECT", "comment:/*!50000", "word:SQL_NO_CACHE", "comment:*/", "number:1"}},
+		{"plain comment in mysql", "SELECT /* DROP */ 1", DialectMySQL, []string{"word:SELECT", "comment:/* DROP */", "number:1"}},
+		{"variable", "SELECT @@session.x", DialectMySQL, []string{"word:SELECT", "var:@@session.x"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, tok := range Tokenize(tt.query, tt.dialect) {
+				got = append(got, tokenKindNames[tok.Kind]+":"+tok.Text)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Tokenize(%q) = %q, want %q", tt.query, got, tt.want)
+			}
+		})
+	}
+}
+
+var tokenKindNames = map[TokenKind]string{
+	TokenWord:        "word",
+	TokenQuotedIdent: "ident",
+	TokenString:      "string",
+	TokenNumber:      "number",
+	TokenPlaceholder: "placeholder",
+	TokenVariable:    "var",
+	TokenOperator:    "op",
+	TokenPunct:       "punct",
+	TokenComment:     "comment",
+}
+
+func TestGetQueryType(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   string
+		dialect Dialect
+		want    QueryType
+	}{
+		{"select", "SELECT * FROM users", DialectPostgres, QueryTypeSelect},
+		{"delete in cte", "WITH x AS (DELETE FROM t RETURNING *) SELECT * FROM x", DialectPostgres, QueryTypeDelete},
+		{"update in cte", "WITH x AS (UPDATE t SET a = 1 RETURNING *) SELECT * FROM x", DialectPostgres, QueryTypeUpdate},
+		{"keyword in string", "SELECT 'DROP TABLE users'", DialectPostgres, QueryTypeSelect},
+		{"keyword in quoted identifier", `SELECT "delete" FROM t`, DialectPostgres, QueryTypeSelect},
+		{"keyword in comment", "/* DELETE FROM t */ SELECT 1", DialectPostgres, QueryTypeSelect},
+		{"drop after select", "SELECT 1; DROP TABLE users", DialectPostgres, QueryTypeDDL},
+		{"drop in dollar quote", "SELECT $$; DROP TABLE users$$", DialectPostgres, QueryTypeSelect},
+		{"executable comment", "SELECT 1 /*!50000 ; DROP TABLE users */", DialectMySQL, QueryTypeDDL},
+		{"executable comment is a comment elsewhere", "SELECT 1 /*!50000 ; DROP TABLE users */", DialectPostgres, QueryTypeSelect},
+		{"leading comment", "-- note\nUPDATE t SET a = 1", DialectPostgres, QueryTypeUpdate},
+		{"insert", "INSERT INTO t VALUES (1)", DialectMySQL, QueryTypeInsert},
+		{"grant", "GRANT SELECT ON t TO bob", DialectPostgres, QueryTypeDCL},
+		{"begin", "BEGIN", DialectPostgres, QueryTypeTCL},
+		{"copy", "COPY t FROM '/tmp/t.csv'", DialectPostgres, QueryTypeBulkLoad},
+		{"unknown", "FROBNICATE t", DialectPostgres, QueryTypeOther},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := GetQueryType(tt.query, tt.dialect); got != tt.want {
+				t.Errorf("GetQueryType(%q) = %s, want %s", tt.query, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRequiresPermission(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   string
+		dialect Dialect
+		want    RequiredPermissions
+	}{
+		{"select", "SELECT 1", DialectPostgres, RequiredPermissions{Read: true}},
+		{"delete in cte", "WITH x AS (DELETE FROM t RETURNING *) SELECT * FROM x", DialectPostgres, RequiredPermissions{Read: true, Delete: true}},
+		{"select then drop", "SELECT 1; DROP TABLE users", DialectPostgres, RequiredPermissions{Read: true, DDL: true}},
+		{"replace implies delete", "REPLACE INTO t (id) VALUES (1)", DialectMySQL, RequiredPermissions{Write: true, Delete: true}},
+		{"bulk load needs write", "LOAD DATA INFILE '/tmp/t' INTO TABLE t", DialectMySQL, RequiredPermissions{BulkLoad: true, Write: true}},
+		{"executable comment", "SELECT 1 /*!50000 ; DELETE FROM t */", DialectMySQL, RequiredPermissions{Read: true, Delete: true}},
+		{"set user variables", "SET @x = 1, @y := (SELECT 2)", DialectMySQL, RequiredPermissions{Read: true}},
+		{"set variable then global", "SET @x = 1, GLOBAL read_only = 1", DialectMySQL, RequiredPermissions{Admin: true}},
+		{"set variable then system variable", "SET @x = 1, @@session.sql_mode = ''", DialectMySQL, RequiredPermissions{Admin: true}},
+		{"set variable then password", "SET @x = 1, PASSWORD = 'x'", DialectMySQL, RequiredPermissions{DCL: true}},
+		{"set variable then autocommit", "SET @x = f(1, 2), autocommit = 0", DialectMySQL, RequiredPermissions{Transaction: true}},
+		{"set transaction modes", "SET TRANSACTION ISOLATION LEVEL SERIALIZABLE, READ ONLY", DialectMySQL, RequiredPermissions{Transaction: true}},
+		{"set constraints list", "SET CONSTRAINTS a, b DEFERRED", DialectPostgres, RequiredPermissions{Transaction: true}},
+		{"set search path list", "SET search_path TO a, b", DialectPostgres, RequiredPermissions{Admin: true}},
+		{"set then next statement", "SET @x = 1; SET GLOBAL read_only = 1", DialectMySQL, RequiredPermissions{Read: true, Admin: true}},
+		{"empty", "-- nothing", DialectPostgres, RequiredPermissions{Unknown: true}},
+		{"unknown", "FROBNICATE t", DialectPostgres, RequiredPermissions{Unknown: true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := RequiresPermission(tt.query, tt.dialect); got != tt.want {
+				t.Errorf("RequiresPermission(%q) = %+v, want %+v", tt.query, got, tt.want)
+			}
+		})
+	}
+}
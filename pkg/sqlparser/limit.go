@@ -0,0 +1,163 @@
+// pkg/sqlparser/limit.go
+package sqlparser
+
+import (
+	"errors"
+	"strconv"
+)
+
+// LimitRows rewrites a SELECT so that its outermost query returns at most
+// maxRows rows. Queries without a row limit get a LIMIT clause inserted
+// before any OFFSET or locking clause; an existing LIMIT or FETCH FIRST
+// with a larger literal count is lowered to maxRows, smaller ones are left
+// alone, and a query whose count is not an integer literal is wrapped in a
+// subquery with the limit. Statements that are not queries, or whose
+// results go into a table, variable or file, are returned unchanged.
+//
+// MySQL executable comments (/*!NNNNN ... */) only run on some server
+// versions. A LIMIT added at the end goes after such a comment, never
+// inside it, and a query whose row limit or target clause sits inside one
+// is wrapped in a subquery so that the cap holds on every version.
+func LimitRows(query string, dialect Dialect, maxRows int) string {
+	var (
+		tokens []Token
+		inExec []bool // whether each token is inside an executable comment
+		end    int    // end of the last token, or of a closing */ after it
+		exec   bool
+	)
+	for _, tok := range Tokenize(query, dialect) {
+		switch {
+		case tok.IsExecCommentStart():
+			exec = true
+		case tok.IsExecCommentEnd():
+			exec = false
+			end = tok.End
+		case tok.Kind == TokenComment:
+		case tok.IsPunct(";"):
+			tokens = append(tokens, tok)
+			inExec = append(inExec, exec)
+		default:
+			tokens = append(tokens, tok)
+			inExec = append(inExec, exec)
+			end = tok.End
+		}
+	}
+	for len(tokens) > 0 && tokens[len(tokens)-1].IsPunct(";") {
+		tokens = tokens[:len(tokens)-1]
+	}
+	if maxRows <= 0 || len(tokens) == 0 || !(tokens[0].IsKeyword("SELECT", "WITH", "VALUES", "TABLE") || tokens[0].IsPunct("(")) {
+		return query
+	}
+
+	limit := strconv.Itoa(maxRows)
+	insertAt := -1
+	depth := 0
+
+scan:
+	for i := 0; i < len(tokens); i++ {
+		tok := tokens[i]
+		switch {
+		case tok.IsPunct("("):
+			depth++
+			continue
+		case tok.IsPunct(")"):
+			depth--
+			continue
+		case depth > 0 || tok.Kind != TokenWord:
+			continue
+		}
+
+		next := func(offset int) Token {
+			if i+offset < len(tokens) {
+				return tokens[i+offset]
+			}
+			return Token{Kind: TokenPunct}
+		}
+
+		if inExec[i] && tok.IsKeyword("INSERT", "UPDATE", "DELETE", "MERGE", "INTO", "LIMIT", "FETCH", "OFFSET", "FOR", "LOCK") {
+			return wrapLimit(query, end, limit)
+		}
+
+		switch tok.Upper() {
+		case "INSERT", "UPDATE", "DELETE", "MERGE", "INTO":
+			// WITH ... INSERT/UPDATE/DELETE, or SELECT ... INTO
+			return query
+		case "LIMIT":
+			if next(1).IsKeyword("ALL") {
+				return replaceToken(query, next(1), limit)
+			}
+			count := i + 1
+			if dialect != DialectPostgres && next(2).IsPunct(",") {
+				// MySQL: LIMIT offset, count
+				count = i + 3
+			}
+			return lowerLimit(query, end, tokens, count, maxRows)
+		case "FETCH":
+			// FETCH FIRST|NEXT [count] ROW|ROWS ONLY
+			if next(2).IsKeyword("ROW", "ROWS") {
+				// The count defaults to one row
+				return query
+			}
+			return lowerLimit(query, end, tokens, i+2, maxRows)
+		case "OFFSET":
+			if insertAt < 0 {
+				insertAt = tok.Pos
+			}
+		case "FOR":
+			if next(1).IsKeyword("UPDATE", "SHARE", "NO", "KEY") {
+				if insertAt < 0 {
+					insertAt = tok.Pos
+				}
+				break scan
+			}
+		case "LOCK":
+			// MySQL: LOCK IN SHARE MODE
+			if next(1).IsKeyword("IN") {
+				if insertAt < 0 {
+					insertAt = tok.Pos
+				}
+				break scan
+			}
+		}
+	}
+
+	if insertAt < 0 {
+		return query[:end] + " LIMIT " + limit + query[end:]
+	}
+	return query[:insertAt] + "LIMIT " + limit + " " + query[insertAt:]
+}
+
+// wrapLimit limits the rows of the query ending at end by selecting from it
+// as a derived table
+func wrapLimit(query string, end int, limit string) string {
+	return "SELECT * FROM (" + query[:end] + ") AS limited LIMIT " + limit + query[end:]
+}
+
+// lowerLimit replaces a literal row count at tokens[j] larger than maxRows.
+// Counts beyond the integer range, such as MySQL's 18446744073709551615 for
+// all rows, are larger. A count that is not a plain integer literal, like a
+// placeholder, expression or subquery, cannot be checked, so the query is
+// wrapped instead.
+func lowerLimit(query string, end int, tokens []Token, j int, maxRows int) string {
+	limit := strconv.Itoa(maxRows)
+	if j >= len(tokens) || tokens[j].Kind != TokenNumber ||
+		j+1 < len(tokens) && (tokens[j+1].Kind == TokenOperator || tokens[j+1].IsPunct("(") || tokens[j+1].IsPunct(".")) {
+		return wrapLimit(query, end, limit)
+	}
+
+	n, err := strconv.ParseUint(tokens[j].Text, 10, 64)
+	switch {
+	case errors.Is(err, strconv.ErrRange):
+		// Larger than any row count
+	case err != nil:
+		// 1e10, 0xFF and the like
+		return wrapLimit(query, end, limit)
+	case n <= uint64(maxRows):
+		return query
+	}
+	return replaceToken(query, tokens[j], limit)
+}
+
+func replaceToken(query string, tok Token, text string) string {
+	return query[:tok.Pos] + text + query[tok.End:]
+}
@@ -0,0 +1,58 @@
+package sqlparser
+
+import (
+	"testing"
+)
+
+func TestLimitRows(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   string
+		dialect Dialect
+		want    string
+	}{
+		{"adds limit", "SELECT * FROM t", DialectPostgres, "SELECT * FROM t LIMIT 100"},
+		{"keeps semicolon", "SELECT * FROM t;", DialectPostgres, "SELECT * FROM t LIMIT 100;"},
+		{"before trailing comment", "SELECT * FROM t -- all rows", DialectPostgres, "SELECT * FROM t LIMIT 100 -- all rows"},
+		{"lowers larger limit", "SELECT * FROM t LIMIT 5000", DialectPostgres, "SELECT * FROM t LIMIT 100"},
+		{"keeps smaller limit", "SELECT * FROM t LIMIT 10", DialectPostgres, "SELECT * FROM t LIMIT 10"},
+		{"limit all", "SELECT * FROM t LIMIT ALL", DialectPostgres, "SELECT * FROM t LIMIT 100"},
+		{"mysql offset, count", "SELECT * FROM t LIMIT 10, 5000", DialectMySQL, "SELECT * FROM t LIMIT 10, 100"},
+		{"before offset", "SELECT * FROM t OFFSET 20", DialectPostgres, "SELECT * FROM t LIMIT 100 OFFSET 20"},
+		{"before for update", "SELECT * FROM t FOR UPDATE", DialectPostgres, "SELECT * FROM t LIMIT 100 FOR UPDATE"},
+		{"lowers fetch first", "SELECT * FROM t FETCH FIRST 5000 ROWS ONLY", DialectPostgres, "SELECT * FROM t FETCH FIRST 100 ROWS ONLY"},
+		{"subquery limit ignored", "SELECT * FROM (SELECT * FROM t LIMIT 5) s", DialectPostgres, "SELECT * FROM (SELECT * FROM t LIMIT 5) s LIMIT 100"},
+		{"select into unchanged", "SELECT * INTO backup FROM t", DialectPostgres, "SELECT * INTO backup FROM t"},
+		{"data-modifying cte unchanged", "WITH d AS (DELETE FROM t RETURNING *) INSERT INTO u SELECT * FROM d", DialectPostgres, "WITH d AS (DELETE FROM t RETURNING *) INSERT INTO u SELECT * FROM d"},
+		{"not a query", "UPDATE t SET a = 1", DialectPostgres, "UPDATE t SET a = 1"},
+		{"limit in string ignored", "SELECT 'LIMIT 5' FROM t", DialectPostgres, "SELECT 'LIMIT 5' FROM t LIMIT 100"},
+		{"limit in comment ignored", "SELECT * FROM t /* LIMIT 5 */", DialectPostgres, "SELECT * FROM t LIMIT 100 /* LIMIT 5 */"},
+		{"union", "SELECT a FROM t UNION SELECT b FROM u", DialectPostgres, "SELECT a FROM t UNION SELECT b FROM u LIMIT 100"},
+		{"read-only cte", "WITH s AS (SELECT * FROM t) SELECT * FROM s", DialectPostgres, "WITH s AS (SELECT * FROM t) SELECT * FROM s LIMIT 100"},
+		{"mysql limit offset", "SELECT * FROM t LIMIT 5000 OFFSET 10", DialectMySQL, "SELECT * FROM t LIMIT 100 OFFSET 10"},
+		{"plain comment in mysql", "SELECT * FROM t /* LIMIT 5 */", DialectMySQL, "SELECT * FROM t LIMIT 100 /* LIMIT 5 */"},
+		{"after executable comment", "SELECT 1 /*!50000 , 2 */", DialectMySQL, "SELECT 1 /*!50000 , 2 */ LIMIT 100"},
+		{"after executable comment before semicolon", "SELECT 1 /*!50000 , 2 */;", DialectMySQL, "SELECT 1 /*!50000 , 2 */ LIMIT 100;"},
+		{"mysql all rows idiom", "SELECT * FROM t LIMIT 18446744073709551615", DialectMySQL, "SELECT * FROM t LIMIT 100"},
+		{"mysql offset, all rows", "SELECT * FROM t LIMIT 5, 18446744073709551615", DialectMySQL, "SELECT * FROM t LIMIT 5, 100"},
+		{"beyond uint64", "SELECT * FROM t LIMIT 99999999999999999999999", DialectPostgres, "SELECT * FROM t LIMIT 100"},
+		{"postgres placeholder", "SELECT * FROM t LIMIT $1", DialectPostgres, "SELECT * FROM (SELECT * FROM t LIMIT $1) AS limited LIMIT 100"},
+		{"mysql placeholder", "SELECT * FROM t LIMIT ?;", DialectMySQL, "SELECT * FROM (SELECT * FROM t LIMIT ?) AS limited LIMIT 100;"},
+		{"mysql offset, placeholder", "SELECT * FROM t LIMIT 10, ?", DialectMySQL, "SELECT * FROM (SELECT * FROM t LIMIT 10, ?) AS limited LIMIT 100"},
+		{"expression", "SELECT * FROM t LIMIT 10*1000000", DialectPostgres, "SELECT * FROM (SELECT * FROM t LIMIT 10*1000000) AS limited LIMIT 100"},
+		{"subquery count", "SELECT * FROM t LIMIT (SELECT n FROM c)", DialectPostgres, "SELECT * FROM (SELECT * FROM t LIMIT (SELECT n FROM c)) AS limited LIMIT 100"},
+		{"fetch first expression", "SELECT * FROM t FETCH FIRST (10*1000) ROWS ONLY", DialectPostgres, "SELECT * FROM (SELECT * FROM t FETCH FIRST (10*1000) ROWS ONLY) AS limited LIMIT 100"},
+		{"fetch first without count", "SELECT * FROM t FETCH FIRST ROW ONLY", DialectPostgres, "SELECT * FROM t FETCH FIRST ROW ONLY"},
+		{"non-integer literal", "SELECT * FROM t LIMIT 1e9", DialectPostgres, "SELECT * FROM (SELECT * FROM t LIMIT 1e9) AS limited LIMIT 100"},
+		{"limit in executable comment", "SELECT * FROM t /*!99999 LIMIT 10 */", DialectMySQL, "SELECT * FROM (SELECT * FROM t /*!99999 LIMIT 10 */) AS limited LIMIT 100"},
+		{"into in executable comment", "SELECT * FROM t /*!99999 INTO OUTFILE '/tmp/t' */", DialectMySQL, "SELECT * FROM (SELECT * FROM t /*!99999 INTO OUTFILE '/tmp/t' */) AS limited LIMIT 100"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := LimitRows(tt.query, tt.dialect, 100); got != tt.want {
+				t.Errorf("LimitRows(%q) = %q, want %q", tt.query, got, tt.want)
+			}
+		})
+	}
+}
@@ -1,128 +1,408 @@
 // pkg/sqlparser/parser.go
 package sqlparser
 
-import (
-	"regexp"
-	"strings"
-)
-
 // QueryType represents the type of SQL query
 type QueryType string
 
 const (
-	QueryTypeSelect QueryType = "SELECT"
-	QueryTypeInsert QueryType = "INSERT"
-	QueryTypeUpdate QueryType = "UPDATE"
-	QueryTypeDelete QueryType = "DELETE"
-	QueryTypeDDL    QueryType = "DDL"
-	QueryTypeOther  QueryType = "OTHER"
+	QueryTypeSelect   QueryType = "SELECT"
+	QueryTypeInsert   QueryType = "INSERT"
+	QueryTypeUpdate   QueryType = "UPDATE"
+	QueryTypeDelete   QueryType = "DELETE"
+	QueryTypeDDL      QueryType = "DDL"
+	QueryTypeDCL      QueryType = "DCL"       // GRANT, REVOKE, role and user management
+	QueryTypeTCL      QueryType = "TCL"       // transaction control
+	QueryTypeCall     QueryType = "CALL"      // procedure calls, anonymous blocks, prepared statements
+	QueryTypeBulkLoad QueryType = "BULK_LOAD" // COPY, LOAD DATA
+	QueryTypeAdmin    QueryType = "ADMIN"     // maintenance, locking and server or session configuration
+	QueryTypeOther    QueryType = "OTHER"
 )
 
-// GetQueryType determines the type of SQL query
-func GetQueryType(query string) QueryType {
-	query = strings.TrimSpace(strings.ToUpper(query))
+// privilegeRank orders query types from least to most privileged
+var privilegeRank = map[QueryType]int{
+	QueryTypeOther:    0,
+	QueryTypeTCL:      1,
+	QueryTypeSelect:   2,
+	QueryTypeInsert:   3,
+	QueryTypeUpdate:   3,
+	QueryTypeDelete:   4,
+	QueryTypeBulkLoad: 4,
+	QueryTypeCall:     5,
+	QueryTypeDDL:      6,
+	QueryTypeAdmin:    6,
+	QueryTypeDCL:      7,
+}
 
-	// Remove comments
-	query = removeComments(query)
+// nestedKeywords are the data-modifying keywords that can start a statement
+// nested inside another one, e.g. in a CTE body or a subquery
+var nestedKeywords = map[string]QueryType{
+	"SELECT": QueryTypeSelect,
+	"INSERT": QueryTypeInsert,
+	"UPDATE": QueryTypeUpdate,
+	"DELETE": QueryTypeDelete,
+}
 
-	// Check for DDL operations
-	if IsDDLQuery(query) {
-		return QueryTypeDDL
-	}
+// operation is a statement or sub-statement found in a query
+type operation struct {
+	Type  QueryType
+	Index int // index of the keyword in the significant token list
+	Depth int // parenthesis depth of the keyword
+	// Implied operations only contribute to the required permissions, e.g.
+	// the delete performed by REPLACE or the actions of a MERGE
+	Implied bool
+}
 
-	// Check for DML operations
-	if strings.HasPrefix(query, "SELECT") || strings.HasPrefix(query, "WITH") {
-		return QueryTypeSelect
-	}
-	if strings.HasPrefix(query, "INSERT") {
-		return QueryTypeInsert
+// findOperations locates every operation in the token stream. Top-level
+// statements are recognised after the start of input or a semicolon;
+// nested statements after an opening or closing parenthesis (CTE bodies,
+// subqueries and the main statement following a WITH list) or after THEN.
+// A statement with an unrecognised leading keyword yields QueryTypeOther.
+func findOperations(tokens []Token, dialect Dialect) []operation {
+	var ops []operation
+	depth := 0
+	statementStart := true
+	merge := false // the current statement is a MERGE, whose actions are implied
+	explainOptionsEnd := -1
+	// owners holds, per parenthesis depth, the last statement keyword seen
+	// at that depth, so that INTO can be attributed to its statement
+	owners := []string{""}
+
+	for i, tok := range tokens {
+		switch {
+		case tok.IsPunct("("):
+			depth++
+			owners = append(owners[:depth], "")
+		case tok.IsPunct(")"):
+			if depth > 0 {
+				depth--
+			}
+		case tok.IsPunct(";"):
+			depth = 0
+			owners = owners[:1]
+			owners[0] = ""
+			statementStart = true
+			merge = false
+			continue
+		case tok.IsKeyword("SELECT", "INSERT", "REPLACE", "UPDATE", "DELETE", "MERGE"):
+			owners[depth] = tok.Upper()
+		}
+
+		if statementStart && !tok.IsPunct("(") {
+			switch {
+			case tok.IsKeyword("WITH"):
+				// The statement following the CTE list is found as a nested operation
+			case tok.Kind != TokenWord:
+				ops = append(ops, operation{Type: QueryTypeOther, Index: i, Depth: depth})
+			default:
+				queryType := statementType(tokens, i, dialect)
+				ops = append(ops, operation{Type: queryType, Index: i, Depth: depth})
+				merge = tok.IsKeyword("MERGE") && queryType == QueryTypeUpdate
+
+				if tok.IsKeyword("REPLACE") && queryType == QueryTypeInsert {
+					// REPLACE deletes the rows it conflicts with
+					ops = append(ops, operation{Type: QueryTypeDelete, Index: i, Depth: depth, Implied: true})
+				}
+
+				// EXPLAIN ANALYZE executes the explained statement
+				if tok.IsKeyword("EXPLAIN", "DESCRIBE", "DESC") {
+					explainOptionsEnd = closingParen(tokens, i+1)
+					if body := explainBody(tokens, i, dialect); body >= 0 {
+						op := operation{Type: statementType(tokens, body, dialect), Index: body, Depth: depth}
+						if tokens[body].IsKeyword("WITH") {
+							op.Type = QueryTypeSelect
+						}
+						ops = append(ops, op)
+					}
+				}
+			}
+		} else if tok.Kind == TokenWord && i > 0 && (tokens[i-1].IsPunct("(") || tokens[i-1].IsPunct(")") || tokens[i-1].IsKeyword("THEN")) {
+			// The statement after an EXPLAIN option list is classified above
+			if queryType, ok := nestedKeywords[tok.Upper()]; ok && i-1 != explainOptionsEnd {
+				implied := tokens[i-1].IsKeyword("THEN") && merge
+				ops = append(ops, operation{Type: queryType, Index: i, Depth: depth, Implied: implied})
+			} else if tok.IsKeyword("MERGE") && tokens[i-1].IsPunct(")") && dialect != DialectMySQL {
+				// MERGE following a WITH list
+				merge = true
+				ops = append(ops, operation{Type: QueryTypeUpdate, Index: i, Depth: depth})
+			}
+		}
+
+		// SELECT ... INTO creates a table in PostgreSQL and writes a file
+		// with INTO OUTFILE/DUMPFILE in MySQL. Only an INTO belonging to a
+		// SELECT at the same depth counts, not that of INSERT or MERGE.
+		if tok.IsKeyword("INTO") && owners[depth] == "SELECT" {
+			if isSelectInto(tokens, i, dialect) {
+				ops = append(ops, operation{Type: QueryTypeDDL, Index: i, Depth: depth})
+			}
+		}
+
+		if !tok.IsPunct("(") {
+			statementStart = false
+		}
 	}
-	if strings.HasPrefix(query, "UPDATE") {
-		return QueryTypeUpdate
+
+	return ops
+}
+
+// closingParen returns the index of the parenthesis closing the one at
+// tokens[open], or -1 when tokens[open] is not an opening parenthesis
+func closingParen(tokens []Token, open int) int {
+	if open >= len(tokens) || !tokens[open].IsPunct("(") {
+		return -1
 	}
-	if strings.HasPrefix(query, "DELETE") {
-		return QueryTypeDelete
+	depth := 0
+	for i := open; i < len(tokens); i++ {
+		if tokens[i].IsPunct("(") {
+			depth++
+		} else if tokens[i].IsPunct(")") {
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
 	}
+	return -1
+}
 
-	return QueryTypeOther
+func isSelectInto(tokens []Token, into int, dialect Dialect) bool {
+	if dialect != DialectMySQL {
+		return true
+	}
+	return into+1 < len(tokens) && tokens[into+1].IsKeyword("OUTFILE", "DUMPFILE")
 }
 
-// IsDDLQuery checks if the query is a DDL operation
-func IsDDLQuery(query string) bool {
-	query = strings.TrimSpace(strings.ToUpper(query))
-	
-	ddlKeywords := []string{
-		"CREATE", "DROP", "ALTER", "TRUNCATE",
-		"RENAME", "COMMENT",
+// analyze tokenizes the query and returns its operations. Generic queries are
+// tokenized with both MySQL and PostgreSQL rules so that neither dialect's
+// quoting can be used to hide a statement, and classified with the keywords
+// of both dialects.
+func analyze(query string, dialect Dialect) []operation {
+	if dialect == DialectGeneric {
+		var ops []operation
+		for _, lexical := range []Dialect{DialectMySQL, DialectPostgres} {
+			ops = append(ops, findOperations(significant(Tokenize(query, lexical)), DialectGeneric)...)
+		}
+		return ops
 	}
+	tokens := significant(Tokenize(query, dialect))
+	return findOperations(tokens, dialect)
+}
 
-	for _, keyword := range ddlKeywords {
-		if strings.HasPrefix(query, keyword) {
-			return true
+// GetQueryType determines the type of SQL query. When a query contains
+// several operations, e.g. a DELETE inside a CTE of a SELECT, the most
+// privileged one is returned.
+func GetQueryType(query string, dialect Dialect) QueryType {
+	result := QueryTypeOther
+	for _, op := range analyze(query, dialect) {
+		if op.Implied {
+			continue
+		}
+		if privilegeRank[op.Type] > privilegeRank[result] {
+			result = op.Type
 		}
 	}
+	return result
+}
 
-	return false
+// IsDDLQuery checks if the query is a DDL operation
+func IsDDLQuery(query string, dialect Dialect) bool {
+	return GetQueryType(query, dialect) == QueryTypeDDL
 }
 
 // IsWriteQuery checks if the query is a write operation (INSERT or UPDATE)
-func IsWriteQuery(query string) bool {
-	queryType := GetQueryType(query)
+func IsWriteQuery(query string, dialect Dialect) bool {
+	queryType := GetQueryType(query, dialect)
 	return queryType == QueryTypeInsert || queryType == QueryTypeUpdate
 }
 
 // IsReadQuery checks if the query is a read operation (SELECT)
-func IsReadQuery(query string) bool {
-	return GetQueryType(query) == QueryTypeSelect
+func IsReadQuery(query string, dialect Dialect) bool {
+	return GetQueryType(query, dialect) == QueryTypeSelect
 }
 
 // IsDeleteQuery checks if the query is a DELETE operation
-func IsDeleteQuery(query string) bool {
-	return GetQueryType(query) == QueryTypeDelete
+func IsDeleteQuery(query string, dialect Dialect) bool {
+	return GetQueryType(query, dialect) == QueryTypeDelete
 }
 
-// RequiresPermission checks what permission is required for the query
-func RequiresPermission(query string) (ddl, write, read, delete bool) {
-	queryType := GetQueryType(query)
+// ReturnsRows reports whether executing the query produces a result set:
+// reads, SHOW/DESCRIBE/EXPLAIN, and data-modifying statements with a
+// RETURNING clause. Other statements only report affected rows.
+func ReturnsRows(query string, dialect Dialect) bool {
+	tokens := significant(Tokenize(query, dialect))
+	if len(tokens) == 0 {
+		return false
+	}
+	if tokens[0].IsKeyword("SHOW", "DESCRIBE", "DESC", "EXPLAIN", "VALUES", "TABLE") {
+		return true
+	}
+	for _, tok := range tokens {
+		if tok.IsKeyword("RETURNING") {
+			return true
+		}
+	}
+	return GetQueryType(query, dialect) == QueryTypeSelect
+}
 
-	switch queryType {
-	case QueryTypeDDL:
-		ddl = true
-	case QueryTypeInsert, QueryTypeUpdate:
-		write = true
-	case QueryTypeSelect:
-		read = true
-	case QueryTypeDelete:
-		delete = true
+// RequiredPermissions lists the permission categories a query needs
+type RequiredPermissions struct {
+	DDL         bool `json:"ddl"`
+	Write       bool `json:"write"`
+	Read        bool `json:"read"`
+	Delete      bool `json:"delete"`
+	DCL         bool `json:"dcl"`
+	Transaction bool `json:"transaction"`
+	Call        bool `json:"call"`
+	BulkLoad    bool `json:"bulk_load"`
+	Admin       bool `json:"admin"`
+	// Unknown is set when a statement could not be classified. Such queries
+	// must be denied.
+	Unknown bool `json:"unknown"`
+}
+
+// Merge adds the requirements of other to r
+func (r *RequiredPermissions) Merge(other RequiredPermissions) {
+	r.DDL = r.DDL || other.DDL
+	r.Write = r.Write || other.Write
+	r.Read = r.Read || other.Read
+	r.Delete = r.Delete || other.Delete
+	r.DCL = r.DCL || other.DCL
+	r.Transaction = r.Transaction || other.Transaction
+	r.Call = r.Call || other.Call
+	r.BulkLoad = r.BulkLoad || other.BulkLoad
+	r.Admin = r.Admin || other.Admin
+	r.Unknown = r.Unknown || other.Unknown
+}
+
+// RequiresPermission checks what permission is required for the query. Every
+// operation anywhere in the query contributes to the result. Bulk loads also
+// require write permission.
+func RequiresPermission(query string, dialect Dialect) RequiredPermissions {
+	var required RequiredPermissions
+
+	ops := analyze(query, dialect)
+	if len(ops) == 0 {
+		required.Unknown = true
 	}
 
-	return
+	for _, op := range ops {
+		switch op.Type {
+		case QueryTypeDDL:
+			required.DDL = true
+		case QueryTypeInsert, QueryTypeUpdate:
+			required.Write = true
+		case QueryTypeSelect:
+			required.Read = true
+		case QueryTypeDelete:
+			required.Delete = true
+		case QueryTypeDCL:
+			required.DCL = true
+		case QueryTypeTCL:
+			required.Transaction = true
+		case QueryTypeCall:
+			required.Call = true
+		case QueryTypeBulkLoad:
+			required.BulkLoad = true
+			required.Write = true
+		case QueryTypeAdmin:
+			required.Admin = true
+		default:
+			required.Unknown = true
+		}
+	}
+
+	return required
+}
+
+// ModifiesRows reports whether the query updates or deletes rows anywhere,
+// e.g. in a CTE body of a SELECT, and so is subject to the WHERE and
+// affected-row guardrails. Changes only implied by another statement, like
+// the deletes of a REPLACE, do not count.
+func ModifiesRows(query string, dialect Dialect) bool {
+	for _, op := range analyze(query, dialect) {
+		if !op.Implied && (op.Type == QueryTypeUpdate || op.Type == QueryTypeDelete) {
+			return true
+		}
+	}
+	return false
 }
 
-// removeComments removes SQL comments from the query
-func removeComments(query string) string {
-	// Remove single-line comments (-- comment)
-	singleLineComment := regexp.MustCompile(`--[^\n]*`)
-	query = singleLineComment.ReplaceAllString(query, "")
+// HasWhereClause reports whether every UPDATE and DELETE in the query has its
+// own WHERE clause. For other queries it reports whether any WHERE is present.
+func HasWhereClause(query string, dialect Dialect) bool {
+	if dialect == DialectGeneric {
+		return HasWhereClause(query, DialectMySQL) && HasWhereClause(query, DialectPostgres)
+	}
+
+	tokens := significant(Tokenize(query, dialect))
+	ops := findOperations(tokens, dialect)
 
-	// Remove multi-line comments (/* comment */)
-	multiLineComment := regexp.MustCompile(`/\*[\s\S]*?\*/`)
-	query = multiLineComment.ReplaceAllString(query, "")
+	checked := false
+	for _, op := range ops {
+		if op.Type != QueryTypeUpdate && op.Type != QueryTypeDelete {
+			continue
+		}
+		if op.Implied {
+			continue
+		}
+		checked = true
+		// MERGE is scoped by its ON condition
+		if tokens[op.Index].IsKeyword("MERGE") {
+			continue
+		}
+		if !statementHasWhere(tokens, op) {
+			return false
+		}
+	}
+	if checked {
+		return true
+	}
 
-	return strings.TrimSpace(query)
+	for _, tok := range tokens {
+		if tok.IsKeyword("WHERE") {
+			return true
+		}
+	}
+	return false
+}
+
+// statementHasWhere looks for a WHERE at the operation's own depth, stopping
+// at the end of the enclosing parentheses or statement
+func statementHasWhere(tokens []Token, op operation) bool {
+	depth := op.Depth
+	for _, tok := range tokens[op.Index+1:] {
+		switch {
+		case tok.IsPunct("("):
+			depth++
+		case tok.IsPunct(")"):
+			depth--
+			if depth < op.Depth {
+				return false
+			}
+		case tok.IsPunct(";"):
+			return false
+		case depth == op.Depth && tok.IsKeyword("WHERE"):
+			return true
+		}
+	}
+	return false
 }
 
 // GetQueryDescription returns a human-readable description of the query type
-func GetQueryDescription(query string) string {
-	queryType := GetQueryType(query)
+func GetQueryDescription(query string, dialect Dialect) string {
+	queryType := GetQueryType(query, dialect)
 
 	descriptions := map[QueryType]string{
-		QueryTypeSelect: "Data retrieval (SELECT)",
-		QueryTypeInsert: "Data insertion (INSERT)",
-		QueryTypeUpdate: "Data modification (UPDATE)",
-		QueryTypeDelete: "Data deletion (DELETE)",
-		QueryTypeDDL:    "Schema modification (DDL)",
-		QueryTypeOther:  "Other operation",
+		QueryTypeSelect:   "Data retrieval (SELECT)",
+		QueryTypeInsert:   "Data insertion (INSERT)",
+		QueryTypeUpdate:   "Data modification (UPDATE)",
+		QueryTypeDelete:   "Data deletion (DELETE)",
+		QueryTypeDDL:      "Schema modification (DDL)",
+		QueryTypeDCL:      "Access control (GRANT/REVOKE)",
+		QueryTypeTCL:      "Transaction control",
+		QueryTypeCall:     "Procedure call",
+		QueryTypeBulkLoad: "Bulk data load (COPY/LOAD DATA)",
+		QueryTypeAdmin:    "Administrative operation",
+		QueryTypeOther:    "Unrecognized operation",
 	}
 
 	return descriptions[queryType]
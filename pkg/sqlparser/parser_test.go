@@ -0,0 +1,70 @@
+package sqlparser
+
+import (
+	"testing"
+)
+
+func TestModifiesRowsAndHasWhereClause(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    string
+		dialect  Dialect
+		modifies bool
+		hasWhere bool
+	}{
+		{"select", "SELECT * FROM orders", DialectPostgres, false, false},
+		{"update with where", "UPDATE orders SET paid = true WHERE id = 1", DialectPostgres, true, true},
+		{"delete without where", "DELETE FROM orders", DialectPostgres, true, false},
+		{"delete in cte with select into", "WITH d AS (DELETE FROM orders RETURNING *) SELECT * INTO backup FROM d", DialectPostgres, true, false},
+		{"delete in cte with select into, generic", "WITH d AS (DELETE FROM orders RETURNING *) SELECT * INTO backup FROM d", DialectGeneric, true, false},
+		{"delete in cte with outer where", "WITH d AS (DELETE FROM orders RETURNING *) SELECT * FROM d WHERE id = 1", DialectPostgres, true, false},
+		{"delete in cte with where", "WITH d AS (DELETE FROM orders WHERE id = 1 RETURNING *) SELECT * FROM d", DialectPostgres, true, true},
+		{"update in subquery where", "SELECT * FROM (UPDATE t SET a = 1 RETURNING *) u", DialectPostgres, true, false},
+		{"replace implies delete", "REPLACE INTO t (id) VALUES (1)", DialectMySQL, false, false},
+		{"insert", "INSERT INTO t (id) VALUES (1)", DialectMySQL, false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ModifiesRows(tt.query, tt.dialect); got != tt.modifies {
+				t.Errorf("ModifiesRows(%q) = %v, want %v", tt.query, got, tt.modifies)
+			}
+			if !tt.modifies {
+				return
+			}
+			if got := HasWhereClause(tt.query, tt.dialect); got != tt.hasWhere {
+				t.Errorf("HasWhereClause(%q) = %v, want %v", tt.query, got, tt.hasWhere)
+			}
+		})
+	}
+}
+
+func TestSelectInto(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   string
+		dialect Dialect
+		want    QueryType
+		ddl     bool
+	}{
+		{"select into", "SELECT * INTO backup FROM orders", DialectPostgres, QueryTypeDDL, true},
+		{"select into after cte", "WITH s AS (SELECT 1 AS id) SELECT * INTO backup FROM s", DialectPostgres, QueryTypeDDL, true},
+		{"explain analyze select into", "EXPLAIN ANALYZE SELECT * INTO backup FROM orders", DialectPostgres, QueryTypeDDL, true},
+		{"merge after cte", "WITH s AS (SELECT 1 AS id) MERGE INTO t USING s ON t.id = s.id WHEN MATCHED THEN DELETE", DialectPostgres, QueryTypeUpdate, false},
+		{"insert after cte", "WITH s AS (SELECT 1 AS id) INSERT INTO t SELECT * FROM s", DialectPostgres, QueryTypeInsert, false},
+		{"insert select", "INSERT INTO t SELECT * FROM u", DialectPostgres, QueryTypeInsert, false},
+		{"mysql into variable", "SELECT id INTO @id FROM t", DialectMySQL, QueryTypeSelect, false},
+		{"mysql into outfile", "SELECT * FROM t INTO OUTFILE '/tmp/t'", DialectMySQL, QueryTypeDDL, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := GetQueryType(tt.query, tt.dialect); got != tt.want {
+				t.Errorf("GetQueryType(%q) = %s, want %s", tt.query, got, tt.want)
+			}
+			if got := RequiresPermission(tt.query, tt.dialect).DDL; got != tt.ddl {
+				t.Errorf("RequiresPermission(%q).DDL = %v, want %v", tt.query, got, tt.ddl)
+			}
+		})
+	}
+}
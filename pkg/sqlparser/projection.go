@@ -0,0 +1,245 @@
+// pkg/sqlparser/projection.go
+package sqlparser
+
+import (
+	"strings"
+)
+
+// SelectItem is one expression of a select list or RETURNING clause
+type SelectItem struct {
+	// Names lists the lower-cased identifiers the item mentions, including
+	// those of subqueries inside it
+	Names []string
+	// Column is the column name when the item is a plain, unaliased column
+	// reference, so that the result column carries the source name
+	Column string
+	// Star is set for * and qualifier.*
+	Star bool
+}
+
+// Projection describes the select lists that produce a statement's result
+type Projection struct {
+	// Lists holds the lists whose items are the result columns in order:
+	// one per SELECT combined by UNION, INTERSECT or EXCEPT, or the
+	// RETURNING clause of a data-modifying statement
+	Lists [][]SelectItem
+	// Nested holds the items of subqueries whose rows reach the result
+	// through a derived table or CTE
+	Nested []SelectItem
+}
+
+// ExtractProjection returns the projection of the first statement in the
+// query. Like ExtractReferences it is heuristic and does not validate syntax.
+func ExtractProjection(query string, dialect Dialect) *Projection {
+	proj := &Projection{}
+	statements := SplitStatements(query, dialect)
+	if len(statements) == 0 {
+		return proj
+	}
+
+	p := &projParser{proj: proj}
+	p.levels = []*projLevel{{flows: true, top: true}}
+	for _, tok := range significant(Tokenize(statements[0].Text, dialect)) {
+		p.token(tok)
+	}
+	for len(p.levels) > 0 {
+		p.closeItem(p.level())
+		p.levels = p.levels[:len(p.levels)-1]
+	}
+	return proj
+}
+
+type projClause int
+
+const (
+	projNone   projClause = iota // tokens do not reach the result
+	projList                     // select list or RETURNING
+	projFrom                     // FROM and JOIN sources
+	projWith                     // CTE definitions
+)
+
+// projLevel is one parenthesis level
+type projLevel struct {
+	clause projClause
+	flows  bool // rows produced at this level reach the result
+	top    bool // select lists at this level are the result columns
+	dml    bool // level holds an INSERT, UPDATE, DELETE or MERGE
+	item   *projItem
+	list   int // index into Projection.Lists, or -1 for nested items
+}
+
+type projItem struct {
+	SelectItem
+	direct []Token // tokens at the item's own level
+	keep   bool    // item reaches the result
+}
+
+type projParser struct {
+	proj   *Projection
+	levels []*projLevel
+	prev   Token
+	seen   bool
+}
+
+func (p *projParser) level() *projLevel {
+	return p.levels[len(p.levels)-1]
+}
+
+func (p *projParser) token(tok Token) {
+	start := p.atStart()
+	defer func() { p.prev, p.seen = tok, true }()
+	l := p.level()
+
+	switch {
+	case tok.IsPunct("("):
+		p.addDirect(l, tok)
+		child := &projLevel{list: -1}
+		child.flows = l.flows && (l.clause == projFrom || l.clause == projWith)
+		if l.top && !l.dml && l.item == nil && (start || p.afterSetOperator()) {
+			// Parenthesised query combined by a set operation
+			child.flows, child.top = true, true
+		}
+		p.levels = append(p.levels, child)
+		return
+	case tok.IsPunct(")"):
+		if len(p.levels) == 1 {
+			return
+		}
+		p.closeItem(l)
+		p.levels = p.levels[:len(p.levels)-1]
+		p.addDirect(p.level(), tok)
+		return
+	case tok.IsPunct(",") && l.item != nil:
+		p.closeItem(l)
+		p.openItem(l)
+		return
+	case tok.Kind == TokenWord && p.clauseKeyword(l, tok, start):
+		return
+	}
+
+	p.addDirect(l, tok)
+	if tok.Kind == TokenWord && !reservedWords[tok.Upper()] || tok.Kind == TokenQuotedIdent {
+		name := strings.ToLower(unquoteIdent(tok.Text))
+		for _, level := range p.levels {
+			if level.item != nil {
+				level.item.Names = append(level.item.Names, name)
+			}
+		}
+	}
+}
+
+// clauseKeyword handles words that start or end a select list and reports
+// whether the word was consumed
+func (p *projParser) clauseKeyword(l *projLevel, tok Token, start bool) bool {
+	switch tok.Upper() {
+	case "SELECT":
+		p.closeItem(l)
+		l.clause = projList
+		l.list = -1
+		if l.top && !l.dml {
+			p.proj.Lists = append(p.proj.Lists, nil)
+			l.list = len(p.proj.Lists) - 1
+		}
+		p.openItem(l)
+		return true
+	case "RETURNING":
+		p.closeItem(l)
+		l.clause = projList
+		l.list = -1
+		if l.top {
+			p.proj.Lists = append(p.proj.Lists, nil)
+			l.list = len(p.proj.Lists) - 1
+		}
+		p.openItem(l)
+		return true
+	case "DISTINCT", "ALL":
+		if l.item != nil && len(l.item.direct) == 0 {
+			return true
+		}
+	case "FROM", "JOIN", "STRAIGHT_JOIN", "USING":
+		p.closeItem(l)
+		l.clause = projFrom
+		return true
+	case "WITH":
+		p.closeItem(l)
+		l.clause = projWith
+		return true
+	case "INSERT", "UPDATE", "DELETE", "MERGE", "REPLACE":
+		if start {
+			l.dml = true
+			l.clause = projNone
+			return true
+		}
+	case "WHERE", "GROUP", "HAVING", "ORDER", "LIMIT", "OFFSET", "FETCH", "WINDOW", "ON",
+		"SET", "VALUES", "INTO", "FOR", "UNION", "INTERSECT", "EXCEPT", "MINUS":
+		if tok.IsKeyword("ON") && p.prev.IsKeyword("DISTINCT") {
+			// SELECT DISTINCT ON (...) belongs to the first item
+			return false
+		}
+		p.closeItem(l)
+		l.clause = projNone
+		return true
+	}
+	return false
+}
+
+// atStart reports whether the next token starts a statement at its level
+func (p *projParser) atStart() bool {
+	return !p.seen || p.prev.IsPunct("(") || p.prev.IsPunct(")")
+}
+
+func (p *projParser) afterSetOperator() bool {
+	return p.prev.IsKeyword("UNION", "INTERSECT", "EXCEPT", "MINUS", "ALL", "DISTINCT")
+}
+
+func (p *projParser) openItem(l *projLevel) {
+	l.item = &projItem{keep: l.list >= 0 || (l.flows && !l.top)}
+}
+
+func (p *projParser) addDirect(l *projLevel, tok Token) {
+	if l.item != nil {
+		l.item.direct = append(l.item.direct, tok)
+	}
+}
+
+// closeItem finishes the level's open item and records it when its values
+// reach the result
+func (p *projParser) closeItem(l *projLevel) {
+	item := l.item
+	l.item = nil
+	if item == nil || !item.keep || (len(item.direct) == 0 && len(item.Names) == 0) {
+		return
+	}
+
+	item.Star, item.Column = classifyItem(item.direct)
+	if l.list >= 0 {
+		p.proj.Lists[l.list] = append(p.proj.Lists[l.list], item.SelectItem)
+		return
+	}
+	p.proj.Nested = append(p.proj.Nested, item.SelectItem)
+}
+
+// classifyItem recognises *, qualifier.* and plain column references such
+// as name, t.name and s.t.name
+func classifyItem(tokens []Token) (star bool, column string) {
+	if len(tokens)%2 == 0 {
+		return false, ""
+	}
+	for i, tok := range tokens {
+		if i%2 == 1 {
+			if !tok.IsPunct(".") {
+				return false, ""
+			}
+			continue
+		}
+		last := i == len(tokens)-1
+		switch {
+		case tok.Kind == TokenOperator && tok.Text == "*" && last:
+			return true, ""
+		case tok.Kind == TokenWord && !reservedWords[tok.Upper()], tok.Kind == TokenQuotedIdent:
+		default:
+			return false, ""
+		}
+	}
+	return false, strings.ToLower(unquoteIdent(tokens[len(tokens)-1].Text))
+}
@@ -0,0 +1,58 @@
+package sqlparser
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExtractProjection(t *testing.T) {
+	tests := []struct {
+		name   string
+		query  string
+		lists  [][]string // per list, the Column of each item, "*" for stars and "?" for expressions
+		nested []string
+	}{
+		{"plain", "SELECT id, u.name FROM users u", [][]string{{"id", "name"}}, nil},
+		{"alias and expression", "SELECT ssn AS x, upper(name) FROM users", [][]string{{"?", "?"}}, nil},
+		{"distinct", "SELECT DISTINCT id FROM users", [][]string{{"id"}}, nil},
+		{"star", "SELECT u.*, 1 FROM users u", [][]string{{"*", "?"}}, nil},
+		{"derived table", "SELECT * FROM (SELECT ssn AS x FROM users) s", [][]string{{"*"}}, []string{"?"}},
+		{"cte", "WITH c AS (SELECT id FROM users) SELECT id FROM c", [][]string{{"id"}}, []string{"id"}},
+		{"where subquery", "SELECT id FROM users WHERE id IN (SELECT user_id FROM orders)", [][]string{{"id"}}, nil},
+		{"union", "SELECT a FROM t UNION ALL SELECT b FROM u", [][]string{{"a"}, {"b"}}, nil},
+		{"parenthesised union", "(SELECT a FROM t) UNION (SELECT b FROM u)", [][]string{{"a"}, {"b"}}, nil},
+		{"insert select", "INSERT INTO t SELECT a FROM u", nil, nil},
+		{"returning", "UPDATE t SET a = 1 RETURNING id, a + 1", [][]string{{"id", "?"}}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			proj := ExtractProjection(tt.query, DialectPostgres)
+			var lists [][]string
+			for _, items := range proj.Lists {
+				lists = append(lists, itemColumns(items))
+			}
+			if !reflect.DeepEqual(lists, tt.lists) {
+				t.Errorf("ExtractProjection(%q) lists = %v, want %v", tt.query, lists, tt.lists)
+			}
+			if nested := itemColumns(proj.Nested); !reflect.DeepEqual(nested, tt.nested) {
+				t.Errorf("ExtractProjection(%q) nested = %v, want %v", tt.query, nested, tt.nested)
+			}
+		})
+	}
+}
+
+func itemColumns(items []SelectItem) []string {
+	var columns []string
+	for _, item := range items {
+		switch {
+		case item.Star:
+			columns = append(columns, "*")
+		case item.Column == "":
+			columns = append(columns, "?")
+		default:
+			columns = append(columns, item.Column)
+		}
+	}
+	return columns
+}
@@ -0,0 +1,1010 @@
+// pkg/sqlparser/references.go
+package sqlparser
+
+import (
+	"strings"
+)
+
+// AccessMode describes how a statement uses a table or column
+type AccessMode string
+
+const (
+	AccessRead   AccessMode = "read"
+	AccessWrite  AccessMode = "write"
+	AccessDelete AccessMode = "delete"
+	AccessAlter  AccessMode = "alter"
+)
+
+// TableRef is a table referenced by a statement
+type TableRef struct {
+	Schema string     `json:"schema,omitempty"`
+	Name   string     `json:"name"`
+	Alias  string     `json:"alias,omitempty"`
+	Access AccessMode `json:"access"`
+}
+
+// ColumnRef is a column referenced by a statement. Table is empty when the
+// column cannot be attributed to a single table.
+type ColumnRef struct {
+	Table  string     `json:"table,omitempty"`
+	Name   string     `json:"name"`
+	Access AccessMode `json:"access"`
+}
+
+// References lists the objects a query touches
+type References struct {
+	Tables  []TableRef  `json:"tables"`
+	Columns []ColumnRef `json:"columns"`
+	CTEs    []string    `json:"ctes,omitempty"`
+}
+
+// ExtractReferences returns the tables and columns referenced by every
+// statement in the query along with how each one is accessed. CTE names and
+// derived tables are resolved and not reported as tables.
+//
+// The extraction is heuristic: it understands the common shapes of SELECT,
+// INSERT, UPDATE, DELETE, MERGE and DDL statements for MySQL and PostgreSQL
+// but does not validate syntax.
+func ExtractReferences(query string, dialect Dialect) *References {
+	refs := &References{Tables: []TableRef{}, Columns: []ColumnRef{}}
+	for _, statement := range SplitStatements(query, dialect) {
+		p := &refParser{tokens: significant(Tokenize(statement.Text, dialect)), ctes: map[string]bool{}, aliases: map[string]int{}}
+		p.parse()
+		p.mergeInto(refs)
+	}
+	return refs
+}
+
+type refClause int
+
+const (
+	clauseNone refClause = iota // identifiers are not columns (DDL options, keywords)
+	clauseExpr                  // expression context: identifiers are read columns
+	clauseFrom                  // table list
+	clauseSet                   // assignment list: targets are written
+)
+
+type columnUse struct {
+	qualifier string
+	name      string
+	access    AccessMode
+}
+
+type refTable struct {
+	ref     TableRef
+	virtual bool // CTE or derived table
+}
+
+type refFrame struct {
+	op            string // keyword of the statement owning the frame
+	clause        refClause
+	tableAccess   AccessMode // access for tables read in a FROM list
+	function      bool       // frame is a function call's argument list
+	derived       bool       // frame is a subquery in a FROM list
+	tables        []int
+	columns       []columnUse
+	setTarget     bool
+	deleteTargets map[string]bool
+}
+
+type refParser struct {
+	tokens    []Token
+	i         int
+	frames    []*refFrame
+	tables    []refTable
+	qualified []columnUse
+	resolved  []ColumnRef
+	ctes      map[string]bool
+	cteOrder  []string
+	aliases   map[string]int
+}
+
+// reservedWords are never treated as column names or aliases
+var reservedWords = map[string]bool{
+	"ALL": true, "AND": true, "ANY": true, "AS": true, "ASC": true, "BETWEEN": true, "BOTH": true, "BY": true,
+	"CASE": true, "CAST": true, "CHECK": true, "COLLATE": true, "CONSTRAINT": true, "CROSS": true,
+	"CURRENT_DATE": true, "CURRENT_TIME": true, "CURRENT_TIMESTAMP": true, "CURRENT_USER": true,
+	"DEFAULT": true, "DELETE": true, "DESC": true, "DISTINCT": true, "DO": true, "ELSE": true, "END": true,
+	"ESCAPE": true, "EXCEPT": true, "EXISTS": true, "FALSE": true, "FETCH": true, "FOR": true, "FOREIGN": true,
+	"FROM": true, "FULL": true, "GROUP": true, "HAVING": true, "ILIKE": true, "IN": true, "INNER": true,
+	"INSERT": true, "INTERSECT": true, "INTERVAL": true, "INTO": true, "IS": true, "JOIN": true, "LATERAL": true,
+	"LEADING": true, "LEFT": true, "LIKE": true, "LIMIT": true, "LOCALTIME": true, "LOCALTIMESTAMP": true,
+	"MATCHED": true, "MERGE": true, "NATURAL": true, "NOT": true, "NULL": true, "NULLS": true, "OFFSET": true,
+	"ON": true, "ONLY": true, "OR": true, "ORDER": true, "OUTER": true, "OVER": true, "PARTITION": true,
+	"PRIMARY": true, "REFERENCES": true, "REGEXP": true, "RETURNING": true, "RIGHT": true, "RLIKE": true,
+	"SELECT": true, "SET": true, "SIMILAR": true, "SOME": true, "STRAIGHT_JOIN": true, "TABLE": true,
+	"THEN": true, "TO": true, "TRAILING": true, "TRUE": true, "UNION": true, "UNIQUE": true, "UNKNOWN": true,
+	"UPDATE": true, "USING": true, "VALUES": true, "WHEN": true, "WHERE": true, "WINDOW": true, "WITH": true,
+	"XOR": true, "DIV": true, "MOD": true,
+}
+
+// clauseWords end an implicit alias position
+var clauseWords = map[string]bool{
+	"WHERE": true, "JOIN": true, "INNER": true, "LEFT": true, "RIGHT": true, "FULL": true, "CROSS": true,
+	"NATURAL": true, "STRAIGHT_JOIN": true, "ON": true, "USING": true, "GROUP": true, "ORDER": true,
+	"HAVING": true, "LIMIT": true, "OFFSET": true, "FETCH": true, "UNION": true, "EXCEPT": true,
+	"INTERSECT": true, "WINDOW": true, "RETURNING": true, "SET": true, "VALUES": true, "FOR": true,
+	"LOCK": true, "SELECT": true, "WHEN": true, "INTO": true, "TABLESAMPLE": true, "PARTITION": true,
+	"FORCE": true, "IGNORE": true, "USE": true, "LATERAL": true, "DEFAULT": true,
+}
+
+func (p *refParser) peek(offset int) Token {
+	if p.i+offset < len(p.tokens) && p.i+offset >= 0 {
+		return p.tokens[p.i+offset]
+	}
+	return Token{Kind: TokenPunct}
+}
+
+func (p *refParser) frame() *refFrame {
+	return p.frames[len(p.frames)-1]
+}
+
+func (p *refParser) push(f *refFrame) {
+	p.frames = append(p.frames, f)
+}
+
+func (p *refParser) parse() {
+	p.push(&refFrame{clause: clauseNone})
+	for p.i < len(p.tokens) {
+		tok := p.tokens[p.i]
+		switch {
+		case tok.IsPunct("("):
+			p.openParen()
+		case tok.IsPunct(")"):
+			p.closeParen()
+		case tok.IsPunct(","):
+			p.comma()
+		case tok.Kind == TokenWord && p.keyword(tok.Upper()):
+		case tok.Kind == TokenWord || tok.Kind == TokenQuotedIdent:
+			p.identifier()
+		case tok.Kind == TokenOperator && tok.Text == "::":
+			// Skip the type name of a PostgreSQL cast
+			p.i += 2
+		case tok.Kind == TokenOperator && tok.Text == "=" && p.frame().clause == clauseSet:
+			p.frame().setTarget = false
+			p.i++
+		default:
+			p.i++
+		}
+	}
+	for len(p.frames) > 1 {
+		p.popFrame()
+	}
+	p.resolveFrame(p.frames[0])
+	p.resolveQualified()
+}
+
+func (p *refParser) openParen() {
+	parent := p.frame()
+	prev := p.peek(-1)
+	next := p.peek(1)
+	p.i++
+
+	if next.IsKeyword("SELECT", "WITH", "VALUES", "TABLE") {
+		p.push(&refFrame{clause: clauseNone, derived: parent.clause == clauseFrom, tableAccess: AccessRead})
+		return
+	}
+
+	isCall := (prev.Kind == TokenWord && !reservedWords[prev.Upper()]) || prev.IsKeyword("CAST", "EXISTS", "IN", "ANY", "ALL", "SOME", "VALUES", "OVER", "FILTER", "WITHIN")
+	clause := parent.clause
+	if isCall || clause == clauseFrom || clause == clauseSet {
+		clause = clauseExpr
+	}
+	p.push(&refFrame{op: parent.op, clause: clause, function: isCall, tableAccess: parent.tableAccess, deleteTargets: parent.deleteTargets})
+
+	// EXTRACT(field FROM source): the field name is not a column
+	if prev.IsKeyword("EXTRACT") && p.peek(1).IsKeyword("FROM") {
+		p.i++
+	}
+}
+
+func (p *refParser) closeParen() {
+	p.i++
+	if len(p.frames) == 1 {
+		return
+	}
+	f := p.popFrame()
+	if f.derived {
+		// Derived table: register its alias so qualified columns resolve
+		index := p.addTable(TableRef{Access: AccessRead}, true)
+		p.readAlias(index)
+		p.frame().tables = append(p.frame().tables, index)
+	}
+}
+
+func (p *refParser) popFrame() *refFrame {
+	f := p.frame()
+	p.frames = p.frames[:len(p.frames)-1]
+
+	if len(f.tables) > 0 {
+		p.resolveFrame(f)
+	} else {
+		// Expression parentheses resolve against the enclosing statement
+		parent := p.frame()
+		parent.columns = append(parent.columns, f.columns...)
+	}
+	return f
+}
+
+func (p *refParser) comma() {
+	f := p.frame()
+	p.i++
+	switch f.clause {
+	case clauseFrom:
+		p.readTableRef(p.fromAccess(f))
+	case clauseSet:
+		f.setTarget = true
+	}
+}
+
+// keyword handles clause-changing keywords. It returns false when the word
+// is not a keyword of interest so that it can be treated as an identifier.
+func (p *refParser) keyword(word string) bool {
+	f := p.frame()
+	prev := p.peek(-1)
+	atStart := p.i == 0 || prev.IsPunct("(") || prev.IsPunct(")") || prev.IsKeyword("THEN")
+
+	switch word {
+	case "WITH":
+		p.i++
+		p.readCTEs()
+	case "SELECT":
+		if f.op == "" || atStart {
+			if f.op == "" {
+				f.op = "SELECT"
+			}
+		}
+		f.clause = clauseExpr
+		f.tableAccess = AccessRead
+		p.i++
+	case "FROM":
+		if f.function {
+			p.i++
+			return true
+		}
+		f.clause = clauseFrom
+		p.i++
+		if f.op == "DELETE" && f.deleteTargets == nil {
+			p.readTableRef(AccessDelete)
+			f.tableAccess = AccessDelete
+		} else {
+			p.readTableRef(p.fromAccess(f))
+		}
+	case "JOIN", "STRAIGHT_JOIN":
+		f.clause = clauseFrom
+		p.i++
+		p.readTableRef(p.fromAccess(f))
+		f.tableAccess = AccessRead
+		if f.deleteTargets != nil {
+			f.tableAccess = AccessRead
+		}
+	case "USING":
+		p.i++
+		if p.peek(0).IsPunct("(") {
+			f.clause = clauseExpr
+			return true
+		}
+		// DELETE ... USING and MERGE ... USING list source tables
+		f.clause = clauseFrom
+		f.tableAccess = AccessRead
+		p.readTableRef(AccessRead)
+	case "WHERE", "ON", "HAVING", "RETURNING", "BY", "WHEN", "LIMIT", "OFFSET":
+		if f.function {
+			p.i++
+			return true
+		}
+		if word == "ON" && prev.IsKeyword("DUPLICATE") {
+			p.i++
+			return true
+		}
+		f.clause = clauseExpr
+		f.tableAccess = AccessRead
+		p.i++
+	case "SET":
+		if f.op == "UPDATE" || f.op == "INSERT" || f.op == "MERGE" {
+			f.clause = clauseSet
+			f.setTarget = true
+		}
+		p.i++
+	case "INSERT", "REPLACE":
+		if !atStart && word == "REPLACE" {
+			return false
+		}
+		if !atStart && !prev.IsKeyword("THEN") {
+			p.i++
+			return true
+		}
+		if f.op == "" || atStart {
+			f.op = "INSERT"
+		}
+		p.i++
+		p.skipWords("LOW_PRIORITY", "DELAYED", "HIGH_PRIORITY", "IGNORE", "INTO")
+		if f.op == "MERGE" || prev.IsKeyword("THEN") {
+			// MERGE ... THEN INSERT (cols): columns belong to the MERGE target
+			p.readColumnList(p.mergeTarget(), AccessWrite)
+			return true
+		}
+		index := p.readTableRef(AccessWrite)
+		p.readColumnList(index, AccessWrite)
+		f.clause = clauseNone
+	case "UPDATE":
+		switch {
+		case prev.IsKeyword("KEY"), prev.IsKeyword("DO"):
+			// ON DUPLICATE KEY UPDATE / ON CONFLICT DO UPDATE
+			f.clause = clauseSet
+			f.setTarget = true
+			p.i++
+			if p.peek(0).IsKeyword("SET") {
+				p.i++
+			}
+		case prev.IsKeyword("THEN"):
+			// MERGE ... THEN UPDATE SET
+			p.i++
+		case atStart:
+			f.op = "UPDATE"
+			p.i++
+			p.skipWords("LOW_PRIORITY", "IGNORE", "ONLY")
+			p.readTableRef(AccessWrite)
+			f.clause = clauseFrom
+			f.tableAccess = AccessRead
+		default:
+			p.i++
+		}
+	case "DELETE":
+		p.i++
+		if !atStart {
+			return true
+		}
+		f.op = "DELETE"
+		p.skipWords("LOW_PRIORITY", "QUICK", "IGNORE")
+		if !p.peek(0).IsKeyword("FROM") {
+			// MySQL multi-table form: DELETE t1, t2 FROM t1 JOIN t2 ...
+			f.deleteTargets = map[string]bool{}
+			for p.i < len(p.tokens) && !p.peek(0).IsKeyword("FROM") {
+				tok := p.peek(0)
+				if tok.Kind == TokenWord || tok.Kind == TokenQuotedIdent {
+					f.deleteTargets[strings.ToLower(unquoteIdent(tok.Text))] = true
+				}
+				p.i++
+			}
+		}
+	case "MERGE":
+		if !atStart {
+			return false
+		}
+		f.op = "MERGE"
+		p.i++
+		p.skipWords("INTO")
+		p.readTableRef(AccessWrite)
+		f.clause = clauseNone
+	case "CREATE":
+		if !atStart {
+			return false
+		}
+		f.op = "CREATE"
+		p.i++
+		p.parseCreate()
+	case "ALTER":
+		if !atStart {
+			return false
+		}
+		f.op = "ALTER"
+		p.i++
+		p.parseAlter()
+	case "DROP":
+		if !atStart {
+			return false
+		}
+		f.op = "DROP"
+		p.i++
+		p.parseDrop()
+	case "TRUNCATE":
+		if !atStart {
+			return false
+		}
+		f.op = "TRUNCATE"
+		p.i++
+		p.skipWords("TABLE", "ONLY")
+		p.readTableList(AccessDelete)
+		f.clause = clauseNone
+	case "RENAME":
+		if !atStart {
+			return false
+		}
+		f.op = "RENAME"
+		p.i++
+		p.skipWords("TABLE", "TABLES")
+		for p.i < len(p.tokens) {
+			p.readTableName(AccessAlter)
+			p.skipWords("TO", "AS")
+			p.readTableName(AccessAlter)
+			if !p.peek(0).IsPunct(",") {
+				break
+			}
+			p.i++
+		}
+		f.clause = clauseNone
+	case "COMMENT":
+		if !atStart || !p.peek(1).IsKeyword("ON") {
+			return false
+		}
+		f.op = "COMMENT"
+		p.i += 2
+		switch {
+		case p.peek(0).IsKeyword("TABLE"):
+			p.i++
+			p.readTableName(AccessAlter)
+		case p.peek(0).IsKeyword("COLUMN"):
+			p.i++
+			parts := p.readQualifiedName()
+			if len(parts) >= 2 {
+				index := p.addTable(tableFromParts(parts[:len(parts)-1], AccessAlter), false)
+				p.addResolvedColumn(p.tables[index].ref.Name, parts[len(parts)-1], AccessAlter)
+			}
+		}
+		f.clause = clauseNone
+	case "AS":
+		// Output alias or cast target type
+		p.i += 2
+	case "VALUES":
+		f.clause = clauseExpr
+		p.i++
+	default:
+		if reservedWords[word] {
+			p.i++
+			return true
+		}
+		return false
+	}
+	return true
+}
+
+// fromAccess is the access mode of tables listed in the frame's FROM clause
+func (p *refParser) fromAccess(f *refFrame) AccessMode {
+	if f.tableAccess == "" {
+		return AccessRead
+	}
+	return f.tableAccess
+}
+
+func (p *refParser) identifier() {
+	f := p.frame()
+	parts := p.readQualifiedName()
+	if len(parts) == 0 {
+		p.i++
+		return
+	}
+
+	// Function calls are not columns
+	if p.peek(0).IsPunct("(") {
+		return
+	}
+
+	access := AccessRead
+	switch f.clause {
+	case clauseExpr:
+	case clauseSet:
+		if f.setTarget {
+			access = AccessWrite
+			f.setTarget = false
+		}
+	default:
+		return
+	}
+
+	name := parts[len(parts)-1]
+	if len(parts) == 1 {
+		f.columns = append(f.columns, columnUse{name: name, access: access})
+		return
+	}
+	p.qualified = append(p.qualified, columnUse{qualifier: parts[len(parts)-2], name: name, access: access})
+}
+
+// readQualifiedName consumes name[.name[.name]] and returns the unquoted parts.
+// A trailing .* is returned as "*".
+func (p *refParser) readQualifiedName() []string {
+	var parts []string
+	for {
+		tok := p.peek(0)
+		if tok.Kind != TokenWord && tok.Kind != TokenQuotedIdent && !(tok.Kind == TokenOperator && tok.Text == "*" && len(parts) > 0) {
+			break
+		}
+		if tok.Kind == TokenWord && reservedWords[tok.Upper()] && len(parts) == 0 {
+			break
+		}
+		parts = append(parts, unquoteIdent(tok.Text))
+		p.i++
+		if !p.peek(0).IsPunct(".") {
+			break
+		}
+		p.i++
+	}
+	return parts
+}
+
+// readTableRef reads a table name and optional alias and returns its index,
+// or -1 when the position holds a subquery or function instead
+func (p *refParser) readTableRef(access AccessMode) int {
+	return p.readTable(access, true)
+}
+
+// readTableName reads a DDL target, which never carries an alias
+func (p *refParser) readTableName(access AccessMode) int {
+	return p.readTable(access, false)
+}
+
+func (p *refParser) readTable(access AccessMode, withAlias bool) int {
+	p.skipWords("ONLY", "LATERAL", "IF", "NOT", "EXISTS")
+	if p.peek(0).IsPunct("(") {
+		return -1
+	}
+
+	parts := p.readQualifiedName()
+	if len(parts) == 0 {
+		return -1
+	}
+	if p.peek(0).IsPunct("(") && p.frame().clause == clauseFrom && access == AccessRead {
+		// Table function such as generate_series(...)
+		return -1
+	}
+
+	f := p.frame()
+	ref := tableFromParts(parts, access)
+	virtual := len(parts) == 1 && p.ctes[strings.ToLower(ref.Name)]
+	if f.deleteTargets != nil {
+		ref.Access = AccessRead
+	}
+
+	index := p.addTable(ref, virtual)
+	if withAlias {
+		p.readAlias(index)
+	}
+
+	if f.deleteTargets != nil {
+		t := &p.tables[index].ref
+		if f.deleteTargets[strings.ToLower(t.Name)] || (t.Alias != "" && f.deleteTargets[strings.ToLower(t.Alias)]) {
+			t.Access = AccessDelete
+		}
+	}
+
+	f.tables = append(f.tables, index)
+	return index
+}
+
+func (p *refParser) readTableList(access AccessMode) {
+	for p.i < len(p.tokens) {
+		p.readTableName(access)
+		if !p.peek(0).IsPunct(",") {
+			return
+		}
+		p.i++
+	}
+}
+
+func (p *refParser) readAlias(index int) {
+	tok := p.peek(0)
+	if tok.IsKeyword("AS") {
+		p.i++
+		tok = p.peek(0)
+	} else if tok.Kind == TokenWord && (reservedWords[tok.Upper()] || clauseWords[tok.Upper()]) {
+		return
+	}
+	if tok.Kind != TokenWord && tok.Kind != TokenQuotedIdent {
+		return
+	}
+
+	alias := unquoteIdent(tok.Text)
+	p.tables[index].ref.Alias = alias
+	p.aliases[strings.ToLower(alias)] = index
+	p.i++
+
+	// Column aliases of a derived table: AS t (a, b)
+	if p.peek(0).IsPunct("(") {
+		p.skipParens()
+	}
+}
+
+func (p *refParser) addTable(ref TableRef, virtual bool) int {
+	p.tables = append(p.tables, refTable{ref: ref, virtual: virtual})
+	index := len(p.tables) - 1
+	if ref.Name != "" {
+		key := strings.ToLower(ref.Name)
+		if _, exists := p.aliases[key]; !exists {
+			p.aliases[key] = index
+		}
+	}
+	return index
+}
+
+// readColumnList reads "(a, b, c)" and records each name against the table
+func (p *refParser) readColumnList(table int, access AccessMode) {
+	if !p.peek(0).IsPunct("(") || p.peek(1).IsKeyword("SELECT", "WITH", "VALUES") {
+		return
+	}
+	p.i++
+	name := ""
+	if table >= 0 && !p.tables[table].virtual {
+		name = p.tables[table].ref.Name
+	}
+	for p.i < len(p.tokens) && !p.peek(0).IsPunct(")") {
+		tok := p.peek(0)
+		if tok.Kind == TokenWord || tok.Kind == TokenQuotedIdent {
+			p.addResolvedColumn(name, unquoteIdent(tok.Text), access)
+		}
+		p.i++
+	}
+	p.i++
+}
+
+func (p *refParser) mergeTarget() int {
+	for index, table := range p.tables {
+		if table.ref.Access == AccessWrite {
+			return index
+		}
+	}
+	return -1
+}
+
+func (p *refParser) readCTEs() {
+	p.skipWords("RECURSIVE")
+	for p.i < len(p.tokens) {
+		tok := p.peek(0)
+		if tok.Kind != TokenWord && tok.Kind != TokenQuotedIdent {
+			return
+		}
+		name := unquoteIdent(tok.Text)
+		p.ctes[strings.ToLower(name)] = true
+		p.cteOrder = append(p.cteOrder, name)
+		p.i++
+
+		if p.peek(0).IsPunct("(") {
+			p.skipParens()
+		}
+		p.skipWords("AS", "NOT", "MATERIALIZED")
+		if !p.peek(0).IsPunct("(") {
+			return
+		}
+
+		// Let the main loop parse the CTE body as a subquery
+		body := p.i
+		depth := 0
+		for j := body; j < len(p.tokens); j++ {
+			if p.tokens[j].IsPunct("(") {
+				depth++
+			} else if p.tokens[j].IsPunct(")") {
+				depth--
+				if depth == 0 {
+					inner := &refParser{tokens: p.tokens[body+1 : j], ctes: p.ctes, aliases: map[string]int{}}
+					inner.parse()
+					p.absorb(inner)
+					p.i = j + 1
+					break
+				}
+			}
+		}
+		if depth != 0 {
+			p.i = len(p.tokens)
+			return
+		}
+
+		if !p.peek(0).IsPunct(",") {
+			return
+		}
+		p.i++
+	}
+}
+
+// absorb copies the resolved references of a nested parser
+func (p *refParser) absorb(inner *refParser) {
+	for _, table := range inner.tables {
+		if !table.virtual {
+			p.tables = append(p.tables, refTable{ref: table.ref})
+		}
+	}
+	p.resolved = append(p.resolved, inner.resolved...)
+	for _, cte := range inner.cteOrder {
+		if !containsString(p.cteOrder, cte) {
+			p.cteOrder = append(p.cteOrder, cte)
+		}
+	}
+}
+
+func (p *refParser) parseCreate() {
+	f := p.frame()
+	f.clause = clauseNone
+	p.skipWords("OR", "REPLACE", "TEMP", "TEMPORARY", "UNLOGGED", "GLOBAL", "LOCAL", "UNIQUE", "FULLTEXT", "SPATIAL", "MATERIALIZED", "RECURSIVE")
+
+	switch {
+	case p.peek(0).IsKeyword("TABLE"):
+		p.i++
+		index := p.readTableName(AccessAlter)
+		if p.peek(0).IsKeyword("LIKE") {
+			p.i++
+			p.readTableRef(AccessRead)
+			return
+		}
+		if p.peek(0).IsPunct("(") && !p.peek(1).IsKeyword("SELECT", "WITH") {
+			p.parseColumnDefinitions(index)
+		}
+	case p.peek(0).IsKeyword("VIEW"):
+		p.i++
+		p.readTableName(AccessAlter)
+		if p.peek(0).IsPunct("(") {
+			p.skipParens()
+		}
+	case p.peek(0).IsKeyword("INDEX"):
+		p.i++
+		for p.i < len(p.tokens) && !p.peek(0).IsKeyword("ON") {
+			p.i++
+		}
+		p.i++
+		index := p.readTableName(AccessAlter)
+		p.skipWords("USING")
+		if p.peek(0).Kind == TokenWord && !p.peek(0).IsPunct("(") && p.peek(1).IsPunct("(") {
+			p.i++
+		}
+		p.readColumnList(index, AccessAlter)
+	case p.peek(0).IsKeyword("TRIGGER"):
+		for p.i < len(p.tokens) && !p.peek(0).IsKeyword("ON") {
+			p.i++
+		}
+		p.i++
+		p.readTableName(AccessAlter)
+		p.i = len(p.tokens)
+	default:
+		// Functions, schemas, sequences and other objects reference no tables
+		p.i = len(p.tokens)
+	}
+}
+
+var constraintWords = []string{"CONSTRAINT", "PRIMARY", "FOREIGN", "UNIQUE", "CHECK", "INDEX", "KEY", "EXCLUDE", "LIKE", "FULLTEXT", "SPATIAL"}
+
+func (p *refParser) parseColumnDefinitions(table int) {
+	name := ""
+	if table >= 0 {
+		name = p.tables[table].ref.Name
+	}
+
+	p.i++ // (
+	depth := 1
+	elementStart := true
+	for p.i < len(p.tokens) && depth > 0 {
+		tok := p.peek(0)
+		switch {
+		case tok.IsPunct("("):
+			depth++
+			p.i++
+		case tok.IsPunct(")"):
+			depth--
+			p.i++
+		case tok.IsPunct(",") && depth == 1:
+			elementStart = true
+			p.i++
+		case tok.IsKeyword("REFERENCES"):
+			p.i++
+			p.readTableRef(AccessRead)
+		case elementStart && depth == 1:
+			elementStart = false
+			if (tok.Kind == TokenWord || tok.Kind == TokenQuotedIdent) && !tok.IsKeyword(constraintWords...) {
+				p.addResolvedColumn(name, unquoteIdent(tok.Text), AccessAlter)
+			}
+			p.i++
+		default:
+			p.i++
+		}
+	}
+}
+
+func (p *refParser) parseAlter() {
+	f := p.frame()
+	f.clause = clauseNone
+	if !p.peek(0).IsKeyword("TABLE") {
+		p.i = len(p.tokens)
+		return
+	}
+	p.i++
+	index := p.readTableName(AccessAlter)
+	if index < 0 {
+		return
+	}
+	table := p.tables[index].ref.Name
+
+	depth := 0
+	for p.i < len(p.tokens) {
+		tok := p.peek(0)
+		switch {
+		case tok.IsPunct("("):
+			depth++
+			p.i++
+		case tok.IsPunct(")"):
+			depth--
+			p.i++
+		case depth > 0:
+			p.i++
+		case tok.IsKeyword("REFERENCES"):
+			p.i++
+			p.readTableRef(AccessRead)
+		case tok.IsKeyword("ADD", "DROP", "ALTER", "MODIFY", "CHANGE"):
+			p.i++
+			if p.peek(0).IsKeyword(constraintWords...) {
+				continue
+			}
+			p.skipWords("COLUMN", "IF", "NOT", "EXISTS")
+			if c := p.peek(0); c.Kind == TokenWord || c.Kind == TokenQuotedIdent {
+				p.addResolvedColumn(table, unquoteIdent(c.Text), AccessAlter)
+				p.i++
+				if tok.IsKeyword("CHANGE") {
+					if n := p.peek(0); n.Kind == TokenWord || n.Kind == TokenQuotedIdent {
+						p.addResolvedColumn(table, unquoteIdent(n.Text), AccessAlter)
+						p.i++
+					}
+				}
+			}
+		case tok.IsKeyword("RENAME"):
+			p.i++
+			switch {
+			case p.peek(0).IsKeyword("COLUMN") || (p.peek(0).Kind != TokenWord || !p.peek(0).IsKeyword("TO", "AS", "INDEX", "KEY", "CONSTRAINT")):
+				p.skipWords("COLUMN")
+				if c := p.peek(0); c.Kind == TokenWord || c.Kind == TokenQuotedIdent {
+					p.addResolvedColumn(table, unquoteIdent(c.Text), AccessAlter)
+					p.i++
+					p.skipWords("TO")
+					if n := p.peek(0); n.Kind == TokenWord || n.Kind == TokenQuotedIdent {
+						p.addResolvedColumn(table, unquoteIdent(n.Text), AccessAlter)
+						p.i++
+					}
+				}
+			case p.peek(0).IsKeyword("TO", "AS"):
+				p.i++
+				p.readTableName(AccessAlter)
+			default:
+				p.i++
+			}
+		default:
+			p.i++
+		}
+	}
+}
+
+func (p *refParser) parseDrop() {
+	f := p.frame()
+	f.clause = clauseNone
+	p.skipWords("TEMPORARY", "MATERIALIZED")
+	switch {
+	case p.peek(0).IsKeyword("TABLE", "VIEW"):
+		p.i++
+		p.skipWords("IF", "EXISTS")
+		p.readTableList(AccessAlter)
+	case p.peek(0).IsKeyword("INDEX"):
+		// MySQL: DROP INDEX name ON table
+		for p.i < len(p.tokens) && !p.peek(0).IsKeyword("ON") {
+			p.i++
+		}
+		if p.i < len(p.tokens) {
+			p.i++
+			p.readTableName(AccessAlter)
+		}
+	}
+	p.i = len(p.tokens)
+}
+
+func (p *refParser) skipWords(words ...string) {
+	for p.i < len(p.tokens) && p.peek(0).IsKeyword(words...) {
+		p.i++
+	}
+}
+
+func (p *refParser) skipParens() {
+	depth := 0
+	for p.i < len(p.tokens) {
+		tok := p.peek(0)
+		p.i++
+		if tok.IsPunct("(") {
+			depth++
+		} else if tok.IsPunct(")") {
+			depth--
+			if depth == 0 {
+				return
+			}
+		}
+	}
+}
+
+// resolveFrame attributes unqualified columns to the frame's only table
+func (p *refParser) resolveFrame(f *refFrame) {
+	table := ""
+	if len(f.tables) == 1 && !p.tables[f.tables[0]].virtual {
+		table = p.tables[f.tables[0]].ref.Name
+	}
+	for _, use := range f.columns {
+		p.addResolvedColumn(table, use.name, use.access)
+	}
+	f.columns = nil
+}
+
+// resolveQualified maps alias-qualified columns to their tables
+func (p *refParser) resolveQualified() {
+	for _, use := range p.qualified {
+		index, ok := p.aliases[strings.ToLower(use.qualifier)]
+		if !ok {
+			p.addResolvedColumn(use.qualifier, use.name, use.access)
+			continue
+		}
+		if p.tables[index].virtual {
+			continue
+		}
+		p.addResolvedColumn(p.tables[index].ref.Name, use.name, use.access)
+	}
+}
+
+func (p *refParser) addResolvedColumn(table, name string, access AccessMode) {
+	p.resolved = append(p.resolved, ColumnRef{Table: table, Name: name, Access: access})
+}
+
+// mergeInto appends the statement's references to refs without duplicates
+func (p *refParser) mergeInto(refs *References) {
+	for _, table := range p.tables {
+		if table.virtual || table.ref.Name == "" {
+			continue
+		}
+		if !containsTable(refs.Tables, table.ref) {
+			refs.Tables = append(refs.Tables, table.ref)
+		}
+	}
+	for _, column := range p.resolved {
+		if !containsColumn(refs.Columns, column) {
+			refs.Columns = append(refs.Columns, column)
+		}
+	}
+	for _, cte := range p.cteOrder {
+		if !containsString(refs.CTEs, cte) {
+			refs.CTEs = append(refs.CTEs, cte)
+		}
+	}
+}
+
+func tableFromParts(parts []string, access AccessMode) TableRef {
+	ref := TableRef{Name: parts[len(parts)-1], Access: access}
+	if len(parts) > 1 {
+		ref.Schema = parts[len(parts)-2]
+	}
+	return ref
+}
+
+// unquoteIdent strips identifier quoting and undoubles escaped quotes
+func unquoteIdent(text string) string {
+	if len(text) >= 2 {
+		quote := text[0]
+		if (quote == '"' || quote == '`') && text[len(text)-1] == quote {
+			return strings.ReplaceAll(text[1:len(text)-1], string([]byte{quote, quote}), string(quote))
+		}
+	}
+	return text
+}
+
+func containsTable(tables []TableRef, ref TableRef) bool {
+	for _, t := range tables {
+		if t == ref {
+			return true
+		}
+	}
+	return false
+}
+
+func containsColumn(columns []ColumnRef, ref ColumnRef) bool {
+	for _, c := range columns {
+		if c == ref {
+			return true
+		}
+	}
+	return false
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
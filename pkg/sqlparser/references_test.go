@@ -0,0 +1,105 @@
+package sqlparser
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExtractReferences(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   string
+		dialect Dialect
+		tables  []TableRef
+		columns []ColumnRef
+		ctes    []string
+	}{
+		{
+			"join with aliases",
+			"SELECT u.name, o.total FROM users u JOIN orders o ON o.user_id = u.id",
+			DialectPostgres,
+			[]TableRef{{Name: "users", Alias: "u", Access: AccessRead}, {Name: "orders", Alias: "o", Access: AccessRead}},
+			[]ColumnRef{{Table: "users", Name: "name", Access: AccessRead}, {Table: "orders", Name: "total", Access: AccessRead}, {Table: "orders", Name: "user_id", Access: AccessRead}, {Table: "users", Name: "id", Access: AccessRead}},
+			nil,
+		},
+		{
+			"delete in cte",
+			"WITH d AS (DELETE FROM orders WHERE id = 1 RETURNING *) SELECT * FROM d",
+			DialectPostgres,
+			[]TableRef{{Name: "orders", Access: AccessDelete}},
+			[]ColumnRef{{Table: "orders", Name: "id", Access: AccessRead}},
+			[]string{"d"},
+		},
+		{
+			"update",
+			"UPDATE users SET name = 'x' WHERE id = 1",
+			DialectPostgres,
+			[]TableRef{{Name: "users", Access: AccessWrite}},
+			[]ColumnRef{{Table: "users", Name: "name", Access: AccessWrite}, {Table: "users", Name: "id", Access: AccessRead}},
+			nil,
+		},
+		{
+			"insert",
+			"INSERT INTO logs (msg, level) VALUES ('x', 1)",
+			DialectMySQL,
+			[]TableRef{{Name: "logs", Access: AccessWrite}},
+			[]ColumnRef{{Table: "logs", Name: "msg", Access: AccessWrite}, {Table: "logs", Name: "level", Access: AccessWrite}},
+			nil,
+		},
+		{
+			"derived table",
+			"SELECT s.x FROM (SELECT ssn AS x FROM users) s",
+			DialectPostgres,
+			[]TableRef{{Name: "users", Access: AccessRead}},
+			[]ColumnRef{{Table: "users", Name: "ssn", Access: AccessRead}},
+			nil,
+		},
+		{
+			"mysql multi-table delete",
+			"DELETE t1 FROM t1 JOIN t2 ON t1.id = t2.id",
+			DialectMySQL,
+			[]TableRef{{Name: "t1", Access: AccessDelete}, {Name: "t2", Access: AccessRead}},
+			[]ColumnRef{{Table: "t1", Name: "id", Access: AccessRead}, {Table: "t2", Name: "id", Access: AccessRead}},
+			nil,
+		},
+		{
+			"alter",
+			"ALTER TABLE users ADD COLUMN age int",
+			DialectPostgres,
+			[]TableRef{{Name: "users", Access: AccessAlter}},
+			[]ColumnRef{{Table: "users", Name: "age", Access: AccessAlter}},
+			nil,
+		},
+		{
+			"drop with schema",
+			"DROP TABLE IF EXISTS public.users",
+			DialectPostgres,
+			[]TableRef{{Schema: "public", Name: "users", Access: AccessAlter}},
+			[]ColumnRef{},
+			nil,
+		},
+		{
+			"names in strings and comments",
+			"SELECT 'FROM secrets' /* JOIN keys */ FROM users",
+			DialectPostgres,
+			[]TableRef{{Name: "users", Access: AccessRead}},
+			[]ColumnRef{},
+			nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			refs := ExtractReferences(tt.query, tt.dialect)
+			if !reflect.DeepEqual(refs.Tables, tt.tables) {
+				t.Errorf("tables = %+v, want %+v", refs.Tables, tt.tables)
+			}
+			if !reflect.DeepEqual(refs.Columns, tt.columns) {
+				t.Errorf("columns = %+v, want %+v", refs.Columns, tt.columns)
+			}
+			if !reflect.DeepEqual(refs.CTEs, tt.ctes) {
+				t.Errorf("ctes = %v, want %v", refs.CTEs, tt.ctes)
+			}
+		})
+	}
+}
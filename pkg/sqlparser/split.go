@@ -0,0 +1,62 @@
+// pkg/sqlparser/split.go
+package sqlparser
+
+import (
+	"strings"
+)
+
+// Statement is a single statement extracted from a multi-statement query
+type Statement struct {
+	Text  string    `json:"text"`
+	Start int       `json:"start"` // byte offset in the original query
+	End   int       `json:"end"`
+	Type  QueryType `json:"type"`
+}
+
+// SplitStatements splits a query on semicolons that are not inside string
+// literals, quoted identifiers, dollar-quoted bodies or comments. Empty
+// statements and statements consisting only of comments are dropped.
+func SplitStatements(query string, dialect Dialect) []Statement {
+	var statements []Statement
+
+	tokens := Tokenize(query, dialect)
+	start := -1
+	end := -1
+
+	flush := func() {
+		if start >= 0 {
+			text := strings.TrimSpace(query[start:end])
+			statements = append(statements, Statement{
+				Text:  text,
+				Start: start,
+				End:   end,
+				Type:  GetQueryType(text, dialect),
+			})
+		}
+		start, end = -1, -1
+	}
+
+	for _, tok := range tokens {
+		if tok.IsPunct(";") {
+			flush()
+			continue
+		}
+		if tok.Kind == TokenComment && !tok.IsExecCommentStart() && !tok.IsExecCommentEnd() {
+			// Comments are only kept when they sit between statement tokens;
+			// the markers of executable comments belong to the statement
+			continue
+		}
+		if start < 0 {
+			start = tok.Pos
+		}
+		end = tok.End
+	}
+	flush()
+
+	return statements
+}
+
+// IsMultiStatement reports whether the query contains more than one statement
+func IsMultiStatement(query string, dialect Dialect) bool {
+	return len(SplitStatements(query, dialect)) > 1
+}
@@ -0,0 +1,47 @@
+package sqlparser
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitStatements(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   string
+		dialect Dialect
+		want    []string
+	}{
+		{"single", "SELECT 1", DialectPostgres, []string{"SELECT 1"}},
+		{"trailing semicolon", "SELECT 1;", DialectPostgres, []string{"SELECT 1"}},
+		{"two statements", "SELECT 1; DROP TABLE users", DialectPostgres, []string{"SELECT 1", "DROP TABLE users"}},
+		{"semicolon in string", "SELECT ';'; SELECT 2", DialectPostgres, []string{"SELECT ';'", "SELECT 2"}},
+		{"semicolon in dollar quote", "CREATE FUNCTION f() RETURNS void AS $$ BEGIN; END $$ LANGUAGE sql; SELECT 1", DialectPostgres,
+			[]string{"CREATE FUNCTION f() RETURNS void AS $$ BEGIN; END $$ LANGUAGE sql", "SELECT 1"}},
+		{"semicolon in block comment", "SELECT 1 /* ; DROP TABLE users */", DialectPostgres, []string{"SELECT 1"}},
+		{"semicolon in line comment", "SELECT 1 -- ; DROP TABLE users", DialectPostgres, []string{"SELECT 1"}},
+		{"semicolon in quoted identifier", `SELECT "a;b" FROM t; SELECT 2`, DialectPostgres, []string{`SELECT "a;b" FROM t`, "SELECT 2"}},
+		{"comment between statements", "SELECT 1; -- note\nSELECT 2", DialectPostgres, []string{"SELECT 1", "SELECT 2"}},
+		{"only comments", "-- a\n/* b */", DialectPostgres, nil},
+		{"empty statements", ";; SELECT 1 ;;", DialectPostgres, []string{"SELECT 1"}},
+		{"executable comment", "SELECT 1 /*!50000 ; DROP TABLE users */", DialectMySQL, []string{"SELECT 1 /*!50000", "DROP TABLE users */"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, statement := range SplitStatements(tt.query, tt.dialect) {
+				got = append(got, statement.Text)
+				if statement.Text != tt.query[statement.Start:statement.End] {
+					t.Errorf("statement %q does not match its offsets %d-%d", statement.Text, statement.Start, statement.End)
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("SplitStatements(%q) = %q, want %q", tt.query, got, tt.want)
+			}
+			if multi := IsMultiStatement(tt.query, tt.dialect); multi != (len(tt.want) > 1) {
+				t.Errorf("IsMultiStatement(%q) = %v", tt.query, multi)
+			}
+		})
+	}
+}